@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/analytics"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+// HandleGetAnalytics answers the occupancy and revenue figures of a hotel per
+// period and room type. Rooms deleted since still count until their deletion.
+func (h *Handler) HandleGetAnalytics(c *fiber.Ctx) error {
+	req, ok := c.Locals(analyticsRequestKey).(*analyticsRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", analyticsRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	hotel, err := h.hotelStore.GetHotelByID(c.UserContext(), req.HotelID)
+	if err != nil {
+		return storeError(err, "Error getting analytics")
+	}
+	roomIDs, err := h.roomStore.GetRoomIDsByHotelID(c.UserContext(), req.HotelID)
+	if err != nil {
+		return storeError(err, "Error getting analytics")
+	}
+	rooms, err := h.roomStore.GetRoomsByIDs(c.UserContext(), roomIDs)
+	if err != nil {
+		return storeError(err, "Error getting analytics")
+	}
+
+	params := &types.AnalyticsParam{From: req.FromDate, Till: req.TillDate, Period: req.Period}
+	facts, err := h.bookingStore.GetBookingFacts(c.UserContext(), roomIDs, params)
+	if err != nil {
+		return storeError(err, "Error getting analytics")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   analytics.Summarize(hotel.ID, params, rooms, facts),
+		Status: fiber.StatusOK,
+	})
+}
@@ -0,0 +1,221 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalytics(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest           = fixtures.AddUser(*tdb.Store, "stats", "guest", false)
+		manager         = fixtures.AddUser(*tdb.Store, "revenue", "manager", false)
+		admin           = fixtures.AddUser(*tdb.Store, "stats", "admin", true)
+		hotel           = fixtures.AddHotel(*tdb.Store, "stats hotel", "Oslo", 4, nil)
+		king            = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 100)
+		suite           = fixtures.AddRoom(*tdb.Store, types.SuiteRoomType, hotel.ID, 300)
+		monday          = time.Date(2030, 3, 4, 0, 0, 0, 0, time.UTC)
+		guestToken, _   = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		managerToken, _ = tokener.GenerateJWT(manager.ID.Hex(), manager.IsAdmin, config)
+		adminToken, _   = tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+		target          = fmt.Sprintf("/v1/analytics/hotels/%s?fromDate=%s&tillDate=%s&period=week",
+			hotel.ID.Hex(), monday.Format(time.DateOnly), monday.AddDate(0, 0, 14).Format(time.DateOnly))
+	)
+	fixtures.AddBooking(*tdb.Store, guest.ID, king.ID.Hex(), monday.Add(14*time.Hour), monday.AddDate(0, 0, 3).Add(11*time.Hour))
+	fixtures.AddBooking(*tdb.Store, guest.ID, suite.ID.Hex(), monday.AddDate(0, 0, 6).Add(14*time.Hour), monday.AddDate(0, 0, 8).Add(11*time.Hour))
+
+	get := func(t *testing.T, token string) *http.Response {
+		t.Helper()
+		testReq := utils.TestRequest{Method: "GET", Target: target, Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("guests and plain users are refused", func(t *testing.T) {
+		if resp := get(t, guestToken); resp.StatusCode != fiber.StatusForbidden {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusForbidden, resp.StatusCode)
+		}
+	})
+
+	t.Run("an admin grants the revenue manager role", func(t *testing.T) {
+		b, _ := json.Marshal(types.SetRolesParams{Roles: []types.Role{types.RoleRevenueManager}})
+		testReq := utils.TestRequest{
+			Method:  "PUT",
+			Target:  "/v1/admin/users/" + manager.ID.Hex() + "/roles",
+			Token:   adminToken,
+			Payload: bytes.NewReader(b),
+		}
+		req := testReq.NewRequestWithHeader()
+		req.Header.Set(fiber.HeaderIfMatch, fmt.Sprintf(`"%d"`, manager.Version))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("weekly figures per room type", func(t *testing.T) {
+		resp := get(t, managerToken)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var result types.HotelAnalytics
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Periods) != 2 {
+			t.Fatalf("expected 2 weeks but received %d", len(result.Periods))
+		}
+		first, second := result.Periods[0], result.Periods[1]
+		// 3 king nights and the sunday night in the suite
+		if first.RoomNights != 14 || first.NightsSold != 4 || first.Revenue != 3*10000+30000 {
+			t.Fatalf("unexpected first week %+v", first.AnalyticsFigures)
+		}
+		if first.Arrivals != 2 || first.AverageStay != 2.5 {
+			t.Fatalf("unexpected arrivals %+v", first.AnalyticsFigures)
+		}
+		if second.NightsSold != 1 || second.RoomTypes[1].Type != types.SuiteRoomType || second.RoomTypes[1].NightsSold != 1 {
+			t.Fatalf("unexpected second week %+v", second.RoomTypes)
+		}
+		if result.Total.Occupancy != roundRatio(5.0/28) {
+			t.Fatalf("expected an occupancy of 5 over 28 nights, got %v", result.Total.Occupancy)
+		}
+	})
+
+	t.Run("revenue keeps the rate the nights were booked at", func(t *testing.T) {
+		if _, err := tdb.db.Collection("rooms").UpdateByID(t.Context(), king.ID, bson.M{"$set": bson.M{"price": 150}}); err != nil {
+			t.Fatal(err)
+		}
+
+		resp := get(t, managerToken)
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var result types.HotelAnalytics
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if first := result.Periods[0]; first.Revenue != 3*10000+30000 {
+			t.Fatalf("expected the booked rates, got %+v", first.AnalyticsFigures)
+		}
+	})
+
+	t.Run("a deleted room keeps its nights", func(t *testing.T) {
+		deletedHotel := fixtures.AddHotel(*tdb.Store, "renovated stats hotel", "Oslo", 4, nil)
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, deletedHotel.ID, 100)
+		fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), monday.Add(14*time.Hour), monday.AddDate(0, 0, 2).Add(11*time.Hour))
+		if err := tdb.Store.Room.DeleteRoom(t.Context(), room.ID.Hex()); err != nil {
+			t.Fatal(err)
+		}
+
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: fmt.Sprintf("/v1/analytics/hotels/%s?fromDate=%s&tillDate=%s&period=week",
+				deletedHotel.ID.Hex(), monday.Format(time.DateOnly), monday.AddDate(0, 0, 7).Format(time.DateOnly)),
+			Token: managerToken,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var result types.HotelAnalytics
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Total.NightsSold != 2 || result.Total.Revenue != 2*10000 || result.Total.Arrivals != 1 {
+			t.Fatalf("expected the 2 nights of the deleted room, got %+v", result.Total)
+		}
+	})
+
+	t.Run("the range is checked", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: fmt.Sprintf("/v1/analytics/hotels/%s?fromDate=2030-01-01&tillDate=2031-06-01", hotel.ID.Hex()),
+			Token:  managerToken,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("a split stay is one arrival", func(t *testing.T) {
+		splitHotel := fixtures.AddHotel(*tdb.Store, "split stats hotel", "Oslo", 4, nil)
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, splitHotel.ID, 100)
+		next := fixtures.AddRoom(*tdb.Store, types.KingRoomType, splitHotel.ID, 100)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), monday.Add(14*time.Hour), monday.AddDate(0, 0, 4).Add(11*time.Hour))
+		if _, _, err := tdb.Store.Booking.MoveBooking(t.Context(), &types.MoveBookingParam{
+			BookingID: booking.ID.Hex(),
+			RoomID:    next.ID.Hex(),
+			Date:      monday.AddDate(0, 0, 2).Add(14 * time.Hour),
+			MovedBy:   admin.ID,
+			Version:   booking.Version,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: fmt.Sprintf("/v1/analytics/hotels/%s?fromDate=%s&tillDate=%s&period=week",
+				splitHotel.ID.Hex(), monday.Format(time.DateOnly), monday.AddDate(0, 0, 7).Format(time.DateOnly)),
+			Token: managerToken,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var result types.HotelAnalytics
+		if err := json.Unmarshal(b, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Total.NightsSold != 4 || result.Total.Arrivals != 1 || result.Total.AverageStay != 4 {
+			t.Fatalf("expected one stay of 4 nights, got %+v", result.Total)
+		}
+	})
+}
+
+func roundRatio(v float64) float64 {
+	return float64(int64(v*10000+0.5)) / 10000
+}
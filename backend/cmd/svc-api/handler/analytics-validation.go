@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	analyticsRequestKey = "analyticsReq"
+	// maxAnalyticsRange keeps a daily breakdown to a year of rows
+	maxAnalyticsRange = 366 * 24 * time.Hour
+)
+
+// analyticsRequest covers the nights from fromDate up to tillDate excluded,
+// both as YYYY-MM-DD. The figures are grouped by day unless asked otherwise.
+type analyticsRequest struct {
+	HotelID  string                `validate:"required,id" query:"-"`
+	FromDate time.Time             `validate:"required" query:"fromDate"`
+	TillDate time.Time             `validate:"required,gtfield=FromDate" query:"tillDate"`
+	Period   types.AnalyticsPeriod `validate:"required,oneof=day week month" query:"period"`
+}
+
+func AnalyticsRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	req := &analyticsRequest{
+		HotelID: c.Params("hotelID"),
+		Period:  types.AnalyticsPeriod(c.Query("period", string(types.AnalyticsDay))),
+	}
+
+	for key, date := range map[string]*time.Time{"fromDate": &req.FromDate, "tillDate": &req.TillDate} {
+		if c.Query(key) == "" {
+			continue
+		}
+		day, err := queryDay(c, key)
+		if err != nil {
+			return nil, analyticsRequestKey, err
+		}
+		*date = day
+	}
+	if req.TillDate.Sub(req.FromDate) > maxAnalyticsRange {
+		return nil, analyticsRequestKey, utils.BadRequestError("the range can not be longer than 366 days")
+	}
+
+	return req, analyticsRequestKey, nil
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func (h *Handler) HandleGetAuditEntries(c *fiber.Ctx) error {
+	req, ok := c.Locals(getAuditRequestKey).(*types.GetAuditRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getAuditRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	entries, total, err := h.auditStore.GetAuditEntries(c.UserContext(), req)
+	if err != nil {
+		return storeError(err, "Error getting audit entries")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
+		ResGeneric: types.ResGeneric{
+			Data:   entries,
+			Status: fiber.StatusOK,
+		},
+		Pagination: types.ResNumericPaginate{
+			Count: total,
+			Page:  req.Page,
+			Limit: int(req.Limit),
+		},
+	})
+}
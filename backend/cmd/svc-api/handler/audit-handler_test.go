@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func TestAuditLog(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user    = fixtures.AddUser(*tdb.Store, "audit", "user", false)
+		admin   = fixtures.AddUser(*tdb.Store, "audit", "admin", true)
+		hotel   = fixtures.AddHotel(*tdb.Store, "audit hotel", "a", 4, nil)
+		room    = fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 10.99)
+		from    = time.Now().AddDate(0, 0, 1)
+		booking = fixtures.AddBooking(*tdb.Store, user.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+	)
+	adminToken, _ := tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+
+	b, _ := json.Marshal(map[string]string{"reason": "guest called the front desk"})
+	cancelReq := utils.TestRequest{
+		Method:  "PUT",
+		Target:  "/v1/bookings/" + booking.ID.Hex() + "/cancel",
+		Payload: bytes.NewReader(b),
+		Token:   adminToken,
+	}
+	resp, err := app.Test(cancelReq.NewRequestWithHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+	}
+
+	t.Run("restrict_for_non_admin_user", func(t *testing.T) {
+		token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		testReq := utils.TestRequest{Method: "GET", Target: "/v1/admin/audit", Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Fatalf("expected 403 status code but received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("records who canceled the booking and why", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: fmt.Sprintf("/v1/admin/audit?targetID=%s&action=%s", booking.ID.Hex(), types.AuditBookingCanceled),
+			Token:  adminToken,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+
+		var response struct {
+			Data []types.AuditEntry `json:"data"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(response.Data) != 1 {
+			t.Fatalf("expected one audit entry, got %d", len(response.Data))
+		}
+		entry := response.Data[0]
+		if entry.ActorID != admin.ID || !entry.ActorAdmin {
+			t.Errorf("expected the admin %s as actor, got %s", admin.ID.Hex(), entry.ActorID.Hex())
+		}
+		if entry.Reason != "guest called the front desk" {
+			t.Errorf("unexpected reason %q", entry.Reason)
+		}
+		if entry.RequestID == "" {
+			t.Errorf("expected the request id to be recorded")
+		}
+		if change, ok := entry.Changes["canceled"]; !ok || change.After != true {
+			t.Errorf("expected the canceled flag in the changes, got %v", entry.Changes)
+		}
+	})
+
+	t.Run("rejects malformed dates", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/v1/admin/audit?from=yesterday", Token: adminToken}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var problem types.Problem
+		if err = json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest || problem.Code != "bad_request" {
+			t.Errorf("expected 400 bad_request, got %d %s", resp.StatusCode, problem.Code)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	getAuditRequestKey = "getAuditReq"
+)
+
+func GetAuditRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, getAuditRequestKey, err
+	}
+	till, err := queryTime(c, "till")
+	if err != nil {
+		return nil, getAuditRequestKey, err
+	}
+
+	return &types.GetAuditRequest{
+		ActorID:              c.Query("actorID"),
+		Action:               types.AuditAction(c.Query("action")),
+		TargetType:           types.AuditTarget(c.Query("targetType")),
+		TargetID:             c.Query("targetID"),
+		From:                 from,
+		Till:                 till,
+		QueryNumericPaginate: types.NewQueryNumericPaginate(c.QueryInt("limit", defaultReadLimit), c.QueryInt("page", 1)),
+	}, getAuditRequestKey, nil
+}
+
+// queryTime reads an optional RFC 3339 timestamp from the query string.
+func queryTime(c *fiber.Ctx, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, utils.BadRequestError(key + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// queryDay reads a YYYY-MM-DD day from the query string, today in UTC when
+// missing.
+func queryDay(c *fiber.Ctx, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, utils.BadRequestError(key + " must be formatted as YYYY-MM-DD")
+	}
+	return day, nil
+}
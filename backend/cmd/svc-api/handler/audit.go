@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	mid "github.com/tnguven/hotel-reservation-app/internals/middleware"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+// audit appends the entry of a mutation that already succeeded, filling in the
+// actor, the origin of the request and the diff between before and after.
+// The change is committed at this point so a failure is logged, not returned.
+func (h *Handler) audit(c *fiber.Ctx, entry *types.AuditEntry, before, after any) {
+	if h.auditStore == nil {
+		return
+	}
+
+	if user, ok := c.Locals("user").(*types.User); ok {
+		entry.ActorID = user.ID
+		entry.ActorAdmin = user.IsAdmin
+	}
+	entry.IP = c.IP()
+	entry.RequestID = c.GetRespHeader(mid.RequestIDHeader)
+
+	changes, err := types.AuditDiff(before, after)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "audit diff failed", "action", entry.Action, logger.Err(err))
+	}
+	entry.Changes = changes
+
+	if err := h.auditStore.Append(c.UserContext(), entry); err != nil {
+		slog.ErrorContext(c.UserContext(), "audit append failed",
+			"action", entry.Action,
+			"targetID", entry.TargetID.Hex(),
+			logger.Err(err),
+		)
+	}
+}
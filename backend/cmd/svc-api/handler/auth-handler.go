@@ -2,13 +2,15 @@ package handler
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/tokener"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type (
@@ -26,7 +28,7 @@ func (h *Handler) HandleAuthenticate(configs tokener.JWTConfigs) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authReqParams, ok := c.Locals(authRequestKey).(*authRequest)
 		if !ok {
-			log.Printf("something went wrong to get the authReqParams")
+			slog.ErrorContext(c.UserContext(), "locals field missing", "key", authRequestKey)
 			return utils.BadRequestError("")
 		}
 		params := types.AuthParams{
@@ -34,15 +36,17 @@ func (h *Handler) HandleAuthenticate(configs tokener.JWTConfigs) fiber.Handler {
 			Password: authReqParams.Password,
 		}
 
-		user, err := h.userStore.GetUserByEmail(c.Context(), params.Email)
+		user, err := h.userStore.GetUserByEmail(c.UserContext(), params.Email)
 		if err != nil {
-			if errors.Is(err, mongo.ErrNoDocuments) {
+			if errors.Is(err, store.ErrNotFound) {
+				metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidCredentials).Inc()
 				return utils.InvalidCredError()
 			}
 			return types.NewError(err, fiber.StatusInternalServerError, "")
 		}
 
 		if !params.IsValidPassword(user.EncryptedPassword) {
+			metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidCredentials).Inc()
 			return utils.InvalidCredError()
 		}
 
@@ -64,26 +68,28 @@ func (h *Handler) HandleAuthenticate(configs tokener.JWTConfigs) fiber.Handler {
 func (h *Handler) HandleSignIn(c *fiber.Ctx) error {
 	params, ok := c.Locals(insertUserRequestKey).(*types.CreateUserParams)
 	if !ok {
-		log.Println("insertUserRequest local missing")
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", insertUserRequestKey)
 		return utils.BadRequestError("")
 	}
 
 	user, err := types.NewUserFromParams(params)
 	if err != nil {
-		log.Printf("new user from params failed: %v", err)
+		slog.ErrorContext(c.UserContext(), "new user from params failed", logger.Err(err))
 		return utils.InternalServerError("")
 	}
 
-	insertedUser, err := h.userStore.InsertUser(c.Context(), user)
+	insertedUser, err := h.userStore.InsertUser(c.UserContext(), user)
 	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			return utils.ConflictError("email already exist")
-		}
-
-		log.Printf("userStore.InserUser failed: %v", err)
-		return utils.InternalServerError("can not inset the new user...")
+		return storeError(err, "can not inset the new user...")
 	}
 
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserCreated,
+		TargetType: types.AuditTargetUser,
+		TargetID:   insertedUser.ID,
+	}, nil, insertedUser)
+
+	setETag(c, insertedUser.Version)
 	return c.Status(fiber.StatusCreated).JSON(&types.ResGeneric{
 		Data:   insertedUser,
 		Status: fiber.StatusCreated,
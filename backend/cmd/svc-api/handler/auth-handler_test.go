@@ -39,7 +39,7 @@ func TestHandleAuthenticate(t *testing.T) {
 		type test struct {
 			desc     string
 			input    *types.AuthParams
-			expected *types.Error
+			expected *types.Problem
 			status   int
 		}
 
@@ -47,13 +47,13 @@ func TestHandleAuthenticate(t *testing.T) {
 			{
 				desc:  "should return invalid email error",
 				input: invalidEmail,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email": "email - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email": "email - invalid",
 					},
 				},
 				status: 400,
@@ -61,13 +61,13 @@ func TestHandleAuthenticate(t *testing.T) {
 			{
 				desc:  "should return invalid password error",
 				input: invalidPassword,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Password": "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Password": "min - invalid",
 					},
 				},
 				status: 400,
@@ -75,14 +75,14 @@ func TestHandleAuthenticate(t *testing.T) {
 			{
 				desc:  "should return invalid password error",
 				input: invalidBoth,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email":    "email - invalid",
-							"Password": "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email":    "email - invalid",
+						"Password": "min - invalid",
 					},
 				},
 				status: 400,
@@ -111,7 +111,7 @@ func TestHandleAuthenticate(t *testing.T) {
 
 			t.Run(test.desc, func(t *testing.T) {
 				t.Parallel()
-				var body types.Error
+				var body types.Problem
 				if errDecode := json.NewDecoder(resp.Body).Decode(&body); errDecode != nil {
 					t.Fatal(errDecode)
 				}
@@ -196,15 +196,19 @@ func TestHandleAuthenticate(t *testing.T) {
 			t.Fatalf("expected http status of 400 but got %d", resp.StatusCode)
 		}
 
-		var result types.Error
+		if contentType := resp.Header.Get("Content-Type"); contentType != types.ProblemContentType {
+			t.Fatalf("expected %s content type but received: %s", types.ProblemContentType, contentType)
+		}
+
+		var result types.Problem
 		if decodeErr := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			t.Fatal(decodeErr)
 		}
 		if result.Status != fiber.StatusBadRequest {
 			t.Fatalf("expected to get status code %d but received: %d", fiber.StatusBadRequest, result.Status)
 		}
-		if result.Msg != "invalid credentials" {
-			t.Fatalf("expected to get msg invalid credential but received: %s", result.Msg)
+		if result.Code != "invalid_credentials" {
+			t.Fatalf("expected to get code invalid_credentials but received: %s", result.Code)
 		}
 	})
 }
@@ -220,7 +224,7 @@ func TestHandleSignin(t *testing.T) {
 		type test struct {
 			desc     string
 			input    *types.CreateUserParams
-			expected *types.Error
+			expected *types.Problem
 			status   int
 		}
 
@@ -260,16 +264,16 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return all required fields error",
 				input:  partialInput,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email":     "required",
-							"FirstName": "required",
-							"LastName":  "required",
-							"Password":  "required",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email":     "required",
+						"FirstName": "required",
+						"LastName":  "required",
+						"Password":  "required",
 					},
 				},
 			},
@@ -277,13 +281,13 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return invalid email field error",
 				input:  invalidEmail,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email": "email - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email": "email - invalid",
 					},
 				},
 			},
@@ -291,14 +295,14 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName minimum field error",
 				input:  invalidMinNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "min - invalid",
-							"LastName":  "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "min - invalid",
+						"LastName":  "min - invalid",
 					},
 				},
 			},
@@ -306,14 +310,14 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidMaxNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "max - invalid",
-							"LastName":  "max - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "max - invalid",
+						"LastName":  "max - invalid",
 					},
 				},
 			},
@@ -321,14 +325,14 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidAlphaNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "alpha - invalid",
-							"LastName":  "alpha - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "alpha - invalid",
+						"LastName":  "alpha - invalid",
 					},
 				},
 			},
@@ -336,13 +340,13 @@ func TestHandleSignin(t *testing.T) {
 				desc:   "Should return invalid password min field error",
 				input:  invalidPassword,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Password": "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Password": "min - invalid",
 					},
 				},
 			},
@@ -369,7 +373,7 @@ func TestHandleSignin(t *testing.T) {
 
 			t.Run(tc.desc, func(t *testing.T) {
 				t.Parallel()
-				var body types.Error
+				var body types.Problem
 				if errDecode := json.NewDecoder(resp.Body).Decode(&body); errDecode != nil {
 					t.Fatal(errDecode)
 				}
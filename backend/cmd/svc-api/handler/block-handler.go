@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func (h *Handler) HandlePostRoomBlock(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(roomBlockRequestKey).(*roomBlockRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", roomBlockRequestKey)
+		return utils.BadRequestError("")
+	}
+	req.params.CreatedBy = user.ID
+
+	block, err := h.blockStore.InsertBlock(c.UserContext(), req.params)
+	if err != nil {
+		return storeError(err, "Error blocking room")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditRoomBlocked,
+		TargetType: types.AuditTargetRoom,
+		TargetID:   block.RoomID,
+		Reason:     block.Reason,
+	}, nil, block)
+
+	return c.Status(fiber.StatusCreated).JSON(&types.ResGeneric{
+		Data:   block,
+		Status: fiber.StatusCreated,
+	})
+}
+
+func (h *Handler) HandleGetRoomBlocks(c *fiber.Ctx) error {
+	blocks, err := h.blockStore.GetBlocksByRoomID(c.UserContext(), c.Params("roomID"))
+	if err != nil {
+		return storeError(err, "Error getting room blocks")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   blocks,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleDeleteRoomBlock(c *fiber.Ctx) error {
+	blockID := c.Params("blockID")
+	block, err := h.blockStore.DeleteBlock(c.UserContext(), c.Params("roomID"), blockID)
+	if err != nil {
+		return storeError(err, "Error deleting room block")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditRoomUnblocked,
+		TargetType: types.AuditTargetRoom,
+		TargetID:   block.RoomID,
+	}, block, nil)
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    "block " + blockID + " has been deleted",
+		Status: fiber.StatusOK,
+	})
+}
@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+func TestRoomBlocks(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "blocked", "guest", false)
+		admin         = fixtures.AddUser(*tdb.Store, "block", "admin", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "renovated hotel", "Graz", 3, nil)
+		guestToken, _ = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		adminToken, _ = tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+	)
+
+	block := func(t *testing.T, room *types.Room, from, till time.Time) *types.RoomBlock {
+		t.Helper()
+		resp := send(t, app, "POST", "/v1/admin/rooms/"+room.ID.Hex()+"/blocks", adminToken, map[string]any{
+			"type":     types.BlockMaintenance,
+			"reason":   "new bathroom",
+			"fromDate": from,
+			"tillDate": till,
+		})
+		expectStatus(t, resp, fiber.StatusCreated)
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var created types.RoomBlock
+		if err := json.Unmarshal(b, &created); err != nil {
+			t.Fatal(err)
+		}
+		return &created
+	}
+
+	t.Run("a blocked room can not be booked nor held", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 70)
+		from := time.Now().AddDate(0, 0, 5)
+		b := block(t, room, from, from.AddDate(0, 0, 7))
+
+		stay := types.BookingParam{FromDate: from.AddDate(0, 0, 2), TillDate: from.AddDate(0, 0, 4), CountPerson: 2}
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", guestToken, stay), fiber.StatusConflict)
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/holds", guestToken, stay), fiber.StatusConflict)
+
+		expectStatus(t, send(t, app, "DELETE", "/v1/admin/rooms/"+room.ID.Hex()+"/blocks/"+b.ID.Hex(), adminToken, nil), fiber.StatusOK)
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", guestToken, stay), fiber.StatusCreated)
+	})
+
+	t.Run("only admins block rooms", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 70)
+		from := time.Now().AddDate(0, 0, 5)
+		resp := send(t, app, "POST", "/v1/admin/rooms/"+room.ID.Hex()+"/blocks", guestToken, map[string]any{
+			"type":     types.BlockOwnerUse,
+			"fromDate": from,
+			"tillDate": from.AddDate(0, 0, 1),
+		})
+		expectStatus(t, resp, fiber.StatusForbidden)
+	})
+
+	t.Run("a block over a booking is refused", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 70)
+		from := time.Now().AddDate(0, 0, 5)
+		fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+
+		resp := send(t, app, "POST", "/v1/admin/rooms/"+room.ID.Hex()+"/blocks", adminToken, map[string]any{
+			"type":     types.BlockOutOfOrder,
+			"fromDate": from.AddDate(0, 0, 1),
+			"tillDate": from.AddDate(0, 0, 3),
+		})
+		expectStatus(t, resp, fiber.StatusConflict)
+	})
+
+	t.Run("a block in force shows as blocked", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 70)
+		block(t, room, time.Now().Add(-time.Hour), time.Now().AddDate(0, 0, 3))
+
+		resp := send(t, app, "GET", "/v1/rooms?status=blocked&limit=100", guestToken, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var rooms []types.Room
+		if err := json.Unmarshal(b, &rooms); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, r := range rooms {
+			found = found || r.ID == room.ID && r.Status == types.BlockedRoom
+		}
+		if !found {
+			t.Fatalf("expected room %s to be blocked", room.ID.Hex())
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	roomBlockRequestKey = "roomBlockReq"
+)
+
+type roomBlockRequest struct {
+	RoomID   string          `validate:"required,id" json:"-"`
+	Type     types.BlockType `validate:"required,oneof=maintenance owner_use out_of_order" json:"type"`
+	Reason   string          `validate:"max=512" json:"reason"`
+	FromDate time.Time       `validate:"required" json:"fromDate"`
+	TillDate time.Time       `validate:"required,gtfield=FromDate" json:"tillDate"`
+
+	params *types.RoomBlockParam
+}
+
+func RoomBlockRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.RoomBlockParam
+	if err := c.BodyParser(&params); err != nil {
+		return nil, roomBlockRequestKey, utils.BadRequestError(err.Error())
+	}
+	params.RoomID = c.Params("roomID")
+
+	return &roomBlockRequest{
+		RoomID:   params.RoomID,
+		Type:     params.Type,
+		Reason:   params.Reason,
+		FromDate: params.FromDate,
+		TillDate: params.TillDate,
+		params:   &params,
+	}, roomBlockRequestKey, nil
+}
@@ -2,74 +2,231 @@ package handler
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 )
 
+// HandleGetBookingsAsUser lists the bookings of the caller, with the filters
+// of the admin list but the user and email ones.
 func (h *Handler) HandleGetBookingsAsUser(c *fiber.Ctx) error {
 	user, ok := c.Context().UserValue("user").(*types.User)
 	if !ok {
 		return utils.UnauthorizedError()
 	}
 
-	bookings, err := h.bookingStore.GetBookingsAsUser(c.Context(), user)
+	req, ok := c.Locals(getBookingsRequestKey).(*types.GetBookingsRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getBookingsRequestKey)
+		return utils.BadRequestError("")
+	}
+	if (req.UserID != "" && req.UserID != user.ID.Hex()) || req.Email != "" {
+		return utils.AccessForbiddenError()
+	}
+	req.UserID = user.ID.Hex()
+
+	return h.getBookings(c, req)
+}
+
+func (h *Handler) HandleGetBookingsAsAdmin(c *fiber.Ctx) error {
+	req, ok := c.Locals(getBookingsRequestKey).(*types.GetBookingsRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getBookingsRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	return h.getBookings(c, req)
+}
+
+func (h *Handler) getBookings(c *fiber.Ctx, req *types.GetBookingsRequest) error {
+	bookings, total, lastID, err := h.bookingStore.GetBookings(c.UserContext(), req)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting bookings")
+		return storeError(err, "Error getting bookings")
 	}
 
-	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
-		Data:   &bookings,
-		Status: fiber.StatusOK,
+	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResCursorPaginate]{
+		ResGeneric: types.ResGeneric{
+			Data:   bookings,
+			Status: fiber.StatusOK,
+		},
+		Pagination: types.ResCursorPaginate{
+			LastID: lastID,
+			Limit:  int(req.Limit),
+			Count:  total,
+		},
 	})
 }
 
-func (h *Handler) HandleGetBookingsAsAdmin(c *fiber.Ctx) error {
-	bookings, err := h.bookingStore.GetBookingsAsAdmin(c.Context())
+// HandleGetBooking answers a booking to its guest, the admins and the staff
+// of its hotel. The booking of another guest is answered as missing.
+func (h *Handler) HandleGetBooking(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(getBookingRequestKey).(*getBookingRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getBookingRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	booking, err := h.bookingStore.GetBookingDetail(c.UserContext(), req.BookingID, req.Expand)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting bookings")
+		return storeError(err, "Error getting booking")
+	}
+	if booking.UserID != user.ID && !user.WorksAt(booking.HotelID) {
+		return store.NotFoundError(store.CodeBookingNotFound, "no booking found with id "+req.BookingID)
 	}
 
+	setETag(c, booking.Version)
 	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
-		Data:   &bookings,
+		Data:   booking,
 		Status: fiber.StatusOK,
 	})
 }
 
-func (h *Handler) HandleGetBooking(c *fiber.Ctx) error {
-	bookingID := c.Params("bookingID")
+// HandleLookupBooking answers the booking of a confirmation code to whoever
+// knows the last name of its guest, with its room and hotel.
+func (h *Handler) HandleLookupBooking(c *fiber.Ctx) error {
+	req, ok := c.Locals(bookingLookupRequestKey).(*bookingLookupRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", bookingLookupRequestKey)
+		return utils.BadRequestError("")
+	}
 
-	booking, err := h.bookingStore.GetBookingsByID(c.Context(), bookingID)
+	booking, err := h.bookingStore.GetBookingByCode(c.UserContext(), req.Code, req.LastName)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting booking")
+		return storeError(err, "Error looking up booking")
+	}
+	detail, err := h.bookingStore.GetBookingDetail(c.UserContext(), booking.ID.Hex(), []types.BookingExpand{
+		types.ExpandRoom,
+		types.ExpandHotel,
+	})
+	if err != nil {
+		return storeError(err, "Error looking up booking")
 	}
 
-	return c.Status(fiber.StatusFound).JSON(&types.ResGeneric{
-		Data:   booking,
-		Status: fiber.StatusFound,
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   detail,
+		Status: fiber.StatusOK,
 	})
 }
 
 func (h *Handler) HandleCancelBooking(c *fiber.Ctx) error {
-	bookingID := c.Params("bookingID")
 	user, ok := c.Locals("user").(*types.User)
 	if !ok {
 		return utils.UnauthorizedError()
 	}
 
+	req, ok := c.Locals(cancelBookingRequestKey).(*cancelBookingRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", cancelBookingRequestKey)
+		return utils.BadRequestError("")
+	}
+	booking, err := h.cancelBooking(c, user, req.BookingID, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, booking.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    fmt.Sprintf("booking %s has been canceled", req.BookingID),
+		Status: fiber.StatusOK,
+	})
+}
+
+// cancelBooking cancels a booking of the user, any booking for an admin, then
+// refunds its payments and records the cancel.
+func (h *Handler) cancelBooking(c *fiber.Ctx, user *types.User, bookingID, reason string) (*types.Booking, error) {
+	before, err := h.bookingStore.GetBookingsByID(c.UserContext(), bookingID)
+	if err != nil {
+		return nil, storeError(err, "failed to cancel booking id: "+bookingID)
+	}
+
+	var booking *types.Booking
 	if user.IsAdmin {
-		if err := h.bookingStore.CancelBookingByAdmin(c.Context(), bookingID); err != nil {
-			return utils.InternalServerError("failed to cancel booking id: " + bookingID)
-		}
+		booking, err = h.bookingStore.CancelBookingByAdmin(c.UserContext(), bookingID)
 	} else {
-		if err := h.bookingStore.CancelBookingByUserID(c.Context(), bookingID, user.ID); err != nil {
-			return utils.InternalServerError("failed to cancel booking id: " + bookingID)
-		}
+		booking, err = h.bookingStore.CancelBookingByUserID(c.UserContext(), bookingID, user.ID)
+	}
+	if err != nil {
+		return nil, storeError(err, "failed to cancel booking id: "+bookingID)
+	}
+
+	h.refundBookingPayments(c.UserContext(), booking, user.IsAdmin)
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditBookingCanceled,
+		TargetType: types.AuditTargetBooking,
+		TargetID:   booking.ID,
+		Reason:     reason,
+	}, before, booking)
+
+	return booking, nil
+}
+
+// HandleMoveBooking moves a stay to another room of the hotel for the front
+// desk. A move after the first night splits the stay, the nights in the new
+// room become a booking of their own linked to the original one.
+func (h *Handler) HandleMoveBooking(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(moveBookingRequestKey).(*moveBookingRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", moveBookingRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.bookingStore.GetBookingDetail(c.UserContext(), req.BookingID, nil)
+	if err != nil {
+		return storeError(err, "Error moving booking")
+	}
+	if err := staffOf(c, before.HotelID); err != nil {
+		return err
+	}
+
+	moved, remainder, err := h.bookingStore.MoveBooking(c.UserContext(), &types.MoveBookingParam{
+		BookingID: req.BookingID,
+		RoomID:    req.RoomID,
+		Date:      req.Date,
+		Reason:    req.Reason,
+		MovedBy:   user.ID,
+		Version:   version,
+	})
+	if err != nil {
+		return storeError(err, "Error moving booking")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditBookingMoved,
+		TargetType: types.AuditTargetBooking,
+		TargetID:   moved.ID,
+		Reason:     req.Reason,
+	}, &before.Booking, moved)
+	if remainder != nil {
+		h.audit(c, &types.AuditEntry{
+			Action:     types.AuditBookingCreated,
+			TargetType: types.AuditTargetBooking,
+			TargetID:   remainder.ID,
+			Reason:     req.Reason,
+		}, nil, remainder)
 	}
 
+	setETag(c, moved.Version)
 	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
-		Msg:    fmt.Sprintf("booking %s has been canceled", bookingID),
+		Data:   &types.MovedBooking{Booking: moved, Remainder: remainder},
 		Status: fiber.StatusOK,
 	})
 }
@@ -1,16 +1,23 @@
 package handler_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	migrations "github.com/tnguven/hotel-reservation-app/db"
 	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/tokener"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestHandleGetBookings(t *testing.T) {
@@ -115,3 +122,520 @@ func TestHandleGetBookings(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleBookingErrors(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user    = fixtures.AddUser(*tdb.Store, "booking", "errors", false)
+		hotel   = fixtures.AddHotel(*tdb.Store, "baz hotel", "b", 3, nil)
+		room    = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 20.5)
+		from    = time.Now().AddDate(0, 0, 10)
+		till    = from.AddDate(0, 0, 2)
+		booking = fixtures.AddBooking(*tdb.Store, user.ID, room.ID.Hex(), from, till)
+	)
+	token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+
+	expectProblem := func(t *testing.T, resp *http.Response, status int, code string) {
+		t.Helper()
+		if resp.StatusCode != status {
+			t.Fatalf("expected %d status code but received %d", status, resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != types.ProblemContentType {
+			t.Fatalf("expected %s content type but received %s", types.ProblemContentType, contentType)
+		}
+
+		var problem types.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Code != code {
+			t.Fatalf("expected code %s but received %s", code, problem.Code)
+		}
+	}
+
+	t.Run("missing booking is not found", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: "/v1/bookings/" + primitive.NewObjectID().Hex(),
+			Token:  token,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectProblem(t, resp, fiber.StatusNotFound, store.CodeBookingNotFound)
+	})
+
+	t.Run("malformed booking id is a bad request", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "GET",
+			Target: "/v1/bookings/not-an-id",
+			Token:  token,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectProblem(t, resp, fiber.StatusBadRequest, store.CodeInvalidID)
+	})
+
+	t.Run("canceling twice is a conflict", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "PUT",
+			Target: "/v1/bookings/" + booking.ID.Hex() + "/cancel",
+			Token:  token,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+
+		resp, err = app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectProblem(t, resp, fiber.StatusConflict, store.CodeBookingAlreadyCanceled)
+	})
+
+	t.Run("a stay in the past or a null body is a bad request", func(t *testing.T) {
+		past, _ := json.Marshal(types.BookingParam{FromDate: time.Now().AddDate(0, 0, -3), TillDate: time.Now().AddDate(0, 0, -1), CountPerson: 1})
+		for _, body := range [][]byte{past, []byte("null")} {
+			testReq := utils.TestRequest{
+				Method:  "POST",
+				Target:  "/v1/rooms/" + room.ID.Hex() + "/booking",
+				Token:   token,
+				Payload: bytes.NewReader(body),
+			}
+			resp, err := app.Test(testReq.NewRequestWithHeader())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectProblem(t, resp, fiber.StatusBadRequest, "bad_request")
+		}
+	})
+
+	t.Run("a stay ending before it starts fails validation", func(t *testing.T) {
+		from := time.Now().AddDate(0, 0, 5)
+		b, _ := json.Marshal(types.BookingParam{FromDate: from, TillDate: from.AddDate(0, 0, -2), CountPerson: 1})
+		testReq := utils.TestRequest{
+			Method:  "POST",
+			Target:  "/v1/rooms/" + room.ID.Hex() + "/booking",
+			Token:   token,
+			Payload: bytes.NewReader(b),
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectProblem(t, resp, fiber.StatusBadRequest, "validation_failed")
+	})
+
+	t.Run("canceling a missing booking is not found", func(t *testing.T) {
+		testReq := utils.TestRequest{
+			Method: "PUT",
+			Target: "/v1/bookings/" + primitive.NewObjectID().Hex() + "/cancel",
+			Token:  token,
+		}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectProblem(t, resp, fiber.StatusNotFound, store.CodeBookingNotFound)
+	})
+}
+
+func TestMoveBooking(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "moving", "guest", false)
+		admin         = fixtures.AddUser(*tdb.Store, "front", "desk", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "move hotel", "Bern", 3, nil)
+		otherHotel    = fixtures.AddHotel(*tdb.Store, "far hotel", "Genf", 3, nil)
+		adminToken, _ = tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+		guestToken, _ = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		from          = time.Now().AddDate(0, 0, 2).Truncate(time.Second)
+		till          = from.AddDate(0, 0, 4)
+	)
+
+	move := func(t *testing.T, token string, booking *types.Booking, payload map[string]any) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(payload)
+		testReq := utils.TestRequest{
+			Method:  "POST",
+			Target:  "/v1/admin/bookings/" + booking.ID.Hex() + "/move",
+			Token:   token,
+			Payload: bytes.NewReader(b),
+		}
+		req := testReq.NewRequestWithHeader()
+		req.Header.Set(fiber.HeaderIfMatch, fmt.Sprintf(`"%d"`, booking.Version))
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	decode := func(t *testing.T, resp *http.Response) *types.MovedBooking {
+		t.Helper()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var moved types.MovedBooking
+		if err := json.Unmarshal(b, &moved); err != nil {
+			t.Fatal(err)
+		}
+		return &moved
+	}
+
+	t.Run("moves the whole stay", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		target := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, till)
+
+		if resp := move(t, guestToken, booking, map[string]any{"roomID": target.ID.Hex()}); resp.StatusCode != fiber.StatusForbidden {
+			t.Fatalf("expected 403 status code but received %d", resp.StatusCode)
+		}
+
+		moved := decode(t, move(t, adminToken, booking, map[string]any{"roomID": target.ID.Hex(), "reason": "broken heating"}))
+		if moved.Remainder != nil || moved.Booking.RoomID != target.ID || len(moved.Booking.Moves) != 1 {
+			t.Fatalf("unexpected move %+v", moved.Booking)
+		}
+		if m := moved.Booking.Moves[0]; m.FromRoomID != room.ID || m.MovedBy != admin.ID || m.Reason != "broken heating" {
+			t.Fatalf("unexpected history %+v", m)
+		}
+
+		// the version moved on, the stale ETag is refused
+		if resp := move(t, adminToken, booking, map[string]any{"roomID": room.ID.Hex()}); resp.StatusCode != fiber.StatusPreconditionFailed {
+			t.Fatalf("expected 412 status code but received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("splits the stay from a date", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		target := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, till)
+		date := from.AddDate(0, 0, 2)
+
+		moved := decode(t, move(t, adminToken, booking, map[string]any{"roomID": target.ID.Hex(), "date": date}))
+		if moved.Booking.RoomID != room.ID || !moved.Booking.TillDate.Equal(date) {
+			t.Fatalf("expected the first nights to stay in room %s, got %+v", room.ID.Hex(), moved.Booking)
+		}
+		rest := moved.Remainder
+		if rest == nil || rest.RoomID != target.ID || !rest.FromDate.Equal(date) || !rest.TillDate.Equal(till) {
+			t.Fatalf("unexpected remainder %+v", rest)
+		}
+		if rest.SplitFromID == nil || *rest.SplitFromID != booking.ID {
+			t.Fatalf("expected the remainder to point to booking %s", booking.ID.Hex())
+		}
+		if split := moved.Booking.Moves[0].SplitBookingID; split == nil || *split != rest.ID {
+			t.Fatalf("expected the history to point to the remainder %s", rest.ID.Hex())
+		}
+	})
+
+	t.Run("refuses a taken room or another hotel", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		taken := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		far := fixtures.AddRoom(*tdb.Store, types.KingRoomType, otherHotel.ID, 90)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, till)
+		fixtures.AddBooking(*tdb.Store, admin.ID, taken.ID.Hex(), from.AddDate(0, 0, 3), till)
+
+		if resp := move(t, adminToken, booking, map[string]any{"roomID": taken.ID.Hex()}); resp.StatusCode != fiber.StatusConflict {
+			t.Fatalf("expected 409 status code but received %d", resp.StatusCode)
+		}
+		if resp := move(t, adminToken, booking, map[string]any{"roomID": far.ID.Hex()}); resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected 400 status code but received %d", resp.StatusCode)
+		}
+		if resp := move(t, adminToken, booking, map[string]any{"roomID": taken.ID.Hex(), "date": till}); resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected 400 status code but received %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSearchBookings(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "search", "guest", false)
+		other         = fixtures.AddUser(*tdb.Store, "search", "other", false)
+		admin         = fixtures.AddUser(*tdb.Store, "search", "admin", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "search hotel", "Rome", 4, nil)
+		room          = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		otherRoom     = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		from          = time.Now().Add(time.Hour)
+		guestToken, _ = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		adminToken, _ = tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+	)
+
+	stays := make([]*types.Booking, 3)
+	for i := range stays {
+		start := from.AddDate(0, 0, 10*i)
+		stays[i] = fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), start, start.AddDate(0, 0, 2))
+	}
+	fixtures.AddBooking(*tdb.Store, other.ID, otherRoom.ID.Hex(), from, from.AddDate(0, 0, 2))
+	if _, err := tdb.Store.Booking.CancelBookingByAdmin(t.Context(), stays[2].ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(t *testing.T, target, token string, status int) ([]*types.Booking, types.ResCursorPaginate) {
+		t.Helper()
+		testReq := utils.TestRequest{Method: "GET", Target: target, Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Fatalf("expected %d status code but received %d", status, resp.StatusCode)
+		}
+		var response types.ResWithPaginate[types.ResCursorPaginate]
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var bookings []*types.Booking
+		if err := json.Unmarshal(b, &bookings); err != nil {
+			t.Fatal(err)
+		}
+		return bookings, response.Pagination
+	}
+
+	t.Run("admins filter by guest email and status", func(t *testing.T) {
+		bookings, page := list(t, "/v1/admin/bookings?status=confirmed&email="+guest.Email, adminToken, fiber.StatusOK)
+		if len(bookings) != 2 || page.Count != 2 {
+			t.Fatalf("expected the 2 live bookings of the guest, got %d of %d", len(bookings), page.Count)
+		}
+		for _, b := range bookings {
+			if b.UserID != guest.ID || b.Canceled {
+				t.Fatalf("unexpected booking %+v", b)
+			}
+		}
+	})
+
+	t.Run("pages follow the sort", func(t *testing.T) {
+		target := "/v1/admin/bookings?sort=-fromDate&limit=2&roomID=" + room.ID.Hex()
+		first, page := list(t, target, adminToken, fiber.StatusOK)
+		if len(first) != 2 || page.Count != 3 || first[0].ID != stays[2].ID || first[1].ID != stays[1].ID {
+			t.Fatalf("unexpected first page %+v", first)
+		}
+
+		second, page := list(t, target+"&lastID="+page.LastID, adminToken, fiber.StatusOK)
+		if len(second) != 1 || second[0].ID != stays[0].ID {
+			t.Fatalf("unexpected second page %+v", second)
+		}
+		if _, page = list(t, target+"&lastID="+page.LastID, adminToken, fiber.StatusOK); page.LastID != "" {
+			t.Fatalf("expected no more pages, got %q", page.LastID)
+		}
+
+		for _, limit := range []string{"0", "-1", "101"} {
+			list(t, "/v1/admin/bookings?limit="+limit, adminToken, fiber.StatusBadRequest)
+		}
+	})
+
+	t.Run("stays overlapping a range", func(t *testing.T) {
+		window := fmt.Sprintf("fromDate=%s&tillDate=%s",
+			from.AddDate(0, 0, 9).UTC().Format(time.RFC3339), from.AddDate(0, 0, 11).UTC().Format(time.RFC3339))
+		bookings, _ := list(t, "/v1/admin/bookings?hotelID="+hotel.ID.Hex()+"&"+window, adminToken, fiber.StatusOK)
+		if len(bookings) != 1 || bookings[0].ID != stays[1].ID {
+			t.Fatalf("unexpected bookings %+v", bookings)
+		}
+	})
+
+	t.Run("guests only see their own bookings", func(t *testing.T) {
+		bookings, page := list(t, "/v1/bookings?hotelID="+hotel.ID.Hex(), guestToken, fiber.StatusOK)
+		if len(bookings) != 3 || page.Count != 3 {
+			t.Fatalf("expected the 3 bookings of the guest, got %d", len(bookings))
+		}
+		list(t, "/v1/bookings?userID="+other.ID.Hex(), guestToken, fiber.StatusForbidden)
+		list(t, "/v1/bookings?email="+other.Email, guestToken, fiber.StatusForbidden)
+		list(t, "/v1/bookings?sort=price", guestToken, fiber.StatusBadRequest)
+	})
+}
+
+func TestGetBookingDetail(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest      = fixtures.AddUser(*tdb.Store, "detail", "guest", false)
+		other      = fixtures.AddUser(*tdb.Store, "detail", "other", false)
+		staff      = fixtures.AddUser(*tdb.Store, "detail", "staff", false)
+		outsider   = fixtures.AddUser(*tdb.Store, "detail", "outsider", false)
+		admin      = fixtures.AddUser(*tdb.Store, "detail", "admin", true)
+		hotel      = fixtures.AddHotel(*tdb.Store, "detail hotel", "Lima", 4, nil)
+		otherHotel = fixtures.AddHotel(*tdb.Store, "other hotel", "Lima", 4, nil)
+		room       = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		from       = time.Now().Add(time.Hour)
+		booking    = fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+		target     = "/v1/bookings/" + booking.ID.Hex()
+	)
+	for user, hotelID := range map[*types.User]primitive.ObjectID{staff: hotel.ID, outsider: otherHotel.ID} {
+		params := &types.SetRolesParams{Roles: []types.Role{types.RoleStaff}, HotelIDs: []primitive.ObjectID{hotelID}}
+		if _, err := tdb.Store.User.SetRoles(t.Context(), user.ID.Hex(), params, user.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(t *testing.T, target string, user *types.User) *http.Response {
+		t.Helper()
+		token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		testReq := utils.TestRequest{Method: "GET", Target: target, Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("the guest, the staff of the hotel and admins read it", func(t *testing.T) {
+		for _, user := range []*types.User{guest, staff, admin} {
+			if resp := get(t, target, user); resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("expected %d status code for %s but received %d", fiber.StatusOK, user.LastName, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("anyone else is answered not found", func(t *testing.T) {
+		for _, user := range []*types.User{other, outsider} {
+			if resp := get(t, target, user); resp.StatusCode != fiber.StatusNotFound {
+				t.Fatalf("expected %d status code for %s but received %d", fiber.StatusNotFound, user.LastName, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("expand joins the documents", func(t *testing.T) {
+		resp := get(t, target+"?expand=room,hotel,user", guest)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var detail types.BookingDetail
+		if err := json.Unmarshal(b, &detail); err != nil {
+			t.Fatal(err)
+		}
+
+		if detail.ID != booking.ID || detail.HotelID != hotel.ID {
+			t.Fatalf("unexpected booking %+v", detail.Booking)
+		}
+		if detail.Room == nil || detail.Room.ID != room.ID {
+			t.Fatalf("expected the room, got %+v", detail.Room)
+		}
+		if detail.Hotel == nil || detail.Hotel.Name != hotel.Name {
+			t.Fatalf("expected the hotel, got %+v", detail.Hotel)
+		}
+		if detail.User == nil || detail.User.Email != guest.Email {
+			t.Fatalf("expected the guest, got %+v", detail.User)
+		}
+	})
+
+	t.Run("unknown expand", func(t *testing.T) {
+		if resp := get(t, target+"?expand=payments", guest); resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func TestLookupBooking(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest   = fixtures.AddUser(*tdb.Store, "lookup", "Guest", false)
+		hotel   = fixtures.AddHotel(*tdb.Store, "lookup hotel", "Quito", 4, nil)
+		room    = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		from    = time.Now().Add(time.Hour)
+		booking = fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+	)
+
+	lookup := func(t *testing.T, code, lastName string) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(map[string]string{"code": code, "lastName": lastName})
+		testReq := utils.TestRequest{Method: "POST", Target: "/v1/booking-lookup", Payload: bytes.NewReader(b)}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if len(booking.Code) != 9 {
+		t.Fatalf("expected a confirmation code on the booking, got %q", booking.Code)
+	}
+
+	t.Run("code and last name find the booking without login", func(t *testing.T) {
+		typed := strings.ToLower(strings.ReplaceAll(booking.Code, "-", ""))
+		resp := lookup(t, typed, "guest")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var detail types.BookingDetail
+		if err := json.Unmarshal(b, &detail); err != nil {
+			t.Fatal(err)
+		}
+		if detail.ID != booking.ID || detail.Hotel == nil || detail.Room == nil || detail.User != nil {
+			t.Fatalf("unexpected booking %+v", detail)
+		}
+	})
+
+	t.Run("a wrong name or code is not found", func(t *testing.T) {
+		for _, c := range [][2]string{{booking.Code, "Other"}, {"2345-6789", "Guest"}, {"not a code", "Guest"}} {
+			if resp := lookup(t, c[0], c[1]); resp.StatusCode != fiber.StatusNotFound {
+				t.Fatalf("expected %d status code for %v but received %d", fiber.StatusNotFound, c, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("both fields are required", func(t *testing.T) {
+		if resp := lookup(t, booking.Code, ""); resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("bookings made before codes get one at startup", func(t *testing.T) {
+		old := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from.AddDate(0, 0, 5), from.AddDate(0, 0, 7))
+		if _, err := tdb.db.Collection("bookings").UpdateByID(t.Context(), old.ID, bson.M{"$unset": bson.M{"code": ""}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := migrations.CreateIndexes(t.Context(), tdb.db); err != nil {
+			t.Fatal(err)
+		}
+
+		backfilled, err := tdb.Store.Booking.GetBookingsByID(t.Context(), old.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backfilled.Code) != 9 {
+			t.Fatalf("expected a backfilled confirmation code, got %q", backfilled.Code)
+		}
+		if resp := lookup(t, backfilled.Code, "Guest"); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+	})
+}
@@ -1,37 +1,226 @@
 package handler
 
 import (
-	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
 )
 
 const (
-	bookRoomRequestKey = "bookRoomReqKey"
+	bookRoomRequestKey      = "bookRoomReqKey"
+	bookRoomTypeRequestKey  = "bookRoomTypeReqKey"
+	cancelBookingRequestKey = "cancelBookingReqKey"
+	moveBookingRequestKey   = "moveBookingReqKey"
+	getBookingsRequestKey   = "getBookingsReqKey"
+	getBookingRequestKey    = "getBookingReqKey"
+	bookingLookupRequestKey = "bookingLookupReqKey"
 )
 
+// bookingLookupRequest finds a booking without a session, the code is read in
+// any case, with or without its dash.
+type bookingLookupRequest struct {
+	Code     string `validate:"required,max=16" json:"code"`
+	LastName string `validate:"required,max=48" json:"lastName"`
+}
+
+func BookingLookupRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params bookingLookupRequest
+	if err := c.BodyParser(&params); err != nil {
+		return nil, bookingLookupRequestKey, utils.BadRequestError(err.Error())
+	}
+
+	return &params, bookingLookupRequestKey, nil
+}
+
+// getBookingRequest lists the documents to join, expand=room,hotel,user. The
+// booking id is checked by the store.
+type getBookingRequest struct {
+	BookingID string                `validate:"required" query:"-"`
+	Expand    []types.BookingExpand `validate:"max=3,dive,oneof=room hotel user" query:"expand"`
+}
+
+func GetBookingRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	req := &getBookingRequest{
+		BookingID: c.Params("bookingID"),
+		Expand:    []types.BookingExpand{},
+	}
+	if expand := c.Query("expand"); expand != "" {
+		for _, name := range strings.Split(expand, ",") {
+			req.Expand = append(req.Expand, types.BookingExpand(strings.TrimSpace(name)))
+		}
+	}
+
+	return req, getBookingRequestKey, nil
+}
+
+// GetBookingsRequestSchema reads the filters of a booking list, the dates as
+// RFC 3339 timestamps.
+func GetBookingsRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	// a zero limit would list every booking, the validator only caps it
+	limit := c.QueryInt("limit", defaultReadLimit)
+	if limit < 1 {
+		return nil, getBookingsRequestKey, utils.BadRequestError("limit must be between 1 and 100")
+	}
+
+	req := &types.GetBookingsRequest{
+		HotelID:             c.Query("hotelID"),
+		RoomID:              c.Query("roomID"),
+		UserID:              c.Query("userID"),
+		Email:               c.Query("email"),
+		Status:              types.BookingStatus(c.Query("status")),
+		Sort:                c.Query("sort"),
+		QueryCursorPaginate: types.NewMongoQueryCursorPaginate(c.Query("lastID"), limit),
+	}
+
+	for key, date := range map[string]*time.Time{
+		"fromDate":    &req.FromDate,
+		"tillDate":    &req.TillDate,
+		"createdFrom": &req.CreatedFrom,
+		"createdTill": &req.CreatedTill,
+	} {
+		t, err := queryTime(c, key)
+		if err != nil {
+			return nil, getBookingsRequestKey, err
+		}
+		*date = t
+	}
+
+	return req, getBookingsRequestKey, nil
+}
+
 type bookingRoomRequest struct {
-	FromDate  time.Time `validate:"required"`
-	TillDate  time.Time `validate:"required"`
-	NumPerson int       `validate:"required,numeric,min=1,max=20"`
+	FromDate    time.Time         `validate:"required" json:"fromDate"`
+	TillDate    time.Time         `validate:"required,gtfield=FromDate" json:"tillDate"`
+	NumPerson   int               `validate:"required,numeric,min=1,max=20" json:"countPerson"`
+	PaymentMode types.PaymentMode `validate:"oneof=pay_now pay_at_hotel deposit" json:"paymentMode"`
+	Card        *cardRequest      `validate:"required_unless=PaymentMode pay_at_hotel" json:"card"`
+}
+
+type cardRequest struct {
+	Number   string `validate:"required,numeric,min=12,max=19" json:"number"`
+	ExpMonth int    `validate:"required,min=1,max=12" json:"expMonth"`
+	ExpYear  int    `validate:"required,min=2000,max=2100" json:"expYear"`
+	CVC      string `validate:"required,numeric,min=3,max=4" json:"cvc"`
 }
 
 func BookingRoomRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
-	var params *types.BookingParam
+	var params types.BookingParam
 	if err := c.BodyParser(&params); err != nil {
-		return nil, bookRoomRequestKey, err
+		return nil, bookRoomRequestKey, utils.BadRequestError(err.Error())
 	}
 
 	now := time.Now()
 	if now.After(params.FromDate) || now.After(params.TillDate) {
-		return nil, bookRoomRequestKey, fmt.Errorf("cannot book a room in the past")
+		return nil, bookRoomRequestKey, utils.BadRequestError("cannot book a room in the past")
 	}
 
 	return &bookingRoomRequest{
-		FromDate:  params.FromDate,
-		TillDate:  params.TillDate,
-		NumPerson: params.CountPerson,
+		FromDate:    params.FromDate,
+		TillDate:    params.TillDate,
+		NumPerson:   params.CountPerson,
+		PaymentMode: bookingPaymentMode(&params),
+		Card:        newCardRequest(params.Card),
 	}, bookRoomRequestKey, nil
 }
+
+// bookingRoomTypeRequest books any free room of a type, the room is assigned
+// when booking.
+type bookingRoomTypeRequest struct {
+	HotelID           string            `validate:"required,id" json:"-"`
+	RoomType          types.RoomType    `validate:"required,oneof=family family_suit suit honey_moon king" json:"roomType"`
+	FromDate          time.Time         `validate:"required" json:"fromDate"`
+	TillDate          time.Time         `validate:"required,gtfield=FromDate" json:"tillDate"`
+	NumPerson         int               `validate:"required,numeric,min=1,max=20" json:"countPerson"`
+	PaymentMode       types.PaymentMode `validate:"oneof=pay_now pay_at_hotel deposit" json:"paymentMode"`
+	Card              *cardRequest      `validate:"required_unless=PaymentMode pay_at_hotel" json:"card"`
+	ChallengeResponse string            `validate:"max=256" json:"challengeResponse"`
+
+	params *types.BookingParam
+}
+
+func BookingRoomTypeRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.BookingParam
+	if err := c.BodyParser(&params); err != nil {
+		return nil, bookRoomTypeRequestKey, utils.BadRequestError(err.Error())
+	}
+
+	if time.Now().After(params.FromDate) {
+		return nil, bookRoomTypeRequestKey, utils.BadRequestError("cannot book a room in the past")
+	}
+	params.RoomID = ""
+	params.PaymentMode = bookingPaymentMode(&params)
+
+	return &bookingRoomTypeRequest{
+		HotelID:           c.Params("hotelID"),
+		RoomType:          params.RoomType,
+		FromDate:          params.FromDate,
+		TillDate:          params.TillDate,
+		NumPerson:         params.CountPerson,
+		PaymentMode:       params.PaymentMode,
+		Card:              newCardRequest(params.Card),
+		ChallengeResponse: params.ChallengeResponse,
+		params:            &params,
+	}, bookRoomTypeRequestKey, nil
+}
+
+func newCardRequest(card *types.Card) *cardRequest {
+	if card == nil {
+		return nil
+	}
+	return &cardRequest{
+		Number:   card.Number,
+		ExpMonth: card.ExpMonth,
+		ExpYear:  card.ExpYear,
+		CVC:      card.CVC,
+	}
+}
+
+// bookingPaymentMode defaults to paying at the hotel, the behaviour of the
+// bookings made before payments existed.
+func bookingPaymentMode(params *types.BookingParam) types.PaymentMode {
+	if params.PaymentMode == "" {
+		return types.PayAtHotel
+	}
+	return params.PaymentMode
+}
+
+// cancelBookingRequest takes an optional body, the reason is kept in the audit
+// log.
+type cancelBookingRequest struct {
+	BookingID string `validate:"required,id" json:"-"`
+	Reason    string `validate:"max=512" json:"reason"`
+}
+
+func CancelBookingRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params cancelBookingRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&params); err != nil {
+			return nil, cancelBookingRequestKey, utils.BadRequestError(err.Error())
+		}
+	}
+	params.BookingID = c.Params("bookingID")
+
+	return &params, cancelBookingRequestKey, nil
+}
+
+// moveBookingRequest moves the whole stay, or the nights from date on when a
+// date is given.
+type moveBookingRequest struct {
+	BookingID string    `validate:"required,id" json:"-"`
+	RoomID    string    `validate:"required,id" json:"roomID"`
+	Date      time.Time `json:"date"`
+	Reason    string    `validate:"max=512" json:"reason"`
+}
+
+func MoveBookingRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params moveBookingRequest
+	if err := c.BodyParser(&params); err != nil {
+		return nil, moveBookingRequestKey, utils.BadRequestError(err.Error())
+	}
+	params.BookingID = c.Params("bookingID")
+
+	return &params, moveBookingRequestKey, nil
+}
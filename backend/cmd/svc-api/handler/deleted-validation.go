@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+const (
+	getDeletedRequestKey = "getDeletedReq"
+)
+
+func GetDeletedRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	return types.NewQueryNumericPaginate(
+		c.QueryInt("limit", defaultReadLimit),
+		c.QueryInt("page", 1),
+	), getDeletedRequestKey, nil
+}
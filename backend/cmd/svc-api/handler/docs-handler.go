@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/openapi"
+)
+
+const docsPage = `<!doctype html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Hotel reservation API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="docs"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({ url: "/openapi.json", dom_id: "#docs" });</script>
+</body>
+</html>`
+
+// OpenAPI generates the document from the routes registered on the app, the
+// second value lists the routes missing from routeDocs.
+func OpenAPI(app *fiber.App) (*openapi.Document, []string) {
+	return openapi.Build(apiInfo, app.GetRoutes(true), routeDocs)
+}
+
+// HandleOpenAPI builds the document on the first request, once every route is
+// registered.
+func (h *Handler) HandleOpenAPI(app *fiber.App) fiber.Handler {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+	)
+
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			doc, _ = OpenAPI(app)
+		})
+
+		return c.Status(fiber.StatusOK).JSON(doc)
+	}
+}
+
+func (h *Handler) HandleDocs(c *fiber.Ctx) error {
+	c.Type("html")
+	return c.Status(fiber.StatusOK).SendString(docsPage)
+}
@@ -0,0 +1,64 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tnguven/hotel-reservation-app/cmd/svc-api/handler"
+	"github.com/tnguven/hotel-reservation-app/internals/openapi"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func TestOpenAPI(t *testing.T) {
+	config := NewConfig()
+	_, app := Setup(mDatabase, config)
+
+	t.Run("every registered route is documented", func(t *testing.T) {
+		_, undocumented := handler.OpenAPI(app)
+		for _, route := range undocumented {
+			t.Errorf("route %q is registered without documentation, add it to routeDocs", route)
+		}
+	})
+
+	t.Run("serves the document", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/openapi.json"}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+
+		var doc openapi.Document
+		if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if doc.OpenAPI != openapi.Version {
+			t.Errorf("expected openapi %s, got %q", openapi.Version, doc.OpenAPI)
+		}
+
+		booking, ok := doc.Paths["/v1/rooms/{roomID}/booking"]
+		if !ok {
+			t.Fatalf("expected the booking path in %v", doc.Paths)
+		}
+		if op := (*booking)["post"]; op == nil || op.RequestBody == nil {
+			t.Errorf("expected the booking request body to be documented")
+		}
+	})
+
+	t.Run("serves the docs ui outside production", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/docs"}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+	})
+}
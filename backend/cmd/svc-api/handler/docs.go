@@ -0,0 +1,424 @@
+package handler
+
+import (
+	"github.com/tnguven/hotel-reservation-app/internals/openapi"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+type (
+	statusResponse struct {
+		Status string `json:"status"`
+	}
+
+	readinessResponse struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+)
+
+var (
+	apiInfo = openapi.Info{
+		Title:       "Hotel reservation API",
+		Description: "Sample API",
+		Version:     "1.0.0",
+	}
+
+	// routeDocs documents every route registered in Register, TestOpenAPI fails
+	// when a route is missing here.
+	routeDocs = map[string]openapi.Doc{
+		"GET /health":       {Summary: "Health check", Tags: []string{"probes"}, Raw: statusResponse{}},
+		"GET /livez":        {Summary: "Liveness probe", Tags: []string{"probes"}, Raw: statusResponse{}},
+		"GET /readyz":       {Summary: "Readiness probe, 503 while a dependency is down or the server drains", Tags: []string{"probes"}, Raw: readinessResponse{}},
+		"GET /metrics":      {Summary: "Prometheus metrics", Tags: []string{"probes"}, Raw: "", ContentType: "text/plain"},
+		"GET /openapi.json": {Summary: "This document", Tags: []string{"docs"}, Raw: map[string]any{}},
+		"GET /docs":         {Summary: "Interactive API docs, not served in production", Tags: []string{"docs"}, Raw: "", ContentType: "text/html"},
+
+		"POST /v1/auth": {
+			Summary: "Authenticate with email and password",
+			Tags:    []string{"auth"},
+			Body:    authRequest{},
+			Data:    AuthResponse{},
+		},
+		"POST /v1/auth/signin": {
+			Summary:    "Create an account",
+			Tags:       []string{"auth"},
+			Idempotent: true,
+			Body:       types.CreateUserParams{},
+			Status:     201,
+			Data:       types.User{},
+		},
+
+		"GET /v1/users": {
+			Summary:    "List users",
+			Tags:       []string{"users"},
+			Auth:       true,
+			Query:      types.QueryNumericPaginate{},
+			Data:       []types.User{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"POST /v1/users": {
+			Summary:    "Create a user",
+			Tags:       []string{"users"},
+			Idempotent: true,
+			Body:       types.CreateUserParams{},
+			Status:     201,
+			Data:       types.User{},
+		},
+		"GET /v1/users/{id}": {
+			Summary: "Get a user",
+			Tags:    []string{"users"},
+			Data:    types.User{},
+		},
+		"PUT /v1/users/{id}": {
+			Summary:    "Update the name of a user, the user itself or an admin",
+			Tags:       []string{"users"},
+			Auth:       true,
+			Idempotent: true,
+			IfMatch:    true,
+			Body:       updateUserRequest{},
+			Data:       types.User{},
+		},
+		"DELETE /v1/users/{id}": {
+			Summary:    "Soft delete a user, the user itself or an admin; admins can restore it until it is purged",
+			Tags:       []string{"users"},
+			Auth:       true,
+			Idempotent: true,
+		},
+
+		"GET /v1/me/export": {
+			Summary: "Download everything kept about the caller, as a json archive",
+			Tags:    []string{"privacy"},
+			Auth:    true,
+			Raw:     types.DataExport{},
+		},
+		"POST /v1/me/erasure": {
+			Summary:    "Erase the personal data of the caller, bookings are kept anonymized",
+			Tags:       []string{"privacy"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       eraseMeRequest{},
+		},
+		"POST /v1/admin/users/{id}/erasure": {
+			Summary:    "Erase the personal data of a user, admin only",
+			Tags:       []string{"privacy"},
+			Auth:       true,
+			Idempotent: true,
+		},
+		"PUT /v1/admin/users/{id}/roles": {
+			Summary:    "Replace the roles of a user and the hotels it works at, admin only",
+			Tags:       []string{"users"},
+			Auth:       true,
+			Idempotent: true,
+			IfMatch:    true,
+			Body:       setRolesRequest{},
+			Data:       types.User{},
+		},
+
+		"GET /v1/hotels": {
+			Summary:    "List hotels",
+			Tags:       []string{"hotels"},
+			Auth:       true,
+			Query:      types.GetHotelsRequest{},
+			Data:       []types.Hotel{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"GET /v1/hotels/{hotelID}": {
+			Summary: "Get a hotel",
+			Tags:    []string{"hotels"},
+			Auth:    true,
+			Status:  302,
+			Data:    types.Hotel{},
+		},
+		"PUT /v1/hotels/{hotelID}": {
+			Summary:    "Update a hotel, admin only",
+			Tags:       []string{"hotels"},
+			Auth:       true,
+			Idempotent: true,
+			IfMatch:    true,
+			Body:       updateHotelRequest{},
+			Data:       types.Hotel{},
+		},
+		"DELETE /v1/hotels/{hotelID}": {
+			Summary:    "Soft delete a hotel, admin only",
+			Tags:       []string{"hotels"},
+			Auth:       true,
+			Idempotent: true,
+		},
+		"GET /v1/hotels/{hotelID}/rooms": {
+			Summary: "List the rooms of a hotel",
+			Tags:    []string{"hotels"},
+			Auth:    true,
+			Data:    []types.Room{},
+		},
+		"GET /v1/hotels/{hotelID}/availability": {
+			Summary: "Count the free rooms of each type for a stay",
+			Tags:    []string{"hotels"},
+			Auth:    true,
+			Query:   availabilityRequest{},
+			Data:    []types.TypeAvailability{},
+		},
+		"POST /v1/hotels/{hotelID}/booking": {
+			Summary:    "Book any free room of a type, the cheapest is assigned",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       bookingRoomTypeRequest{},
+			Status:     201,
+			Data:       types.Booking{},
+		},
+
+		"GET /v1/rooms": {
+			Summary:    "List rooms",
+			Tags:       []string{"rooms"},
+			Auth:       true,
+			Query:      types.GetRoomsRequest{},
+			Data:       []types.Room{},
+			Pagination: types.ResCursorPaginate{},
+		},
+		"POST /v1/rooms/{roomID}/booking": {
+			Summary:    "Book a room, paid now, as a deposit or at the hotel",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       bookingRoomRequest{},
+			Status:     201,
+			Data:       types.Booking{},
+		},
+		"POST /v1/rooms/{roomID}/holds": {
+			Summary:    "Hold a room for a stay during checkout, 15 minutes unless given",
+			Tags:       []string{"holds"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       holdRoomRequest{},
+			Status:     201,
+			Data:       types.Hold{},
+		},
+		"GET /v1/holds/{holdID}": {
+			Summary: "Get a live hold of the caller",
+			Tags:    []string{"holds"},
+			Auth:    true,
+			Data:    types.Hold{},
+		},
+		"DELETE /v1/holds/{holdID}": {
+			Summary:    "Release a hold",
+			Tags:       []string{"holds"},
+			Auth:       true,
+			Idempotent: true,
+		},
+		"POST /v1/holds/{holdID}/confirm": {
+			Summary:    "Pay a hold and turn it into a booking",
+			Tags:       []string{"holds"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       confirmHoldRequest{},
+			Status:     201,
+			Data:       types.Booking{},
+		},
+		"POST /v1/groups": {
+			Summary:    "Book several rooms of a hotel at once, all or none",
+			Tags:       []string{"groups"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       groupBookingRequest{},
+			Status:     201,
+			Data:       types.GroupBookingWithBookings{},
+		},
+		"GET /v1/groups/{groupID}": {
+			Summary: "Get a group booking with its bookings",
+			Tags:    []string{"groups"},
+			Auth:    true,
+			Data:    types.GroupBookingWithBookings{},
+		},
+		"PUT /v1/groups/{groupID}/bookings/{bookingID}/cancel": {
+			Summary:    "Cancel one room of a group booking",
+			Tags:       []string{"groups"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       cancelGroupRoomRequest{},
+		},
+		"DELETE /v1/rooms/{roomID}": {
+			Summary:    "Soft delete a room, admin only",
+			Tags:       []string{"rooms"},
+			Auth:       true,
+			Idempotent: true,
+		},
+
+		"GET /v1/admin/deleted/users": {
+			Summary:    "List the soft deleted users, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Query:      types.QueryNumericPaginate{},
+			Data:       []types.User{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"POST /v1/admin/deleted/users/{id}/restore": {
+			Summary:    "Restore a soft deleted user that was not erased, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Idempotent: true,
+			Data:       types.User{},
+		},
+		"GET /v1/admin/deleted/hotels": {
+			Summary:    "List the soft deleted hotels, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Query:      types.QueryNumericPaginate{},
+			Data:       []types.Hotel{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"POST /v1/admin/deleted/hotels/{hotelID}/restore": {
+			Summary:    "Restore a soft deleted hotel, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Idempotent: true,
+			Data:       types.Hotel{},
+		},
+		"GET /v1/admin/deleted/rooms": {
+			Summary:    "List the soft deleted rooms, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Query:      types.QueryNumericPaginate{},
+			Data:       []types.Room{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"POST /v1/admin/deleted/rooms/{roomID}/restore": {
+			Summary:    "Restore a soft deleted room, admin only",
+			Tags:       []string{"admin"},
+			Auth:       true,
+			Idempotent: true,
+			Data:       types.Room{},
+		},
+
+		"GET /v1/admin/bookings": {
+			Summary:    "Search the bookings, admin only",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Query:      types.GetBookingsRequest{},
+			Data:       []types.Booking{},
+			Pagination: types.ResCursorPaginate{},
+		},
+		"POST /v1/admin/bookings/{bookingID}/move": {
+			Summary:    "Move a stay, or its remaining nights, to another room of the hotel, admins and hotel staff",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			IfMatch:    true,
+			Body:       moveBookingRequest{},
+			Data:       types.MovedBooking{},
+		},
+		"GET /v1/admin/rooms/{roomID}/blocks": {
+			Summary: "List the blocks of a room, admin only",
+			Tags:    []string{"rooms"},
+			Auth:    true,
+			Data:    []types.RoomBlock{},
+		},
+		"POST /v1/admin/rooms/{roomID}/blocks": {
+			Summary:    "Take a room out of service for a date range, admin only",
+			Tags:       []string{"rooms"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       roomBlockRequest{},
+			Status:     201,
+			Data:       types.RoomBlock{},
+		},
+		"DELETE /v1/admin/rooms/{roomID}/blocks/{blockID}": {
+			Summary:    "Put a blocked room back in service, admin only",
+			Tags:       []string{"rooms"},
+			Auth:       true,
+			Idempotent: true,
+		},
+		"POST /v1/admin/bookings/{bookingID}/check-in": {
+			Summary:    "Check a guest in, the room must be clean or inspected, admins and hotel staff",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			Data:       types.Booking{},
+		},
+		"POST /v1/admin/bookings/{bookingID}/check-out": {
+			Summary:    "Check a guest out and mark the room dirty, admins and hotel staff",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			Data:       types.Booking{},
+		},
+		"GET /v1/admin/hotels/{hotelID}/housekeeping": {
+			Summary: "List the rooms to prepare on a day, by arrival time, admins and hotel staff",
+			Tags:    []string{"housekeeping"},
+			Auth:    true,
+			Query:   housekeepingTasksRequest{},
+			Data:    []types.HousekeepingTask{},
+		},
+		"GET /v1/analytics/hotels/{hotelID}": {
+			Summary: "Occupancy, ADR, RevPAR, booking pace, cancellations and stay length per period and room type, admins and revenue managers",
+			Tags:    []string{"analytics"},
+			Auth:    true,
+			Query:   analyticsRequest{},
+			Data:    types.HotelAnalytics{},
+		},
+		"GET /v1/admin/hotels/{hotelID}/reports/{kind}": {
+			Summary: "Arrivals, departures or in-house guests of a day, as json or csv, admins and hotel staff",
+			Tags:    []string{"reports"},
+			Auth:    true,
+			Query:   dailyReportRequest{},
+			Data:    types.DailyReport{},
+		},
+		"PUT /v1/admin/rooms/{roomID}/housekeeping": {
+			Summary:    "Report the cleaning state of a room, admins and hotel staff",
+			Tags:       []string{"housekeeping"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       housekeepingRequest{},
+			Data:       types.Room{},
+		},
+		"GET /v1/admin/audit": {
+			Summary:    "Search the audit log, admin only",
+			Tags:       []string{"audit"},
+			Auth:       true,
+			Query:      types.GetAuditRequest{},
+			Data:       []types.AuditEntry{},
+			Pagination: types.ResNumericPaginate{},
+		},
+		"GET /v1/bookings": {
+			Summary:    "List the bookings of the caller, userID and email are for admins",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Query:      types.GetBookingsRequest{},
+			Data:       []types.Booking{},
+			Pagination: types.ResCursorPaginate{},
+		},
+		"POST /v1/booking-lookup": {
+			Summary: "Find a booking by confirmation code and guest last name, without login",
+			Tags:    []string{"bookings"},
+			Body:    bookingLookupRequest{},
+			Data:    types.BookingDetail{},
+		},
+		"GET /v1/bookings/{bookingID}": {
+			Summary: "Get a booking with its room, hotel or guest, for the guest, admins and the staff of the hotel",
+			Tags:    []string{"bookings"},
+			Auth:    true,
+			Query:   getBookingRequest{},
+			Data:    types.BookingDetail{},
+		},
+		"PUT /v1/bookings/{bookingID}/cancel": {
+			Summary:    "Cancel a booking and refund its payments by the cancellation policy",
+			Tags:       []string{"bookings"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       cancelBookingRequest{},
+		},
+		"GET /v1/bookings/{bookingID}/payments": {
+			Summary: "List the payments of a booking",
+			Tags:    []string{"payments"},
+			Auth:    true,
+			Data:    []types.Payment{},
+		},
+		"POST /v1/admin/payments/{paymentID}/refund": {
+			Summary:    "Refund a payment, in full when no amount is given, admin only",
+			Tags:       []string{"payments"},
+			Auth:       true,
+			Idempotent: true,
+			Body:       refundPaymentRequest{},
+			Data:       types.Payment{},
+		},
+	}
+)
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+// storeError lets the typed store errors through to the server error handler,
+// anything else is an unexpected failure answered with a 500 carrying msg.
+func storeError(err error, msg string) error {
+	var domainErr *store.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+	return types.NewError(err, fiber.StatusInternalServerError, msg)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+// setETag exposes the document version, clients send it back in If-Match to
+// update the document.
+func setETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// ifMatchVersion reads the version the client expects to overwrite.
+func ifMatchVersion(c *fiber.Ctx) (int64, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" {
+		return 0, utils.PreconditionRequiredError()
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+	if err != nil || version < 0 {
+		return 0, utils.BadRequestError("If-Match must be an ETag returned by the api")
+	}
+
+	return version, nil
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleExportMe answers a subject access request with everything kept about
+// the caller, as a downloadable json archive.
+func (h *Handler) HandleExportMe(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	bookings, err := h.bookingStore.GetBookingsAsUser(c.UserContext(), user)
+	if err != nil {
+		return storeError(err, "Error exporting bookings")
+	}
+
+	originalIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, b := range bookings {
+		if b.SplitFromID == nil {
+			originalIDs = append(originalIDs, b.ID)
+		}
+	}
+	payments, err := h.paymentStore.GetPaymentsByBookingIDs(c.UserContext(), originalIDs)
+	if err != nil {
+		return storeError(err, "Error exporting payments")
+	}
+
+	groups, err := h.groupStore.GetGroupBookingsByUserID(c.UserContext(), user.ID)
+	if err != nil {
+		return storeError(err, "Error exporting group bookings")
+	}
+
+	entries := []*types.AuditEntry{}
+	if h.auditStore != nil {
+		if entries, err = h.auditStore.GetUserAuditEntries(c.UserContext(), user.ID); err != nil {
+			return storeError(err, "Error exporting audit entries")
+		}
+	}
+
+	c.Attachment(fmt.Sprintf("export-%s.json", user.ID.Hex()))
+	return c.Status(fiber.StatusOK).JSON(&types.DataExport{
+		GeneratedAt:  time.Now().UTC(),
+		User:         user,
+		Bookings:     bookings,
+		Payments:     payments,
+		Groups:       groups,
+		AuditEntries: entries,
+	})
+}
+
+func (h *Handler) HandleEraseMe(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(eraseMeRequestKey).(*eraseMeRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", eraseMeRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	params := types.AuthParams{Password: req.Password}
+	if !params.IsValidPassword(user.EncryptedPassword) {
+		return utils.InvalidCredError()
+	}
+
+	if err := h.eraseUser(c, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    "your personal data has been erased",
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleEraseUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := h.eraseUser(c, id); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    fmt.Sprintf("User %s erased", id),
+		Status: fiber.StatusOK,
+	})
+}
+
+// eraseUser anonymizes the user, marks its bookings, clears the lead guest of
+// its groups and redacts the audit log.
+// Every step can run again, a failed erasure is retried by sending it again.
+func (h *Handler) eraseUser(c *fiber.Ctx, id string) error {
+	user, err := h.userStore.EraseUser(c.UserContext(), id)
+	if err != nil {
+		return storeError(err, "Error erasing user")
+	}
+
+	if _, err := h.bookingStore.EraseUserBookings(c.UserContext(), user.ID); err != nil {
+		return storeError(err, "Error erasing bookings")
+	}
+	if _, err := h.groupStore.EraseUserGroupBookings(c.UserContext(), user.ID); err != nil {
+		return storeError(err, "Error erasing group bookings")
+	}
+
+	// appended before the redaction so the IP of a self erasure goes too
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserErased,
+		TargetType: types.AuditTargetUser,
+		TargetID:   user.ID,
+	}, nil, nil)
+
+	if h.auditStore != nil {
+		if _, err := h.auditStore.RedactUser(c.UserContext(), user.ID); err != nil {
+			return storeError(err, "Error redacting the audit log")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func TestPersonalData(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user    = fixtures.AddUser(*tdb.Store, "gdpr", "guest", false)
+		hotel   = fixtures.AddHotel(*tdb.Store, "gdpr hotel", "Berlin", 4, nil)
+		room    = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 99.9)
+		from    = time.Now().AddDate(0, 0, 3)
+		booking = fixtures.AddBooking(*tdb.Store, user.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+	)
+	token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+
+	erase := func(t *testing.T, password string) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(map[string]string{"password": password})
+		testReq := utils.TestRequest{Method: "POST", Target: "/v1/me/erasure", Payload: bytes.NewReader(b), Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if _, err := tdb.Store.Payment.InsertPayment(t.Context(), &types.Payment{
+		BookingID: booking.ID,
+		UserID:    user.ID,
+		Mode:      types.PayAtHotel,
+		Status:    types.PaymentPending,
+		Total:     19980,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exports the profile, the bookings and the payments", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/v1/me/export", Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+		if disposition := resp.Header.Get(fiber.HeaderContentDisposition); disposition == "" {
+			t.Errorf("expected the export to be an attachment")
+		}
+
+		var export types.DataExport
+		if err := json.NewDecoder(resp.Body).Decode(&export); err != nil {
+			t.Fatal(err)
+		}
+		if export.User == nil || export.User.Email != user.Email {
+			t.Errorf("expected the profile of %s, got %+v", user.Email, export.User)
+		}
+		if len(export.Bookings) != 1 || export.Bookings[0].ID != booking.ID {
+			t.Errorf("expected the booking %s, got %+v", booking.ID.Hex(), export.Bookings)
+		}
+		if len(export.Payments) != 1 || export.Payments[0].BookingID != booking.ID {
+			t.Errorf("expected the payment of %s, got %+v", booking.ID.Hex(), export.Payments)
+		}
+	})
+
+	t.Run("erasure asks for the password", func(t *testing.T) {
+		resp := erase(t, "not the password")
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Fatalf("expected 401 status code but received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("erasure anonymizes the user and keeps the bookings", func(t *testing.T) {
+		resp := erase(t, "gdpr_guest")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+
+		deleted, _, err := tdb.Store.User.GetDeletedUsers(t.Context(), types.NewQueryNumericPaginate(100, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		anonymized := slices.ContainsFunc(deleted, func(u *types.User) bool {
+			return u.ID == user.ID && u.Email == types.ErasedEmail(user.ID) && u.FirstName == types.ErasedFirstName
+		})
+		if !anonymized {
+			t.Errorf("expected %s to be anonymized", user.ID.Hex())
+		}
+
+		kept, err := tdb.Store.Booking.GetBookingsByID(t.Context(), booking.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kept.ErasedAt == nil || kept.UserID != user.ID {
+			t.Errorf("expected the booking to be kept and marked erased, got %+v", kept)
+		}
+
+		testReq := utils.TestRequest{Method: "GET", Target: "/v1/me/export", Token: token}
+		resp, err = app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("expected the token of an erased user to be refused, received %d", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	eraseMeRequestKey = "eraseMeReq"
+)
+
+// eraseMeRequest asks for the password again, the erasure can not be undone.
+type eraseMeRequest struct {
+	Password string `validate:"required,min=7,max=256" json:"password"`
+}
+
+func EraseMeRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params eraseMeRequest
+	if err := c.BodyParser(&params); err != nil {
+		return nil, eraseMeRequestKey, utils.BadRequestError(err.Error())
+	}
+
+	return &params, eraseMeRequestKey, nil
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+// HandlePostGroupBooking books every room of a group in one transaction. The
+// group is settled at the hotel, each room gets its pending payment.
+func (h *Handler) HandlePostGroupBooking(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(groupBookingRequestKey).(*groupBookingRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", groupBookingRequestKey)
+		return utils.BadRequestError("")
+	}
+	req.params.UserID = user.ID
+
+	group, bookings, err := h.groupStore.InsertGroupBooking(c.UserContext(), req.params)
+	if err != nil {
+		return storeError(err, "Error booking group")
+	}
+
+	rooms, err := h.roomStore.GetRoomsByHotelID(c.UserContext(), req.params.HotelID)
+	if err != nil {
+		slog.ErrorContext(c.UserContext(), "rooms of group not loaded", "groupID", group.ID.Hex(), logger.Err(err))
+	}
+	prices := make(map[string]*types.Room, len(rooms))
+	for _, room := range rooms {
+		prices[room.ID.Hex()] = room
+	}
+
+	for _, booking := range bookings {
+		if room, ok := prices[booking.RoomID.Hex()]; ok {
+			total := payment.StayAmount(room, booking.FromDate, booking.TillDate)
+			if _, err := h.paymentStore.InsertPayment(c.UserContext(), newPayment(booking, total)); err != nil {
+				slog.ErrorContext(c.UserContext(), "payment of group booking not recorded", "bookingID", booking.ID.Hex(), logger.Err(err))
+			}
+		}
+
+		h.audit(c, &types.AuditEntry{
+			Action:     types.AuditBookingCreated,
+			TargetType: types.AuditTargetBooking,
+			TargetID:   booking.ID,
+		}, nil, booking)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&types.ResGeneric{
+		Data:   &types.GroupBookingWithBookings{GroupBooking: group, Bookings: bookings},
+		Status: fiber.StatusCreated,
+	})
+}
+
+func (h *Handler) HandleGetGroupBooking(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	group, err := h.userGroup(c, user, c.Params("groupID"))
+	if err != nil {
+		return err
+	}
+
+	bookings, err := h.groupStore.GetGroupBookings(c.UserContext(), group.ID)
+	if err != nil {
+		return storeError(err, "Error getting group booking")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   &types.GroupBookingWithBookings{GroupBooking: group, Bookings: bookings},
+		Status: fiber.StatusOK,
+	})
+}
+
+// HandleCancelGroupRoom cancels one room of a group, the other rooms stay
+// booked.
+func (h *Handler) HandleCancelGroupRoom(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(cancelGroupRoomKey).(*cancelGroupRoomRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", cancelGroupRoomKey)
+		return utils.BadRequestError("")
+	}
+
+	group, err := h.userGroup(c, user, req.GroupID)
+	if err != nil {
+		return err
+	}
+
+	inGroup := false
+	for _, id := range group.BookingIDs {
+		inGroup = inGroup || id.Hex() == req.BookingID
+	}
+	if !inGroup {
+		return store.NotFoundError(store.CodeBookingNotFound, "no booking found with id "+req.BookingID+" in the group")
+	}
+
+	booking, err := h.cancelBooking(c, user, req.BookingID, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	setETag(c, booking.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    fmt.Sprintf("booking %s of group %s has been canceled", req.BookingID, group.Reference),
+		Status: fiber.StatusOK,
+	})
+}
+
+// userGroup is a group booked by the user, any group for an admin. The group
+// of another guest is answered as missing.
+func (h *Handler) userGroup(c *fiber.Ctx, user *types.User, groupID string) (*types.GroupBooking, error) {
+	group, err := h.groupStore.GetGroupBookingByID(c.UserContext(), groupID)
+	if err != nil {
+		return nil, storeError(err, "Error getting group booking")
+	}
+	if !user.IsAdmin && group.UserID != user.ID {
+		return nil, store.NotFoundError(store.CodeGroupNotFound, "no group booking found with id "+groupID)
+	}
+
+	return group, nil
+}
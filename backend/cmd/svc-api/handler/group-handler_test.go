@@ -0,0 +1,138 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+func TestGroupBookings(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		lead          = fixtures.AddUser(*tdb.Store, "group", "lead", false)
+		other         = fixtures.AddUser(*tdb.Store, "group", "stranger", false)
+		hotel         = fixtures.AddHotel(*tdb.Store, "group hotel", "Madrid", 4, nil)
+		leadToken, _  = tokener.GenerateJWT(lead.ID.Hex(), lead.IsAdmin, config)
+		otherToken, _ = tokener.GenerateJWT(other.ID.Hex(), other.IsAdmin, config)
+		from          = time.Now().AddDate(0, 0, 20)
+		till          = from.AddDate(0, 0, 2)
+		leadGuest     = map[string]any{"firstName": "Ana", "lastName": "Garcia", "email": "ana@example.com"}
+	)
+
+	decode := func(t *testing.T, resp *http.Response) *types.GroupBookingWithBookings {
+		t.Helper()
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var group types.GroupBookingWithBookings
+		if err := json.Unmarshal(b, &group); err != nil {
+			t.Fatal(err)
+		}
+		return &group
+	}
+
+	groupOf := func(rooms ...map[string]any) map[string]any {
+		return map[string]any{
+			"hotelID":   hotel.ID.Hex(),
+			"leadGuest": leadGuest,
+			"fromDate":  from,
+			"tillDate":  till,
+			"rooms":     rooms,
+		}
+	}
+
+	t.Run("books rooms by id and by type under one reference", func(t *testing.T) {
+		picked := fixtures.AddRoom(*tdb.Store, types.SuiteRoomType, hotel.ID, 150)
+		fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 100)
+		fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 100)
+
+		resp := send(t, app, "POST", "/v1/groups", leadToken, groupOf(
+			map[string]any{"roomID": picked.ID.Hex(), "countPerson": 2},
+			map[string]any{"type": types.FamilyRoomType, "count": 2, "countPerson": 4},
+		))
+		expectStatus(t, resp, fiber.StatusCreated)
+
+		group := decode(t, resp)
+		if len(group.Bookings) != 3 || len(group.BookingIDs) != 3 || group.Reference == "" {
+			t.Fatalf("unexpected group %+v", group)
+		}
+		for _, b := range group.Bookings {
+			if b.GroupID == nil || *b.GroupID != group.ID {
+				t.Fatalf("booking %s is not linked to the group", b.ID.Hex())
+			}
+		}
+
+		expectStatus(t, send(t, app, "GET", "/v1/groups/"+group.ID.Hex(), otherToken, nil), fiber.StatusNotFound)
+		resp = send(t, app, "GET", "/v1/groups/"+group.ID.Hex(), leadToken, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+		if fetched := decode(t, resp); len(fetched.Bookings) != 3 {
+			t.Fatalf("expected 3 bookings but received %d", len(fetched.Bookings))
+		}
+	})
+
+	t.Run("one unavailable room books none", func(t *testing.T) {
+		free := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		taken := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		fixtures.AddBooking(*tdb.Store, other.ID, taken.ID.Hex(), from, till)
+
+		resp := send(t, app, "POST", "/v1/groups", leadToken, groupOf(
+			map[string]any{"roomID": free.ID.Hex(), "countPerson": 2},
+			map[string]any{"roomID": taken.ID.Hex(), "countPerson": 2},
+		))
+		expectStatus(t, resp, fiber.StatusConflict)
+
+		bookings, err := tdb.Store.Booking.GetBookingsByRoomID(t.Context(), &types.BookingParam{
+			RoomID:   free.ID.Hex(),
+			FromDate: time.Now(),
+			TillDate: till.AddDate(0, 0, 1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bookings) != 0 {
+			t.Fatalf("expected no booking of the free room but found %d", len(bookings))
+		}
+	})
+
+	t.Run("a room of the group is canceled alone", func(t *testing.T) {
+		first := fixtures.AddRoom(*tdb.Store, types.HoneyMoonRoomType, hotel.ID, 200)
+		second := fixtures.AddRoom(*tdb.Store, types.HoneyMoonRoomType, hotel.ID, 200)
+
+		resp := send(t, app, "POST", "/v1/groups", leadToken, groupOf(
+			map[string]any{"roomID": first.ID.Hex(), "countPerson": 2},
+			map[string]any{"roomID": second.ID.Hex(), "countPerson": 2},
+		))
+		expectStatus(t, resp, fiber.StatusCreated)
+		group := decode(t, resp)
+		canceled := group.Bookings[0].ID.Hex()
+		target := "/v1/groups/" + group.ID.Hex() + "/bookings/" + canceled + "/cancel"
+
+		expectStatus(t, send(t, app, "PUT", target, otherToken, nil), fiber.StatusNotFound)
+		expectStatus(t, send(t, app, "PUT", target, leadToken, map[string]any{"reason": "one guest less"}), fiber.StatusOK)
+
+		resp = send(t, app, "GET", "/v1/groups/"+group.ID.Hex(), leadToken, nil)
+		for _, b := range decode(t, resp).Bookings {
+			if b.Canceled != (b.ID.Hex() == canceled) {
+				t.Fatalf("unexpected cancel state of booking %s", b.ID.Hex())
+			}
+		}
+	})
+
+	t.Run("rejects a room asked by id and type", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		resp := send(t, app, "POST", "/v1/groups", leadToken, groupOf(
+			map[string]any{"roomID": room.ID.Hex(), "type": types.KingRoomType, "countPerson": 2},
+		))
+		expectStatus(t, resp, fiber.StatusBadRequest)
+	})
+}
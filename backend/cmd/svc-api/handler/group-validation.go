@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	groupBookingRequestKey = "groupBookingReq"
+	cancelGroupRoomKey     = "cancelGroupRoomReq"
+)
+
+type groupBookingRequest struct {
+	HotelID   string             `validate:"required,id" json:"hotelID"`
+	LeadGuest leadGuestRequest   `json:"leadGuest"`
+	FromDate  time.Time          `validate:"required" json:"fromDate"`
+	TillDate  time.Time          `validate:"required,gtfield=FromDate" json:"tillDate"`
+	Rooms     []groupRoomRequest `validate:"required,min=1,max=50,dive" json:"rooms"`
+
+	params *types.GroupBookingParam
+}
+
+type leadGuestRequest struct {
+	FirstName string `validate:"required,min=2,max=48" json:"firstName"`
+	LastName  string `validate:"required,min=2,max=48" json:"lastName"`
+	Email     string `validate:"required,email" json:"email"`
+	Phone     string `validate:"max=32" json:"phone"`
+}
+
+// groupRoomRequest asks for a room by id, or for count rooms of a type.
+type groupRoomRequest struct {
+	RoomID      string         `validate:"required_without=Type,excluded_with=Type,omitempty,id" json:"roomID"`
+	Type        types.RoomType `validate:"required_without=RoomID,omitempty,oneof=family family_suit suit honey_moon king" json:"type"`
+	Count       int            `validate:"required_with=Type,excluded_with=RoomID,omitempty,min=1,max=50" json:"count"`
+	CountPerson int            `validate:"required,min=1,max=20" json:"countPerson"`
+}
+
+func GroupBookingRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.GroupBookingParam
+	if err := c.BodyParser(&params); err != nil {
+		return nil, groupBookingRequestKey, utils.BadRequestError(err.Error())
+	}
+
+	if time.Now().After(params.FromDate) {
+		return nil, groupBookingRequestKey, utils.BadRequestError("cannot book a room in the past")
+	}
+
+	total := 0
+	asked := map[string]bool{}
+	rooms := make([]groupRoomRequest, len(params.Rooms))
+	for i, room := range params.Rooms {
+		if room.RoomID != "" {
+			if asked[room.RoomID] {
+				return nil, groupBookingRequestKey, utils.BadRequestError(fmt.Sprintf("room %s is asked twice", room.RoomID))
+			}
+			asked[room.RoomID] = true
+			total++
+		}
+		total += room.Count
+		rooms[i] = groupRoomRequest(room)
+	}
+	if total > types.MaxGroupRooms {
+		return nil, groupBookingRequestKey, utils.BadRequestError(fmt.Sprintf("a group books at most %d rooms", types.MaxGroupRooms))
+	}
+
+	return &groupBookingRequest{
+		HotelID:   params.HotelID,
+		LeadGuest: leadGuestRequest(params.LeadGuest),
+		FromDate:  params.FromDate,
+		TillDate:  params.TillDate,
+		Rooms:     rooms,
+		params:    &params,
+	}, groupBookingRequestKey, nil
+}
+
+type cancelGroupRoomRequest struct {
+	GroupID   string `validate:"required,id" json:"-"`
+	BookingID string `validate:"required,id" json:"-"`
+	Reason    string `validate:"max=512" json:"reason"`
+}
+
+func CancelGroupRoomRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params cancelGroupRoomRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&params); err != nil {
+			return nil, cancelGroupRoomKey, utils.BadRequestError(err.Error())
+		}
+	}
+	params.GroupID = c.Params("groupID")
+	params.BookingID = c.Params("bookingID")
+
+	return &params, cancelGroupRoomKey, nil
+}
@@ -1,12 +1,29 @@
 package handler
 
-import "github.com/tnguven/hotel-reservation-app/internals/store"
+import (
+	"github.com/tnguven/hotel-reservation-app/internals/health"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/ratelimit"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+)
 
 type Handler struct {
 	userStore    store.UserStore
 	hotelStore   store.HotelStore
 	roomStore    store.RoomStore
 	bookingStore store.BookingStore
+	holdStore    store.HoldStore
+	groupStore   store.GroupBookingStore
+	blockStore   store.BlockStore
+
+	idempotencyStore store.IdempotencyStore
+	auditStore       store.AuditStore
+	paymentStore     store.PaymentStore
+
+	readiness   *health.Readiness
+	rateLimiter *ratelimit.Limiter
+	// without a payment provider only pay at hotel bookings are accepted
+	paymentProvider payment.Provider
 }
 
 func NewHandler(stores *store.Stores) *Handler {
@@ -15,5 +32,27 @@ func NewHandler(stores *store.Stores) *Handler {
 		hotelStore:   stores.Hotel,
 		roomStore:    stores.Room,
 		bookingStore: stores.Booking,
+		holdStore:    stores.Hold,
+		groupStore:   stores.Group,
+		blockStore:   stores.Block,
+
+		idempotencyStore: stores.Idempotency,
+		auditStore:       stores.Audit,
+		paymentStore:     stores.Payment,
 	}
 }
+
+func (h *Handler) WithReadiness(readiness *health.Readiness) *Handler {
+	h.readiness = readiness
+	return h
+}
+
+func (h *Handler) WithRateLimiter(limiter *ratelimit.Limiter) *Handler {
+	h.rateLimiter = limiter
+	return h
+}
+
+func (h *Handler) WithPaymentProvider(provider payment.Provider) *Handler {
+	h.paymentProvider = provider
+	return h
+}
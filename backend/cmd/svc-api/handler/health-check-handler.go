@@ -1,6 +1,11 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+)
 
 func (h *Handler) HandleHealthCheck() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -9,3 +14,38 @@ func (h *Handler) HandleHealthCheck() fiber.Handler {
 		})
 	}
 }
+
+// HandleReadiness answers 503 while a dependency check fails or the server is
+// draining, so the load balancer takes the instance out of rotation.
+func (h *Handler) HandleReadiness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if h.readiness == nil {
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"status": "ready",
+			})
+		}
+
+		// the errors may carry driver details, they only go to the logs
+		results, ready := h.readiness.Check(c.UserContext())
+		checks := make(map[string]string, len(results))
+		for name, err := range results {
+			checks[name] = "ok"
+			if err != nil {
+				checks[name] = "failed"
+				slog.WarnContext(c.UserContext(), "readiness check failed", "check", name, logger.Err(err))
+			}
+		}
+
+		if !ready {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"status": "not_ready",
+				"checks": checks,
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status": "ready",
+			"checks": checks,
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func TestHandleProbes(t *testing.T) {
+	config := NewConfig()
+	_, app := Setup(mDatabase, config)
+
+	t.Run("livez is always ok", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/livez"}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("readyz checks the dependencies", func(t *testing.T) {
+		testReq := utils.TestRequest{Method: "GET", Target: "/readyz"}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body struct {
+			Status string            `json:"status"`
+			Checks map[string]string `json:"checks"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 status code but received %d: %+v", resp.StatusCode, body.Checks)
+		}
+
+		for _, name := range []string{"mongo", "replicaSet", "migrations"} {
+			if body.Checks[name] != "ok" {
+				t.Errorf("expected %s check to be ok, got %q", name, body.Checks[name])
+			}
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+func (h *Handler) HandlePostHold(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(holdRoomRequestKey).(*holdRoomRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", holdRoomRequestKey)
+		return utils.BadRequestError("")
+	}
+	req.params.UserID = user.ID
+
+	hold, err := h.holdStore.InsertHold(c.UserContext(), req.params)
+	if err != nil {
+		return storeError(err, "Error holding room")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(&types.ResGeneric{
+		Data:   hold,
+		Status: fiber.StatusCreated,
+	})
+}
+
+func (h *Handler) HandleGetHold(c *fiber.Ctx) error {
+	hold, err := h.userHold(c, c.Params("holdID"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   hold,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleReleaseHold(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	holdID := c.Params("holdID")
+	if err := h.holdStore.ReleaseHold(c.UserContext(), holdID, user.ID); err != nil {
+		return storeError(err, "Error releasing hold")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    "hold " + holdID + " has been released",
+		Status: fiber.StatusOK,
+	})
+}
+
+// HandleConfirmHold books the held stay, the hold is released in the booking
+// transaction.
+func (h *Handler) HandleConfirmHold(c *fiber.Ctx) error {
+	req, ok := c.Locals(confirmHoldRequestKey).(*confirmHoldRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", confirmHoldRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	hold, err := h.userHold(c, req.HoldID)
+	if err != nil {
+		return err
+	}
+
+	return h.bookRoom(c, &types.BookingParam{
+		RoomID:            hold.RoomID.Hex(),
+		UserID:            hold.UserID,
+		CountPerson:       hold.CountPerson,
+		FromDate:          hold.FromDate,
+		TillDate:          hold.TillDate,
+		PaymentMode:       req.PaymentMode,
+		Card:              req.card,
+		ChallengeResponse: req.ChallengeResponse,
+		HoldID:            hold.ID.Hex(),
+	})
+}
+
+// userHold answers the holds of other guests as missing.
+func (h *Handler) userHold(c *fiber.Ctx, holdID string) (*types.Hold, error) {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return nil, utils.UnauthorizedError()
+	}
+
+	hold, err := h.holdStore.GetHoldByID(c.UserContext(), holdID)
+	if err != nil {
+		return nil, storeError(err, "Error getting hold")
+	}
+	if hold.UserID != user.ID {
+		return nil, store.NotFoundError(store.CodeHoldNotFound, "the hold "+holdID+" expired or does not exist")
+	}
+
+	return hold, nil
+}
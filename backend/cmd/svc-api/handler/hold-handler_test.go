@@ -0,0 +1,112 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+func TestRoomHolds(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "holding", "guest", false)
+		other         = fixtures.AddUser(*tdb.Store, "late", "guest", false)
+		hotel         = fixtures.AddHotel(*tdb.Store, "hold hotel", "Porto", 4, nil)
+		guestToken, _ = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		otherToken, _ = tokener.GenerateJWT(other.ID.Hex(), other.IsAdmin, config)
+		from          = time.Now().AddDate(0, 0, 10)
+		till          = from.AddDate(0, 0, 3)
+	)
+
+	stay := types.BookingParam{FromDate: from, TillDate: till, CountPerson: 2}
+
+	hold := func(t *testing.T, room *types.Room, token string) *types.Hold {
+		t.Helper()
+		resp := send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/holds", token, stay)
+		expectStatus(t, resp, fiber.StatusCreated)
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var h types.Hold
+		if err := json.Unmarshal(b, &h); err != nil {
+			t.Fatal(err)
+		}
+		return &h
+	}
+
+	t.Run("a hold in the past is a bad request", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		past := types.BookingParam{FromDate: time.Now().AddDate(0, 0, -2), TillDate: time.Now().AddDate(0, 0, 1), CountPerson: 2}
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/holds", guestToken, past), fiber.StatusBadRequest)
+	})
+
+	t.Run("a hold blocks the other guests", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		h := hold(t, room, guestToken)
+		if minutes := time.Until(h.ExpiresAt).Minutes(); minutes < 14 || minutes > 15 {
+			t.Fatalf("expected a 15 minutes hold, expires in %.1f minutes", minutes)
+		}
+
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", otherToken, stay), fiber.StatusConflict)
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/holds", otherToken, stay), fiber.StatusConflict)
+		expectStatus(t, send(t, app, "GET", "/v1/holds/"+h.ID.Hex(), otherToken, nil), fiber.StatusNotFound)
+	})
+
+	t.Run("confirming turns the hold into a booking", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		h := hold(t, room, guestToken)
+
+		resp := send(t, app, "POST", "/v1/holds/"+h.ID.Hex()+"/confirm", guestToken, map[string]any{"paymentMode": types.PayAtHotel})
+		expectStatus(t, resp, fiber.StatusCreated)
+
+		bookings, err := tdb.Store.Booking.GetBookingsAsUser(t.Context(), guest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, b := range bookings {
+			found = found || b.RoomID == room.ID && b.FromDate.Equal(h.FromDate)
+		}
+		if !found {
+			t.Fatalf("expected a booking of room %s", room.ID.Hex())
+		}
+
+		expectStatus(t, send(t, app, "GET", "/v1/holds/"+h.ID.Hex(), guestToken, nil), fiber.StatusNotFound)
+		expectStatus(t, send(t, app, "POST", "/v1/holds/"+h.ID.Hex()+"/confirm", guestToken, nil), fiber.StatusNotFound)
+	})
+
+	t.Run("a released hold frees the room", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		h := hold(t, room, guestToken)
+
+		expectStatus(t, send(t, app, "DELETE", "/v1/holds/"+h.ID.Hex(), guestToken, nil), fiber.StatusOK)
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", otherToken, stay), fiber.StatusCreated)
+	})
+
+	t.Run("an expired hold blocks nothing", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		_, err := tdb.db.Collection("holds").InsertOne(t.Context(), &types.Hold{
+			RoomID:      room.ID,
+			UserID:      guest.ID,
+			CountPerson: 2,
+			FromDate:    from,
+			TillDate:    till,
+			ExpiresAt:   time.Now().Add(-time.Minute),
+			CreatedAt:   time.Now().Add(-16 * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectStatus(t, send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", otherToken, stay), fiber.StatusCreated)
+	})
+}
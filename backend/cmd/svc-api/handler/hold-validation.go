@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	holdRoomRequestKey    = "holdRoomReq"
+	confirmHoldRequestKey = "confirmHoldReq"
+)
+
+type holdRoomRequest struct {
+	FromDate  time.Time `validate:"required" json:"fromDate"`
+	TillDate  time.Time `validate:"required,gtfield=FromDate" json:"tillDate"`
+	NumPerson int       `validate:"required,numeric,min=1,max=20" json:"countPerson"`
+	Minutes   int       `validate:"min=0,max=30" json:"minutes"`
+
+	params *types.HoldParam
+}
+
+func HoldRoomRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.HoldParam
+	if err := c.BodyParser(&params); err != nil {
+		return nil, holdRoomRequestKey, utils.BadRequestError(err.Error())
+	}
+	params.RoomID = c.Params("roomID")
+
+	if time.Now().After(params.FromDate) {
+		return nil, holdRoomRequestKey, utils.BadRequestError("cannot hold a room in the past")
+	}
+
+	return &holdRoomRequest{
+		FromDate:  params.FromDate,
+		TillDate:  params.TillDate,
+		NumPerson: params.CountPerson,
+		Minutes:   params.Minutes,
+		params:    &params,
+	}, holdRoomRequestKey, nil
+}
+
+// confirmHoldRequest pays the hold, the stay itself comes from the hold.
+type confirmHoldRequest struct {
+	HoldID            string            `validate:"required,id" json:"-"`
+	PaymentMode       types.PaymentMode `validate:"oneof=pay_now pay_at_hotel deposit" json:"paymentMode"`
+	Card              *cardRequest      `validate:"required_unless=PaymentMode pay_at_hotel" json:"card"`
+	ChallengeResponse string            `validate:"max=256" json:"challengeResponse"`
+
+	card *types.Card
+}
+
+func ConfirmHoldRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.BookingParam
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&params); err != nil {
+			return nil, confirmHoldRequestKey, utils.BadRequestError(err.Error())
+		}
+	}
+
+	return &confirmHoldRequest{
+		HoldID:            c.Params("holdID"),
+		PaymentMode:       bookingPaymentMode(&params),
+		Card:              newCardRequest(params.Card),
+		ChallengeResponse: params.ChallengeResponse,
+		card:              params.Card,
+	}, confirmHoldRequestKey, nil
+}
@@ -1,29 +1,25 @@
 package handler
 
 import (
-	"errors"
+	"fmt"
+	"log/slog"
 
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (h *Handler) HandleGetHotels(c *fiber.Ctx) error {
 	qParams, ok := c.Locals(getHotelsRequestKey).(*types.GetHotelsRequest)
 	if !ok {
-		log.Errorf("locals %s field missing", getHotelRequestKey)
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getHotelsRequestKey)
 		return utils.BadRequestError("")
 	}
 
-	hotels, total, err := h.hotelStore.GetHotels(c.Context(), qParams)
+	hotels, total, err := h.hotelStore.GetHotels(c.UserContext(), qParams)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return utils.NotFoundError()
-		}
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting hotels")
+		return storeError(err, "Error getting hotels")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
@@ -42,9 +38,9 @@ func (h *Handler) HandleGetHotels(c *fiber.Ctx) error {
 func (h *Handler) HandleGetRoomsByHotelID(c *fiber.Ctx) error {
 	hotelID := c.Params("hotelID")
 
-	rooms, err := h.roomStore.GetRoomsByHotelID(c.Context(), hotelID)
+	rooms, err := h.roomStore.GetRoomsByHotelID(c.UserContext(), hotelID)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting rooms")
+		return storeError(err, "Error getting rooms")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
@@ -55,13 +51,121 @@ func (h *Handler) HandleGetRoomsByHotelID(c *fiber.Ctx) error {
 
 func (h *Handler) HandleGetHotel(c *fiber.Ctx) error {
 	hotelID := c.Params("hotelID")
-	hotel, err := h.hotelStore.GetHotelByID(c.Context(), hotelID)
+	hotel, err := h.hotelStore.GetHotelByID(c.UserContext(), hotelID)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting hotel")
+		return storeError(err, "Error getting hotel")
 	}
 
+	setETag(c, hotel.Version)
 	return c.Status(fiber.StatusFound).JSON(&types.ResGeneric{
 		Data:   hotel,
 		Status: fiber.StatusFound,
 	})
 }
+
+func (h *Handler) HandlePutHotel(c *fiber.Ctx) error {
+	req, ok := c.Locals(putHotelRequestKey).(*updateHotelRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", putHotelRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.hotelStore.GetHotelByID(c.UserContext(), req.HotelID)
+	if err != nil {
+		return storeError(err, "Error updating hotel")
+	}
+
+	hotel, err := h.hotelStore.UpdateHotel(c.UserContext(), req.HotelID, &types.UpdateHotelParams{
+		Name:     req.Name,
+		Location: req.Location,
+		Rating:   req.Rating,
+	}, version)
+	if err != nil {
+		return storeError(err, "Error updating hotel")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditHotelUpdated,
+		TargetType: types.AuditTargetHotel,
+		TargetID:   hotel.ID,
+	}, before, hotel)
+
+	setETag(c, hotel.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   hotel,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleDeleteHotel(c *fiber.Ctx) error {
+	id := c.Params("hotelID")
+
+	before, err := h.hotelStore.GetHotelByID(c.UserContext(), id)
+	if err != nil {
+		return storeError(err, "Error deleting hotel")
+	}
+
+	if err := h.hotelStore.DeleteHotel(c.UserContext(), id); err != nil {
+		return storeError(err, "Error deleting hotel")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditHotelDeleted,
+		TargetType: types.AuditTargetHotel,
+		TargetID:   before.ID,
+	}, before, nil)
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    fmt.Sprintf("Hotel %s deleted", id),
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleGetDeletedHotels(c *fiber.Ctx) error {
+	query, ok := c.Locals(getDeletedRequestKey).(*types.QueryNumericPaginate)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getDeletedRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	hotels, total, err := h.hotelStore.GetDeletedHotels(c.UserContext(), query)
+	if err != nil {
+		return storeError(err, "Error getting deleted hotels")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
+		ResGeneric: types.ResGeneric{
+			Data:   hotels,
+			Status: fiber.StatusOK,
+		},
+		Pagination: types.ResNumericPaginate{
+			Count: total,
+			Page:  query.Page,
+			Limit: int(query.Limit),
+		},
+	})
+}
+
+func (h *Handler) HandleRestoreHotel(c *fiber.Ctx) error {
+	hotel, err := h.hotelStore.RestoreHotel(c.UserContext(), c.Params("hotelID"))
+	if err != nil {
+		return storeError(err, "Error restoring hotel")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditHotelRestored,
+		TargetType: types.AuditTargetHotel,
+		TargetID:   hotel.ID,
+	}, nil, hotel)
+
+	setETag(c, hotel.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   hotel,
+		Status: fiber.StatusOK,
+	})
+}
@@ -3,11 +3,13 @@ package handler
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
 )
 
 const (
 	getHotelRequestKey  = "getHotelReq"
 	getHotelsRequestKey = "getHotelsReq"
+	putHotelRequestKey  = "putHotelReq"
 )
 
 type getHotelRequest struct {
@@ -20,6 +22,23 @@ func GetHotelRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
 	}, getHotelRequestKey, nil
 }
 
+type updateHotelRequest struct {
+	HotelID  string `validate:"required,id" json:"-"`
+	Name     string `validate:"omitempty,min=2,max=64" json:"name"`
+	Location string `validate:"omitempty,min=2,max=64" json:"location"`
+	Rating   int    `validate:"omitempty,min=1,max=5" json:"rating"`
+}
+
+func UpdateHotelRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params updateHotelRequest
+	if err := c.BodyParser(&params); err != nil {
+		return nil, putHotelRequestKey, utils.BadRequestError(err.Error())
+	}
+	params.HotelID = c.Params("hotelID")
+
+	return &params, putHotelRequestKey, nil
+}
+
 func GetHotelsQueryRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
 	return &types.GetHotelsRequest{
 		Rating:               c.QueryInt("rating", 0),
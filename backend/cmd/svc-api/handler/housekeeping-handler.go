@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *Handler) HandleGetHousekeepingTasks(c *fiber.Ctx) error {
+	req, ok := c.Locals(housekeepingTasksRequestKey).(*housekeepingTasksRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", housekeepingTasksRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	hotel, err := h.hotelStore.GetHotelByID(c.UserContext(), req.HotelID)
+	if err != nil {
+		return storeError(err, "Error getting housekeeping tasks")
+	}
+	if err := staffOf(c, hotel.ID); err != nil {
+		return err
+	}
+
+	tasks, err := h.roomStore.GetHousekeepingTasks(c.UserContext(), req.HotelID, req.Date)
+	if err != nil {
+		return storeError(err, "Error getting housekeeping tasks")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   tasks,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandlePutHousekeeping(c *fiber.Ctx) error {
+	req, ok := c.Locals(housekeepingRequestKey).(*housekeepingRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", housekeepingRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	before, err := h.roomStore.GetRoomByID(c.UserContext(), req.RoomID)
+	if err != nil {
+		return storeError(err, "Error updating housekeeping")
+	}
+	if err := staffOf(c, before.HotelID); err != nil {
+		return err
+	}
+	room, err := h.roomStore.SetHousekeeping(c.UserContext(), req.RoomID, req.Status)
+	if err != nil {
+		return storeError(err, "Error updating housekeeping")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditRoomHousekeeping,
+		TargetType: types.AuditTargetRoom,
+		TargetID:   room.ID,
+	}, before, room)
+
+	setETag(c, room.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   room,
+		Status: fiber.StatusOK,
+	})
+}
+
+// HandleCheckIn is refused while the room is not clean or inspected.
+func (h *Handler) HandleCheckIn(c *fiber.Ctx) error {
+	return h.stayTransition(c, types.AuditBookingCheckedIn, h.bookingStore.CheckIn)
+}
+
+// HandleCheckOut marks the room dirty for housekeeping.
+func (h *Handler) HandleCheckOut(c *fiber.Ctx) error {
+	return h.stayTransition(c, types.AuditBookingCheckedOut, h.bookingStore.CheckOut)
+}
+
+func (h *Handler) stayTransition(
+	c *fiber.Ctx,
+	action types.AuditAction,
+	transition func(context.Context, string) (*types.Booking, error),
+) error {
+	bookingID := c.Params("bookingID")
+	before, err := h.bookingStore.GetBookingDetail(c.UserContext(), bookingID, nil)
+	if err != nil {
+		return storeError(err, "Error updating stay")
+	}
+	if err := staffOf(c, before.HotelID); err != nil {
+		return err
+	}
+
+	booking, err := transition(c.UserContext(), bookingID)
+	if err != nil {
+		return storeError(err, "Error updating stay")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     action,
+		TargetType: types.AuditTargetBooking,
+		TargetID:   booking.ID,
+	}, &before.Booking, booking)
+
+	setETag(c, booking.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   booking,
+		Status: fiber.StatusOK,
+	})
+}
+
+// staffOf lets through the admins and the staff of the hotel, the role itself
+// is checked by the route.
+func staffOf(c *fiber.Ctx, hotelID primitive.ObjectID) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+	if !user.WorksAt(hotelID) {
+		return utils.AccessForbiddenError()
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHousekeeping(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "tidy", "guest", false)
+		staff         = fixtures.AddUser(*tdb.Store, "house", "keeper", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "tidy hotel", "Oslo", 4, nil)
+		staffToken, _ = tokener.GenerateJWT(staff.ID.Hex(), staff.IsAdmin, config)
+	)
+
+	setStatus := func(t *testing.T, room *types.Room, status types.HousekeepingStatus) {
+		t.Helper()
+		expectStatus(t, send(t, app, "PUT", "/v1/admin/rooms/"+room.ID.Hex()+"/housekeeping", staffToken, map[string]any{"status": status}), fiber.StatusOK)
+	}
+
+	t.Run("check in needs a clean room and check out dirties it", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		from := time.Now()
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+		target := "/v1/admin/bookings/" + booking.ID.Hex()
+
+		setStatus(t, room, types.HousekeepingDirty)
+		expectStatus(t, send(t, app, "POST", target+"/check-in", staffToken, nil), fiber.StatusConflict)
+		expectStatus(t, send(t, app, "POST", target+"/check-out", staffToken, nil), fiber.StatusConflict)
+
+		setStatus(t, room, types.HousekeepingInspected)
+		expectStatus(t, send(t, app, "POST", target+"/check-in", staffToken, nil), fiber.StatusOK)
+		expectStatus(t, send(t, app, "POST", target+"/check-in", staffToken, nil), fiber.StatusConflict)
+		expectStatus(t, send(t, app, "POST", target+"/check-out", staffToken, nil), fiber.StatusOK)
+
+		room, err := tdb.Store.Room.GetRoomByID(t.Context(), room.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if room.Housekeeping != types.HousekeepingDirty {
+			t.Fatalf("expected a dirty room after check out, got %q", room.Housekeeping)
+		}
+	})
+
+	t.Run("check in waits for the arrival day", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		from := time.Now().AddDate(0, 0, 3)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 2))
+
+		setStatus(t, room, types.HousekeepingInspected)
+		expectStatus(t, send(t, app, "POST", "/v1/admin/bookings/"+booking.ID.Hex()+"/check-in", staffToken, nil), fiber.StatusConflict)
+	})
+
+	t.Run("a split keeps the guest checked in", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		next := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		from := time.Now().Add(-time.Hour)
+		booking := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), from, from.AddDate(0, 0, 3))
+		expectStatus(t, send(t, app, "POST", "/v1/admin/bookings/"+booking.ID.Hex()+"/check-in", staffToken, nil), fiber.StatusOK)
+
+		in, err := tdb.Store.Booking.GetBookingsByID(t.Context(), booking.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		original, rest, err := tdb.Store.Booking.MoveBooking(t.Context(), &types.MoveBookingParam{
+			BookingID: booking.ID.Hex(),
+			RoomID:    next.ID.Hex(),
+			Date:      from.AddDate(0, 0, 1),
+			MovedBy:   staff.ID,
+			Version:   in.Version,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if original.CheckedOutAt == nil || !original.CheckedOutAt.Equal(original.TillDate) {
+			t.Fatalf("expected the original stay to end at the move date, got %+v", original)
+		}
+		if rest.CheckedInAt == nil || !rest.CheckedInAt.Equal(*in.CheckedInAt) {
+			t.Fatalf("expected the remainder to keep the check-in, got %+v", rest)
+		}
+
+		expectStatus(t, send(t, app, "POST", "/v1/admin/bookings/"+rest.ID.Hex()+"/check-out", staffToken, nil), fiber.StatusOK)
+	})
+
+	t.Run("the task list follows the arrivals", func(t *testing.T) {
+		taskHotel := fixtures.AddHotel(*tdb.Store, "busy hotel", "Oslo", 4, nil)
+		day := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+		late := fixtures.AddRoom(*tdb.Store, types.KingRoomType, taskHotel.ID, 90)
+		early := fixtures.AddRoom(*tdb.Store, types.KingRoomType, taskHotel.ID, 90)
+		dirty := fixtures.AddRoom(*tdb.Store, types.KingRoomType, taskHotel.ID, 90)
+		fixtures.AddRoom(*tdb.Store, types.KingRoomType, taskHotel.ID, 90)
+		fixtures.AddBooking(*tdb.Store, guest.ID, late.ID.Hex(), day.Add(16*time.Hour), day.AddDate(0, 0, 2))
+		fixtures.AddBooking(*tdb.Store, guest.ID, early.ID.Hex(), day.Add(9*time.Hour), day.AddDate(0, 0, 2))
+		setStatus(t, dirty, types.HousekeepingDirty)
+
+		resp := send(t, app, "GET", "/v1/admin/hotels/"+taskHotel.ID.Hex()+"/housekeeping?date="+day.Format(time.DateOnly), staffToken, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var tasks []types.HousekeepingTask
+		if err := json.Unmarshal(b, &tasks); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tasks) != 3 {
+			t.Fatalf("expected 3 tasks but received %d", len(tasks))
+		}
+		for i, room := range []*types.Room{early, late, dirty} {
+			if tasks[i].RoomID != room.ID {
+				t.Fatalf("expected room %s at position %d, got %s", room.ID.Hex(), i, tasks[i].RoomID.Hex())
+			}
+		}
+		if tasks[2].Arrival != nil || tasks[2].Housekeeping != types.HousekeepingDirty {
+			t.Fatalf("unexpected task %+v", tasks[2])
+		}
+	})
+	t.Run("staff work at their own hotel only", func(t *testing.T) {
+		otherHotel := fixtures.AddHotel(*tdb.Store, "other tidy hotel", "Oslo", 4, nil)
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		local := fixtures.AddUser(*tdb.Store, "local", "keeper", false)
+		params := &types.SetRolesParams{Roles: []types.Role{types.RoleStaff}, HotelIDs: []primitive.ObjectID{hotel.ID}}
+		if _, err := tdb.Store.User.SetRoles(t.Context(), local.ID.Hex(), params, local.Version); err != nil {
+			t.Fatal(err)
+		}
+		localToken, _ := tokener.GenerateJWT(local.ID.Hex(), local.IsAdmin, config)
+		guestToken, _ := tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+
+		as := func(t *testing.T, token, method, target string, payload any) *http.Response {
+			t.Helper()
+			b, _ := json.Marshal(payload)
+			testReq := utils.TestRequest{Method: method, Target: target, Token: token, Payload: bytes.NewReader(b)}
+			resp, err := app.Test(testReq.NewRequestWithHeader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return resp
+		}
+
+		expectStatus(t, as(t, localToken, "GET", "/v1/admin/hotels/"+hotel.ID.Hex()+"/housekeeping", nil), fiber.StatusOK)
+		expectStatus(t, as(t, localToken, "PUT", "/v1/admin/rooms/"+room.ID.Hex()+"/housekeeping", map[string]any{"status": types.HousekeepingInspected}), fiber.StatusOK)
+		expectStatus(t, as(t, localToken, "GET", "/v1/admin/hotels/"+hotel.ID.Hex()+"/reports/arrivals", nil), fiber.StatusOK)
+		expectStatus(t, as(t, localToken, "GET", "/v1/admin/hotels/"+otherHotel.ID.Hex()+"/housekeeping", nil), fiber.StatusForbidden)
+		expectStatus(t, as(t, localToken, "GET", "/v1/admin/hotels/"+otherHotel.ID.Hex()+"/reports/arrivals", nil), fiber.StatusForbidden)
+		expectStatus(t, as(t, guestToken, "GET", "/v1/admin/hotels/"+hotel.ID.Hex()+"/housekeeping", nil), fiber.StatusForbidden)
+	})
+}
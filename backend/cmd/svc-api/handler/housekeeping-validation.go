@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	housekeepingRequestKey      = "housekeepingReq"
+	housekeepingTasksRequestKey = "housekeepingTasksReq"
+)
+
+type housekeepingRequest struct {
+	RoomID string                   `validate:"required,id" json:"-"`
+	Status types.HousekeepingStatus `validate:"required,oneof=clean dirty inspected in_progress" json:"status"`
+}
+
+func HousekeepingRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params housekeepingRequest
+	if err := c.BodyParser(&params); err != nil {
+		return nil, housekeepingRequestKey, utils.BadRequestError(err.Error())
+	}
+	params.RoomID = c.Params("roomID")
+
+	return &params, housekeepingRequestKey, nil
+}
+
+// housekeepingTasksRequest takes the day as YYYY-MM-DD, today in UTC when
+// missing.
+type housekeepingTasksRequest struct {
+	HotelID string    `validate:"required,id" query:"-"`
+	Date    time.Time `query:"date"`
+}
+
+func HousekeepingTasksRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	day, err := queryDay(c, "date")
+	if err != nil {
+		return nil, housekeepingTasksRequestKey, err
+	}
+
+	return &housekeepingTasksRequest{
+		HotelID: c.Params("hotelID"),
+		Date:    day,
+	}, housekeepingTasksRequestKey, nil
+}
@@ -5,12 +5,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/testcontainers/testcontainers-go/modules/mongodb"
 	"github.com/tnguven/hotel-reservation-app/db"
 	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
 	"github.com/tnguven/hotel-reservation-app/internals/must"
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
@@ -30,6 +32,7 @@ type TestConfigs struct {
 	tokenExpHour int64
 	listenAddr   string
 	log          bool
+	logLevel     string
 	env          string
 }
 
@@ -46,6 +49,7 @@ func NewConfig() *TestConfigs {
 		listenAddr:   ":5000",
 		env:          "test",
 		log:          true,
+		logLevel:     cmp.Or(os.Getenv("LOG_LEVEL"), "error"),
 	}
 }
 
@@ -81,6 +85,10 @@ func (conf *TestConfigs) WithLog() bool {
 	return conf.log
 }
 
+func (conf *TestConfigs) LogLevel() string {
+	return conf.logLevel
+}
+
 var (
 	mDatabase *repo.MongoDatabase
 )
@@ -100,6 +108,7 @@ func TestMain(m *testing.M) {
 
 	endpoint := must.Panic(mongoDBContainer.ConnectionString(ctx))
 	testConfig := NewConfig().WithMongoDbURI(endpoint)
+	slog.SetDefault(logger.New(os.Stdout, testConfig.LogLevel()))
 	mDatabase = utils.NewDb(testConfig)
 
 	list := must.Panic(mDatabase.GetDb().ListCollectionNames(context.TODO(), bson.M{}))
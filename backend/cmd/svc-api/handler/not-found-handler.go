@@ -1,9 +1,10 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
 
 func (h *Handler) HandleNotFound(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"error": "Page not found",
-	})
+	return utils.NotFoundError()
 }
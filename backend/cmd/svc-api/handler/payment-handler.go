@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+// authorization is the money held on the card until the booking is stored.
+type authorization struct {
+	reference string
+	amount    int64
+	cardLast4 string
+}
+
+func (h *Handler) HandleGetBookingPayments(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	bookingID := c.Params("bookingID")
+	booking, err := h.bookingStore.GetBookingDetail(c.UserContext(), bookingID, nil)
+	if err != nil {
+		return storeError(err, "Error getting payments")
+	}
+	// answer like a missing booking, the id of another guest's booking is
+	// not confirmed
+	if booking.UserID != user.ID && !user.WorksAt(booking.HotelID) {
+		return store.NotFoundError(store.CodeBookingNotFound, "no booking found with id "+bookingID)
+	}
+
+	// the payments of a split stay stay on its original booking
+	payments, err := h.paymentStore.GetPaymentsByBookingID(c.UserContext(), booking.OriginalID())
+	if err != nil {
+		return storeError(err, "Error getting payments")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   payments,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleRefundPayment(c *fiber.Ctx) error {
+	req, ok := c.Locals(refundPaymentRequestKey).(*refundPaymentRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", refundPaymentRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	p, err := h.paymentStore.GetPaymentByID(c.UserContext(), req.PaymentID)
+	if err != nil {
+		return storeError(err, "Error refunding payment")
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = p.Refundable()
+	}
+	if amount <= 0 {
+		return utils.PaymentNotRefundableError("nothing left to refund on this payment")
+	}
+	if amount > p.Refundable() {
+		return utils.PaymentNotRefundableError("the amount is above what is left to refund")
+	}
+
+	before := *p
+	if err := h.refundPayment(c.UserContext(), p, amount); err != nil {
+		return err
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditPaymentRefunded,
+		TargetType: types.AuditTargetPayment,
+		TargetID:   p.ID,
+		Reason:     req.Reason,
+	}, &before, p)
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   p,
+		Status: fiber.StatusOK,
+	})
+}
+
+// authorizePayment holds the charge of the payment mode on the card. Declines
+// and 3DS challenges are answered to the client, nothing is held then.
+func (h *Handler) authorizePayment(ctx context.Context, params *types.BookingParam, total int64) (*authorization, error) {
+	if !params.PaymentMode.Charged() {
+		return nil, nil
+	}
+	if h.paymentProvider == nil {
+		return nil, utils.PaymentModeUnavailableError(string(params.PaymentMode))
+	}
+	if params.Card == nil {
+		return nil, utils.BadRequestError("a card is required to pay when booking")
+	}
+
+	amount := payment.ChargeAmount(params.PaymentMode, total)
+	result, err := h.paymentProvider.Authorize(ctx, payment.AuthorizeRequest{
+		Amount:            amount,
+		Currency:          payment.Currency,
+		Card:              *params.Card,
+		ChallengeResponse: params.ChallengeResponse,
+	})
+	if err != nil {
+		metrics.PaymentAuthorizations.WithLabelValues("failed").Inc()
+		slog.ErrorContext(ctx, "payment authorization failed", "roomID", params.RoomID, logger.Err(err))
+		return nil, utils.PaymentProviderError()
+	}
+
+	metrics.PaymentAuthorizations.WithLabelValues(string(result.Status)).Inc()
+	switch result.Status {
+	case payment.StatusDeclined:
+		return nil, utils.PaymentDeclinedError(result.DeclineCode)
+	case payment.StatusActionRequired:
+		return nil, utils.PaymentActionRequiredError()
+	}
+
+	return &authorization{
+		reference: result.Reference,
+		amount:    amount,
+		cardLast4: params.Card.Last4(),
+	}, nil
+}
+
+// settlePayment records the payment of a stored booking and captures the
+// authorization. When either fails the booking is canceled again, a booking
+// is never left confirmed without its payment.
+func (h *Handler) settlePayment(
+	ctx context.Context,
+	booking *types.Booking,
+	total int64,
+	auth *authorization,
+) (*types.Payment, error) {
+	p := newPayment(booking, total)
+	if auth != nil {
+		p.Status = types.PaymentAuthorized
+		p.Provider = h.paymentProvider.Name()
+		p.ProviderRef = auth.reference
+		p.CardLast4 = auth.cardLast4
+	}
+
+	if _, err := h.paymentStore.InsertPayment(ctx, p); err != nil {
+		h.abortBooking(ctx, booking, auth)
+		return nil, storeError(err, "Error inserting payment")
+	}
+	if auth == nil {
+		return p, nil
+	}
+
+	if _, err := h.paymentProvider.Capture(ctx, auth.reference, auth.amount); err != nil {
+		slog.ErrorContext(ctx, "payment capture failed", "paymentID", p.ID.Hex(), logger.Err(err))
+		h.abortBooking(ctx, booking, auth)
+		p.Status = types.PaymentVoided
+		if err := h.paymentStore.UpdatePayment(ctx, p); err != nil {
+			slog.ErrorContext(ctx, "payment update failed", "paymentID", p.ID.Hex(), logger.Err(err))
+		}
+		return nil, utils.PaymentProviderError()
+	}
+
+	p.Status = types.PaymentCaptured
+	p.Captured = auth.amount
+	if err := h.paymentStore.UpdatePayment(ctx, p); err != nil {
+		// the money is taken, keep the booking and leave the record to be
+		// reconciled with the provider reference
+		slog.ErrorContext(ctx, "captured payment not recorded",
+			"paymentID", p.ID.Hex(),
+			"providerRef", p.ProviderRef,
+			logger.Err(err),
+		)
+	}
+
+	return p, nil
+}
+
+// newPayment is the pending payment of a booking, settled at the hotel unless
+// a card is charged.
+func newPayment(booking *types.Booking, total int64) *types.Payment {
+	return &types.Payment{
+		BookingID: booking.ID,
+		UserID:    booking.UserID,
+		Mode:      booking.PaymentMode,
+		Status:    types.PaymentPending,
+		Currency:  payment.Currency,
+		Total:     total,
+	}
+}
+
+// abortBooking releases the authorization and cancels a booking whose payment
+// could not be completed.
+func (h *Handler) abortBooking(ctx context.Context, booking *types.Booking, auth *authorization) {
+	h.voidAuthorization(ctx, auth)
+	if _, err := h.bookingStore.CancelUnpaidBooking(ctx, booking.ID.Hex()); err != nil {
+		slog.ErrorContext(ctx, "unpaid booking not canceled", "bookingID", booking.ID.Hex(), logger.Err(err))
+	}
+}
+
+func (h *Handler) voidAuthorization(ctx context.Context, auth *authorization) {
+	if auth == nil || h.paymentProvider == nil {
+		return
+	}
+	if _, err := h.paymentProvider.Void(ctx, auth.reference); err != nil {
+		slog.ErrorContext(ctx, "payment void failed", "providerRef", auth.reference, logger.Err(err))
+	}
+}
+
+// refundBookingPayments applies the cancellation policy to the payments of a
+// canceled booking. The cancel is committed at this point so failures are
+// logged, the admin refund endpoint settles what is left.
+func (h *Handler) refundBookingPayments(ctx context.Context, booking *types.Booking, byAdmin bool) {
+	payments, err := h.paymentStore.GetPaymentsByBookingID(ctx, booking.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "payments of canceled booking not loaded", "bookingID", booking.ID.Hex(), logger.Err(err))
+		return
+	}
+
+	now := time.Now()
+	for _, p := range payments {
+		var err error
+		switch p.Status {
+		case types.PaymentPending:
+			p.Status = types.PaymentVoided
+			err = h.paymentStore.UpdatePayment(ctx, p)
+		case types.PaymentAuthorized:
+			h.voidAuthorization(ctx, &authorization{reference: p.ProviderRef})
+			p.Status = types.PaymentVoided
+			err = h.paymentStore.UpdatePayment(ctx, p)
+		case types.PaymentCaptured, types.PaymentPartiallyRefunded:
+			if amount := payment.RefundAmount(p, booking.FromDate, now, byAdmin); amount > 0 {
+				err = h.refundPayment(ctx, p, amount)
+			}
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "payment of canceled booking not settled", "paymentID", p.ID.Hex(), logger.Err(err))
+		}
+	}
+}
+
+func (h *Handler) refundPayment(ctx context.Context, p *types.Payment, amount int64) error {
+	if h.paymentProvider == nil || p.ProviderRef == "" {
+		return utils.PaymentNotRefundableError("the payment was not made online")
+	}
+
+	if _, err := h.paymentProvider.Refund(ctx, p.ProviderRef, amount); err != nil {
+		slog.ErrorContext(ctx, "payment refund failed", "paymentID", p.ID.Hex(), logger.Err(err))
+		return utils.PaymentProviderError()
+	}
+	metrics.PaymentRefunds.Inc()
+
+	p.Refunded += amount
+	p.Status = types.PaymentPartiallyRefunded
+	if p.Refundable() == 0 {
+		p.Status = types.PaymentRefunded
+	}
+	if err := h.paymentStore.UpdatePayment(ctx, p); err != nil {
+		return storeError(err, "Error recording refund")
+	}
+
+	return nil
+}
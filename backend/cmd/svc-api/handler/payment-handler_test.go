@@ -0,0 +1,241 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPayments(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user          = fixtures.AddUser(*tdb.Store, "paying", "guest", false)
+		admin         = fixtures.AddUser(*tdb.Store, "paying", "admin", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "payment hotel", "Lisbon", 4, nil)
+		token, _      = tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		adminToken, _ = tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+		card          = func(number string) *types.Card {
+			return &types.Card{Number: number, ExpMonth: 12, ExpYear: time.Now().Year() + 2, CVC: "123"}
+		}
+	)
+
+	// book reserves two nights of a new 120.00 room starting in the given days
+	book := func(t *testing.T, inDays int, params types.BookingParam) (*http.Response, *types.Room) {
+		t.Helper()
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 120)
+		params.FromDate = time.Now().AddDate(0, 0, inDays)
+		params.TillDate = params.FromDate.AddDate(0, 0, 2)
+		params.CountPerson = 2
+		return send(t, app, "POST", "/v1/rooms/"+room.ID.Hex()+"/booking", token, params), room
+	}
+
+	decode := func(t *testing.T, resp *http.Response, data any) {
+		t.Helper()
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		if err := json.Unmarshal(b, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectProblem := func(t *testing.T, resp *http.Response, status int, code string) types.Problem {
+		t.Helper()
+		if resp.StatusCode != status {
+			t.Fatalf("expected %d status code but received %d", status, resp.StatusCode)
+		}
+		var problem types.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Code != code {
+			t.Fatalf("expected code %s but received %s", code, problem.Code)
+		}
+		return problem
+	}
+
+	paymentsOf := func(t *testing.T, booking *types.Booking) []types.Payment {
+		t.Helper()
+		resp := send(t, app, "GET", "/v1/bookings/"+booking.ID.Hex()+"/payments", token, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+		var payments []types.Payment
+		decode(t, resp, &payments)
+		if len(payments) != 1 {
+			t.Fatalf("expected 1 payment but received %d", len(payments))
+		}
+		return payments
+	}
+
+	created := func(t *testing.T, resp *http.Response) *types.Booking {
+		t.Helper()
+		expectStatus(t, resp, fiber.StatusCreated)
+		var booking types.Booking
+		decode(t, resp, &booking)
+		return &booking
+	}
+
+	t.Run("pay now captures the stay", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{PaymentMode: types.PayNow, Card: card("4242424242424242")})
+		booking := created(t, resp)
+
+		p := paymentsOf(t, booking)[0]
+		if p.Status != types.PaymentCaptured || p.Total != 24000 || p.Captured != 24000 || p.CardLast4 != "4242" {
+			t.Fatalf("unexpected payment %+v", p)
+		}
+	})
+
+	t.Run("a declined card books nothing", func(t *testing.T) {
+		resp, room := book(t, 5, types.BookingParam{PaymentMode: types.PayNow, Card: card(payment.FakeCardDeclined)})
+		problem := expectProblem(t, resp, fiber.StatusPaymentRequired, "payment_declined")
+		if problem.Errors["declineCode"] != payment.DeclineCardDeclined {
+			t.Errorf("expected the decline code, got %v", problem.Errors)
+		}
+
+		bookings, err := tdb.Store.Booking.GetBookingsByRoomID(t.Context(), &types.BookingParam{
+			RoomID:   room.ID.Hex(),
+			FromDate: time.Now(),
+			TillDate: time.Now().AddDate(0, 1, 0),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bookings) != 0 {
+			t.Fatalf("expected no booking but found %d", len(bookings))
+		}
+	})
+
+	t.Run("a card mode needs a card", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{PaymentMode: types.PayDeposit})
+		expectProblem(t, resp, fiber.StatusBadRequest, "validation_failed")
+	})
+
+	t.Run("3ds challenge is answered on retry", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 120)
+		from := time.Now().AddDate(0, 0, 5)
+		params := types.BookingParam{
+			FromDate:    from,
+			TillDate:    from.AddDate(0, 0, 2),
+			CountPerson: 2,
+			PaymentMode: types.PayNow,
+			Card:        card(payment.FakeCard3DS),
+		}
+		target := "/v1/rooms/" + room.ID.Hex() + "/booking"
+
+		expectProblem(t, send(t, app, "POST", target, token, params), fiber.StatusPaymentRequired, "payment_action_required")
+
+		params.ChallengeResponse = payment.FakeChallengeSuccess
+		created(t, send(t, app, "POST", target, token, params))
+	})
+
+	t.Run("late cancel keeps the deposit share", func(t *testing.T) {
+		resp, _ := book(t, 1, types.BookingParam{PaymentMode: types.PayNow, Card: card("4242424242424242")})
+		booking := created(t, resp)
+
+		resp = send(t, app, "PUT", "/v1/bookings/"+booking.ID.Hex()+"/cancel", token, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+
+		p := paymentsOf(t, booking)[0]
+		if p.Status != types.PaymentPartiallyRefunded || p.Refunded != 24000-4800 {
+			t.Fatalf("unexpected payment %+v", p)
+		}
+	})
+
+	t.Run("early cancel refunds the deposit", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{PaymentMode: types.PayDeposit, Card: card("4242424242424242")})
+		booking := created(t, resp)
+		if p := paymentsOf(t, booking)[0]; p.Captured != 4800 {
+			t.Fatalf("expected a 20%% deposit, got %+v", p)
+		}
+
+		send(t, app, "PUT", "/v1/bookings/"+booking.ID.Hex()+"/cancel", token, nil)
+
+		p := paymentsOf(t, booking)[0]
+		if p.Status != types.PaymentRefunded || p.Refunded != 4800 {
+			t.Fatalf("unexpected payment %+v", p)
+		}
+	})
+
+	t.Run("pay at hotel is voided on cancel", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{})
+		booking := created(t, resp)
+		if p := paymentsOf(t, booking)[0]; p.Status != types.PaymentPending || p.Mode != types.PayAtHotel {
+			t.Fatalf("unexpected payment %+v", p)
+		}
+
+		send(t, app, "PUT", "/v1/bookings/"+booking.ID.Hex()+"/cancel", token, nil)
+
+		if p := paymentsOf(t, booking)[0]; p.Status != types.PaymentVoided {
+			t.Fatalf("unexpected payment %+v", p)
+		}
+	})
+
+	t.Run("admin refunds a payment", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{PaymentMode: types.PayNow, Card: card("4242424242424242")})
+		booking := created(t, resp)
+		p := paymentsOf(t, booking)[0]
+		target := "/v1/admin/payments/" + p.ID.Hex() + "/refund"
+
+		resp = send(t, app, "POST", target, token, nil)
+		expectStatus(t, resp, fiber.StatusForbidden)
+
+		resp = send(t, app, "POST", target, adminToken, map[string]any{"amount": 1000, "reason": "broken shower"})
+		expectStatus(t, resp, fiber.StatusOK)
+		var refunded types.Payment
+		decode(t, resp, &refunded)
+		if refunded.Status != types.PaymentPartiallyRefunded || refunded.Refunded != 1000 {
+			t.Fatalf("unexpected payment %+v", refunded)
+		}
+
+		expectProblem(t, send(t, app, "POST", target, adminToken, map[string]any{"amount": 30000}), fiber.StatusConflict, "payment_not_refundable")
+
+		resp = send(t, app, "POST", target, adminToken, nil)
+		expectStatus(t, resp, fiber.StatusOK)
+		decode(t, resp, &refunded)
+		if refunded.Status != types.PaymentRefunded || refunded.Refunded != 24000 {
+			t.Fatalf("unexpected payment %+v", refunded)
+		}
+	})
+
+	t.Run("payments of another guest are hidden", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{})
+		booking := created(t, resp)
+
+		other := fixtures.AddUser(*tdb.Store, "other", "guest", false)
+		otherToken, _ := tokener.GenerateJWT(other.ID.Hex(), other.IsAdmin, config)
+		expectProblem(t, send(t, app, "GET", "/v1/bookings/"+booking.ID.Hex()+"/payments", otherToken, nil), fiber.StatusNotFound, "booking_not_found")
+	})
+
+	t.Run("the staff of the hotel reads the payments", func(t *testing.T) {
+		resp, _ := book(t, 5, types.BookingParam{})
+		booking := created(t, resp)
+		target := "/v1/bookings/" + booking.ID.Hex() + "/payments"
+
+		otherHotel := fixtures.AddHotel(*tdb.Store, "other payment hotel", "Porto", 4, nil)
+		staffToken := func(hotelID primitive.ObjectID) string {
+			staff := fixtures.AddUser(*tdb.Store, "payment", "staff", false)
+			params := &types.SetRolesParams{Roles: []types.Role{types.RoleStaff}, HotelIDs: []primitive.ObjectID{hotelID}}
+			if _, err := tdb.Store.User.SetRoles(t.Context(), staff.ID.Hex(), params, staff.Version); err != nil {
+				t.Fatal(err)
+			}
+			token, _ := tokener.GenerateJWT(staff.ID.Hex(), staff.IsAdmin, config)
+			return token
+		}
+
+		if resp := send(t, app, "GET", target, staffToken(hotel.ID), nil); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+		expectProblem(t, send(t, app, "GET", target, staffToken(otherHotel.ID), nil), fiber.StatusNotFound, "booking_not_found")
+	})
+}
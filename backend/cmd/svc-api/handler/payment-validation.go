@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const refundPaymentRequestKey = "refundPaymentReq"
+
+// refundPaymentRequest refunds everything left when the amount is zero.
+type refundPaymentRequest struct {
+	PaymentID string `validate:"required,id" json:"-"`
+	Amount    int64  `validate:"min=0" json:"amount"`
+	Reason    string `validate:"max=512" json:"reason"`
+}
+
+func RefundPaymentRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params refundPaymentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&params); err != nil {
+			return nil, refundPaymentRequestKey, utils.BadRequestError(err.Error())
+		}
+	}
+	params.PaymentID = c.Params("paymentID")
+
+	return &params, refundPaymentRequestKey, nil
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/report"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleGetDailyReport answers who arrives, leaves or stays at a hotel on a
+// day, with the guest names and what they still owe.
+func (h *Handler) HandleGetDailyReport(c *fiber.Ctx) error {
+	req, ok := c.Locals(dailyReportRequestKey).(*dailyReportRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", dailyReportRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	hotel, err := h.hotelStore.GetHotelByID(c.UserContext(), req.HotelID)
+	if err != nil {
+		return storeError(err, "Error building report")
+	}
+	if err := staffOf(c, hotel.ID); err != nil {
+		return err
+	}
+	bookings, err := h.bookingStore.GetHotelBookingsOn(c.UserContext(), req.HotelID, req.Kind, req.Date)
+	if err != nil {
+		return storeError(err, "Error building report")
+	}
+
+	lines, err := h.reportLines(c, bookings)
+	if err != nil {
+		return err
+	}
+
+	if req.Format == "csv" {
+		var out bytes.Buffer
+		if err := report.WriteCSV(&out, lines); err != nil {
+			return types.NewError(err, fiber.StatusInternalServerError, "Error writing report")
+		}
+		c.Attachment(fmt.Sprintf("%s-%s-%s.csv", req.Kind, hotel.ID.Hex(), req.Date.Format(time.DateOnly)))
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		return c.Status(fiber.StatusOK).Send(out.Bytes())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data: &types.DailyReport{
+			HotelID: hotel.ID,
+			Kind:    req.Kind,
+			Date:    req.Date.Format(time.DateOnly),
+			Lines:   lines,
+		},
+		Status: fiber.StatusOK,
+	})
+}
+
+// reportLines loads the other parts of the split stays, then the rooms, guests
+// and payments of the bookings in one query each.
+func (h *Handler) reportLines(c *fiber.Ctx, bookings []*types.Booking) ([]*types.ReportLine, error) {
+	if len(bookings) == 0 {
+		return []*types.ReportLine{}, nil
+	}
+
+	originalIDs := make([]primitive.ObjectID, 0, len(bookings))
+	userIDs := make([]primitive.ObjectID, 0, len(bookings))
+	for _, b := range bookings {
+		originalIDs = append(originalIDs, b.OriginalID())
+		userIDs = append(userIDs, b.UserID)
+	}
+
+	parts, err := h.bookingStore.GetStayParts(c.UserContext(), originalIDs)
+	if err != nil {
+		return nil, storeError(err, "Error building report")
+	}
+	roomIDs := make([]primitive.ObjectID, 0, len(bookings)+len(parts))
+	for _, b := range bookings {
+		roomIDs = append(roomIDs, b.RoomID)
+	}
+	for _, p := range parts {
+		roomIDs = append(roomIDs, p.RoomID)
+	}
+
+	// a room deleted since still prices the bookings it had
+	rooms, err := h.roomStore.GetRoomsByIDs(c.UserContext(), roomIDs)
+	if err != nil {
+		return nil, storeError(err, "Error building report")
+	}
+	users, err := h.userStore.GetUsersByIDs(c.UserContext(), userIDs)
+	if err != nil {
+		return nil, storeError(err, "Error building report")
+	}
+	// the payments of a split stay sit on its original booking
+	payments, err := h.paymentStore.GetPaymentsByBookingIDs(c.UserContext(), originalIDs)
+	if err != nil {
+		return nil, storeError(err, "Error building report")
+	}
+
+	roomsByID := make(map[primitive.ObjectID]*types.Room, len(rooms))
+	for _, room := range rooms {
+		roomsByID[room.ID] = room
+	}
+	usersByID := make(map[primitive.ObjectID]*types.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+	paymentsByBooking := make(map[primitive.ObjectID][]*types.Payment, len(bookings))
+	for _, p := range payments {
+		paymentsByBooking[p.BookingID] = append(paymentsByBooking[p.BookingID], p)
+	}
+
+	return report.Lines(bookings, parts, roomsByID, usersByID, paymentsByBooking), nil
+}
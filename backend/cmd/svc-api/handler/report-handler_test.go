@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDailyReports(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		guest         = fixtures.AddUser(*tdb.Store, "report", "guest", false)
+		staff         = fixtures.AddUser(*tdb.Store, "night", "auditor", true)
+		hotel         = fixtures.AddHotel(*tdb.Store, "report hotel", "Oslo", 4, nil)
+		arriving      = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 90)
+		leaving       = fixtures.AddRoom(*tdb.Store, types.SuiteRoomType, hotel.ID, 150)
+		day           = time.Now().UTC().AddDate(0, 0, 5).Truncate(24 * time.Hour)
+		staffToken, _ = tokener.GenerateJWT(staff.ID.Hex(), staff.IsAdmin, config)
+		guestToken, _ = tokener.GenerateJWT(guest.ID.Hex(), guest.IsAdmin, config)
+		arrival       = fixtures.AddBooking(*tdb.Store, guest.ID, arriving.ID.Hex(), day.Add(14*time.Hour), day.AddDate(0, 0, 2))
+		departure     = fixtures.AddBooking(*tdb.Store, guest.ID, leaving.ID.Hex(), day.AddDate(0, 0, -2), day.Add(11*time.Hour))
+	)
+
+	get := func(t *testing.T, target, token string) *http.Response {
+		t.Helper()
+		testReq := utils.TestRequest{Method: "GET", Target: target, Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	target := func(kind string) string {
+		return "/v1/admin/hotels/" + hotel.ID.Hex() + "/reports/" + kind + "?date=" + day.Format(time.DateOnly)
+	}
+
+	report := func(t *testing.T, kind string) *types.DailyReport {
+		t.Helper()
+		resp := get(t, target(kind), staffToken)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var daily types.DailyReport
+		if err := json.Unmarshal(b, &daily); err != nil {
+			t.Fatal(err)
+		}
+		return &daily
+	}
+
+	t.Run("each report lists its stays", func(t *testing.T) {
+		for kind, want := range map[string]*types.Booking{"arrivals": arrival, "departures": departure} {
+			daily := report(t, kind)
+			if len(daily.Lines) != 1 || daily.Lines[0].BookingID != want.ID {
+				t.Fatalf("unexpected %s report %+v", kind, daily.Lines)
+			}
+			if daily.Lines[0].GuestName != "report guest" {
+				t.Fatalf("expected the guest name, got %q", daily.Lines[0].GuestName)
+			}
+		}
+
+		inHouse := report(t, "in-house")
+		if len(inHouse.Lines) != 1 || inHouse.Lines[0].BookingID != arrival.ID {
+			t.Fatalf("unexpected in-house report %+v", inHouse.Lines)
+		}
+		if inHouse.Lines[0].RoomType != types.KingRoomType {
+			t.Fatalf("expected the room type, got %v", inHouse.Lines[0].RoomType)
+		}
+	})
+
+	t.Run("a guest who checked out early is not in house", func(t *testing.T) {
+		room := fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 100)
+		early := fixtures.AddBooking(*tdb.Store, guest.ID, room.ID.Hex(), day.AddDate(0, 0, -1), day.AddDate(0, 0, 3))
+		if _, err := tdb.db.Collection("bookings").UpdateByID(t.Context(), early.ID, bson.M{"$set": bson.M{"checkedOutAt": day.Add(9 * time.Hour)}}); err != nil {
+			t.Fatal(err)
+		}
+
+		inHouse := report(t, "in-house")
+		if len(inHouse.Lines) != 1 || inHouse.Lines[0].BookingID != arrival.ID {
+			t.Fatalf("unexpected in-house report %+v", inHouse.Lines)
+		}
+	})
+
+	t.Run("csv download", func(t *testing.T) {
+		resp := get(t, target("arrivals")+"&format=csv", staffToken)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusOK, resp.StatusCode)
+		}
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 2 || rows[1][0] != arrival.ID.Hex() {
+			t.Fatalf("unexpected csv %v", rows)
+		}
+	})
+
+	t.Run("unknown report and guests", func(t *testing.T) {
+		if resp := get(t, target("cleaning"), staffToken); resp.StatusCode != fiber.StatusBadRequest {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusBadRequest, resp.StatusCode)
+		}
+		if resp := get(t, target("arrivals"), guestToken); resp.StatusCode != fiber.StatusForbidden {
+			t.Fatalf("expected %d status code but received %d", fiber.StatusForbidden, resp.StatusCode)
+		}
+	})
+	t.Run("a deleted room still prices its bookings", func(t *testing.T) {
+		if err := tdb.Store.Room.DeleteRoom(t.Context(), leaving.ID.Hex()); err != nil {
+			t.Fatal(err)
+		}
+
+		daily := report(t, "departures")
+		if len(daily.Lines) != 1 || daily.Lines[0].RoomType != types.SuiteRoomType || daily.Lines[0].Balance == 0 {
+			t.Fatalf("expected the suite and its balance, got %+v", daily.Lines)
+		}
+	})
+}
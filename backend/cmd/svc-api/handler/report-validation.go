@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+const (
+	dailyReportRequestKey = "dailyReportReq"
+)
+
+// dailyReportRequest takes the day as YYYY-MM-DD, today in UTC when missing,
+// and answers json unless format is csv.
+type dailyReportRequest struct {
+	HotelID string           `validate:"required,id" query:"-"`
+	Kind    types.ReportKind `validate:"required,oneof=arrivals departures in-house" query:"-"`
+	Date    time.Time        `query:"date"`
+	Format  string           `validate:"omitempty,oneof=json csv" query:"format"`
+}
+
+func DailyReportRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	day, err := queryDay(c, "date")
+	if err != nil {
+		return nil, dailyReportRequestKey, err
+	}
+
+	return &dailyReportRequest{
+		HotelID: c.Params("hotelID"),
+		Kind:    types.ReportKind(c.Params("kind")),
+		Date:    day,
+		Format:  c.Query("format"),
+	}, dailyReportRequestKey, nil
+}
@@ -1,6 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 
@@ -16,27 +22,139 @@ func (h *Handler) HandleBookRoom(c *fiber.Ctx) error {
 
 	var params types.BookingParam
 	if err := c.BodyParser(&params); err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error request body")
+		return utils.BadRequestError("invalid request body")
 	}
 	params.RoomID = roomID
 	params.UserID = user.ID
 
-	insertedBooking, err := h.bookingStore.InsertBooking(c.Context(), &params)
+	return h.bookRoom(c, &params)
+}
+
+// HandleBookRoomType books the cheapest free room of a type, the front desk
+// can move the guest to another room later.
+func (h *Handler) HandleBookRoomType(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(bookRoomTypeRequestKey).(*bookingRoomTypeRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", bookRoomTypeRequestKey)
+		return utils.BadRequestError("")
+	}
+	req.params.UserID = user.ID
+
+	rooms, err := h.roomStore.GetFreeRooms(c.UserContext(), &types.FreeRoomsParam{
+		HotelID:  req.HotelID,
+		Type:     req.RoomType,
+		FromDate: req.FromDate,
+		TillDate: req.TillDate,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		return storeError(err, "Error inserting booking")
+	}
+	if len(rooms) == 0 {
+		return store.ConflictError(store.CodeRoomNotAvailable, fmt.Sprintf("no %s room is available for the stay", req.RoomType))
+	}
+
+	return h.bookOneOf(c, req.params, rooms)
+}
+
+// bookRoom charges the payment mode and stores the booking, for a direct
+// booking as for a confirmed hold.
+func (h *Handler) bookRoom(c *fiber.Ctx, params *types.BookingParam) error {
+	params.PaymentMode = bookingPaymentMode(params)
+
+	room, err := h.roomStore.GetRoomByID(c.UserContext(), params.RoomID)
+	if err != nil {
+		return storeError(err, "Error inserting booking")
+	}
+
+	return h.bookOneOf(c, params, []*types.Room{room})
+}
+
+// bookOneOf books the first candidate room still free when inserting. The card
+// is authorized once for the first candidate, only the candidates of the same
+// price are tried after it.
+func (h *Handler) bookOneOf(c *fiber.Ctx, params *types.BookingParam, candidates []*types.Room) error {
+	total := payment.StayAmount(candidates[0], params.FromDate, params.TillDate)
+
+	auth, err := h.authorizePayment(c.UserContext(), params, total)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error inserting booking")
+		return err
 	}
 
+	var insertedBooking *types.Booking
+	for _, room := range candidates {
+		if payment.StayAmount(room, params.FromDate, params.TillDate) != total {
+			break
+		}
+		params.RoomID = room.ID.Hex()
+		insertedBooking, err = h.bookingStore.InsertBooking(c.UserContext(), params)
+		// another guest took the room since the candidates were listed
+		if !errors.Is(err, store.ErrConflict) {
+			break
+		}
+	}
+	if err != nil {
+		h.voidAuthorization(c.UserContext(), auth)
+		return storeError(err, "Error inserting booking")
+	}
+
+	if _, err := h.settlePayment(c.UserContext(), insertedBooking, total, auth); err != nil {
+		return err
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditBookingCreated,
+		TargetType: types.AuditTargetBooking,
+		TargetID:   insertedBooking.ID,
+	}, nil, insertedBooking)
+
+	setETag(c, insertedBooking.Version)
 	return c.Status(fiber.StatusCreated).JSON(types.ResGeneric{
 		Data:   insertedBooking,
 		Status: fiber.StatusCreated,
 	})
 }
 
+// HandleGetAvailability answers the free inventory per room type of a stay,
+// the rooms themselves are assigned when booking.
+func (h *Handler) HandleGetAvailability(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return utils.UnauthorizedError()
+	}
+
+	req, ok := c.Locals(getAvailabilityRequestKey).(*availabilityRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getAvailabilityRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	availability, err := h.roomStore.GetTypeAvailability(c.UserContext(), &types.FreeRoomsParam{
+		HotelID:  req.HotelID,
+		FromDate: req.FromDate,
+		TillDate: req.TillDate,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		return storeError(err, "Error getting availability")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   availability,
+		Status: fiber.StatusOK,
+	})
+}
+
 func (h *Handler) HandleGetRooms(c *fiber.Ctx) error {
 	qParams := c.Locals(getRoomsRequstKey).(*types.GetRoomsRequest)
-	rooms, total, nextLastId, err := h.roomStore.GetRooms(c.Context(), qParams)
+	rooms, total, nextLastId, err := h.roomStore.GetRooms(c.UserContext(), qParams)
 	if err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting rooms")
+		return storeError(err, "Error getting rooms")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResCursorPaginate]{
@@ -51,3 +169,71 @@ func (h *Handler) HandleGetRooms(c *fiber.Ctx) error {
 		},
 	})
 }
+
+func (h *Handler) HandleDeleteRoom(c *fiber.Ctx) error {
+	id := c.Params("roomID")
+
+	before, err := h.roomStore.GetRoomByID(c.UserContext(), id)
+	if err != nil {
+		return storeError(err, "Error deleting room")
+	}
+
+	if err := h.roomStore.DeleteRoom(c.UserContext(), id); err != nil {
+		return storeError(err, "Error deleting room")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditRoomDeleted,
+		TargetType: types.AuditTargetRoom,
+		TargetID:   before.ID,
+	}, before, nil)
+
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Msg:    fmt.Sprintf("Room %s deleted", id),
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleGetDeletedRooms(c *fiber.Ctx) error {
+	query, ok := c.Locals(getDeletedRequestKey).(*types.QueryNumericPaginate)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getDeletedRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	rooms, total, err := h.roomStore.GetDeletedRooms(c.UserContext(), query)
+	if err != nil {
+		return storeError(err, "Error getting deleted rooms")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
+		ResGeneric: types.ResGeneric{
+			Data:   rooms,
+			Status: fiber.StatusOK,
+		},
+		Pagination: types.ResNumericPaginate{
+			Count: total,
+			Page:  query.Page,
+			Limit: int(query.Limit),
+		},
+	})
+}
+
+func (h *Handler) HandleRestoreRoom(c *fiber.Ctx) error {
+	room, err := h.roomStore.RestoreRoom(c.UserContext(), c.Params("roomID"))
+	if err != nil {
+		return storeError(err, "Error restoring room")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditRoomRestored,
+		TargetType: types.AuditTargetRoom,
+		TargetID:   room.ID,
+	}, nil, room)
+
+	setETag(c, room.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   room,
+		Status: fiber.StatusOK,
+	})
+}
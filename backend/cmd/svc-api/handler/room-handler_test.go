@@ -1,15 +1,19 @@
 package handler_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	mid "github.com/tnguven/hotel-reservation-app/internals/middleware"
 	"github.com/tnguven/hotel-reservation-app/internals/tokener"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestHandleGetRooms(t *testing.T) {
@@ -119,6 +123,164 @@ func TestHandleGetRooms(t *testing.T) {
 	})
 }
 
+func TestBookRoomIdempotency(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user     = fixtures.AddUser(*tdb.Store, "idempotent", "booking", false)
+		hotel    = fixtures.AddHotel(*tdb.Store, "idempotent hotel", "a", 4, nil)
+		room     = fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 10.99)
+		token, _ = tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		target   = "/v1/rooms/" + room.ID.Hex() + "/booking"
+		from     = time.Now().AddDate(0, 0, 1)
+	)
+
+	book := func(t *testing.T, key string, params types.BookingParam) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(params)
+		testReq := utils.TestRequest{
+			Method:  "POST",
+			Target:  target,
+			Token:   token,
+			Payload: bytes.NewReader(b),
+		}
+		req := testReq.NewRequestWithHeader()
+		req.Header.Set(mid.IdempotencyKeyHeader, key)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("a retried booking is created once", func(t *testing.T) {
+		params := types.BookingParam{FromDate: from, TillDate: from.AddDate(0, 0, 2), CountPerson: 2}
+
+		first := book(t, "retry-1", params)
+		second := book(t, "retry-1", params)
+		if first.StatusCode != http.StatusCreated || second.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 twice but received %d and %d", first.StatusCode, second.StatusCode)
+		}
+		if second.Header.Get(mid.IdempotentReplayedHeader) != "true" {
+			t.Fatalf("expected the second response to be replayed")
+		}
+
+		bookings, err := tdb.Store.Booking.GetBookingsAsUser(t.Context(), user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bookings) != 1 {
+			t.Fatalf("expected 1 booking but found %d", len(bookings))
+		}
+	})
+
+	t.Run("the same key with other dates is refused", func(t *testing.T) {
+		params := types.BookingParam{FromDate: from.AddDate(0, 1, 0), TillDate: from.AddDate(0, 1, 2), CountPerson: 2}
+
+		resp := book(t, "retry-1", params)
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422 status code but received %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestBookRoomType(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user     = fixtures.AddUser(*tdb.Store, "typed", "booking", false)
+		hotel    = fixtures.AddHotel(*tdb.Store, "typed hotel", "a", 4, nil)
+		cheap    = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 80)
+		dear     = fixtures.AddRoom(*tdb.Store, types.KingRoomType, hotel.ID, 120)
+		_        = fixtures.AddRoom(*tdb.Store, types.FamilyRoomType, hotel.ID, 60)
+		token, _ = tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		from     = time.Now().AddDate(0, 0, 3)
+		till     = from.AddDate(0, 0, 2)
+		params   = types.BookingParam{RoomType: types.KingRoomType, FromDate: from, TillDate: till, CountPerson: 2}
+	)
+
+	send := func(t *testing.T, method, target string, payload any) *http.Response {
+		t.Helper()
+		b, _ := json.Marshal(payload)
+		testReq := utils.TestRequest{Method: method, Target: target, Token: token, Payload: bytes.NewReader(b)}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	book := func(t *testing.T) *http.Response {
+		t.Helper()
+		return send(t, "POST", "/v1/hotels/"+hotel.ID.Hex()+"/booking", params)
+	}
+
+	bookedRoom := func(t *testing.T, resp *http.Response) primitive.ObjectID {
+		t.Helper()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 status code but received %d", resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var booking types.Booking
+		if err := json.Unmarshal(b, &booking); err != nil {
+			t.Fatal(err)
+		}
+		return booking.RoomID
+	}
+
+	availability := func(t *testing.T) map[types.RoomType]types.TypeAvailability {
+		t.Helper()
+		target := fmt.Sprintf("/v1/hotels/%s/availability?fromDate=%s&tillDate=%s",
+			hotel.ID.Hex(), from.UTC().Format(time.RFC3339), till.UTC().Format(time.RFC3339))
+		resp := send(t, "GET", target, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+		var response types.ResGeneric
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(response.Data)
+		var list []types.TypeAvailability
+		if err := json.Unmarshal(b, &list); err != nil {
+			t.Fatal(err)
+		}
+		byType := map[types.RoomType]types.TypeAvailability{}
+		for _, a := range list {
+			byType[a.Type] = a
+		}
+		return byType
+	}
+
+	if king := availability(t)[types.KingRoomType]; king.Total != 2 || king.Available != 2 || king.FromRate != 80 {
+		t.Fatalf("unexpected king availability %+v", king)
+	}
+
+	if room := bookedRoom(t, book(t)); room != cheap.ID {
+		t.Fatalf("expected the cheapest room %s but got %s", cheap.ID.Hex(), room.Hex())
+	}
+	if king := availability(t)[types.KingRoomType]; king.Available != 1 || king.FromRate != 120 {
+		t.Fatalf("unexpected king availability %+v", king)
+	}
+
+	if room := bookedRoom(t, book(t)); room != dear.ID {
+		t.Fatalf("expected the room %s but got %s", dear.ID.Hex(), room.Hex())
+	}
+
+	if resp := book(t); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 status code but received %d", resp.StatusCode)
+	}
+	if family := availability(t)[types.FamilyRoomType]; family.Available != 1 {
+		t.Fatalf("unexpected family availability %+v", family)
+	}
+}
+
 // func TestHandleGetRooms(t *testing.T) {
 // 	config := NewConfig()
 // 	tdb, app := Setup(mDatabase, config)
@@ -1,19 +1,20 @@
 package handler
 
 import (
-	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 )
 
 const (
-	getRoomsRequstKey = "getRoomsReq"
+	getRoomsRequstKey         = "getRoomsReq"
+	getAvailabilityRequestKey = "getAvailabilityReq"
 )
 
 func GetRoomsSchema(c *fiber.Ctx) (interface{}, string, error) {
-	qStatus := strings.Split(c.Query("status", "occupied,available,booked"), ",")
+	qStatus := strings.Split(c.Query("status", "occupied,available,booked,blocked"), ",")
 	status := make([]types.RoomStatus, len(qStatus))
 
 	for i, s := range qStatus {
@@ -23,9 +24,6 @@ func GetRoomsSchema(c *fiber.Ctx) (interface{}, string, error) {
 	lastID := c.Query("lastID", "")
 	limit := c.QueryInt("limit", 10)
 
-	fmt.Println("---------", lastID)
-	fmt.Println("LIMIT", limit)
-
 	return &types.GetRoomsRequest{
 		Status: status,
 		QueryCursorPaginate: types.NewMongoQueryCursorPaginate(
@@ -34,3 +32,27 @@ func GetRoomsSchema(c *fiber.Ctx) (interface{}, string, error) {
 		),
 	}, getRoomsRequstKey, nil
 }
+
+// availabilityRequest asks for the inventory per room type of a stay.
+type availabilityRequest struct {
+	HotelID  string    `validate:"required,id" query:"-"`
+	FromDate time.Time `validate:"required" query:"fromDate"`
+	TillDate time.Time `validate:"required,gtfield=FromDate" query:"tillDate"`
+}
+
+func GetAvailabilitySchema(c *fiber.Ctx) (interface{}, string, error) {
+	from, err := queryTime(c, "fromDate")
+	if err != nil {
+		return nil, getAvailabilityRequestKey, err
+	}
+	till, err := queryTime(c, "tillDate")
+	if err != nil {
+		return nil, getAvailabilityRequestKey, err
+	}
+
+	return &availabilityRequest{
+		HotelID:  c.Params("hotelID"),
+		FromDate: from,
+		TillDate: till,
+	}, getAvailabilityRequestKey, nil
+}
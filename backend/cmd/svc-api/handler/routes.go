@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/internals/configure"
 	mid "github.com/tnguven/hotel-reservation-app/internals/middleware"
+	"github.com/tnguven/hotel-reservation-app/internals/ratelimit"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
 )
 
 type RouteConfigs interface {
@@ -12,6 +16,36 @@ type RouteConfigs interface {
 	configure.Common
 }
 
+var (
+	defaultBudget = ratelimit.Policy{
+		Anonymous:     ratelimit.Limit{Requests: 60, Window: time.Minute},
+		Authenticated: ratelimit.Limit{Requests: 300, Window: time.Minute},
+		Admin:         ratelimit.Limit{Requests: 1000, Window: time.Minute},
+	}
+	// credential stuffing target, keep it tight for everyone
+	authBudget = ratelimit.Policy{
+		Anonymous:     ratelimit.Limit{Requests: 10, Window: time.Minute},
+		Authenticated: ratelimit.Limit{Requests: 10, Window: time.Minute},
+		Admin:         ratelimit.Limit{Requests: 10, Window: time.Minute},
+	}
+	// the rooms listing runs a $lookup over every booking
+	roomsBudget = ratelimit.Policy{
+		Anonymous:     ratelimit.Limit{Requests: 20, Window: time.Minute},
+		Authenticated: ratelimit.Limit{Requests: 60, Window: time.Minute},
+		Admin:         ratelimit.Limit{Requests: 300, Window: time.Minute},
+	}
+	bookingBudget = ratelimit.Policy{
+		Anonymous:     ratelimit.Limit{Requests: 5, Window: time.Minute},
+		Authenticated: ratelimit.Limit{Requests: 20, Window: time.Minute},
+		Admin:         ratelimit.Limit{Requests: 100, Window: time.Minute},
+	}
+	// codes can be guessed, as tight as the credentials
+	lookupBudget = authBudget
+)
+
+// long enough to cover the retries of a client coming back online
+const idempotencyTTL = 24 * time.Hour
+
 func (h *Handler) Register(
 	app *fiber.App,
 	configs RouteConfigs,
@@ -19,51 +53,235 @@ func (h *Handler) Register(
 ) {
 	root := app.Group("/")
 	root.Get("/health", h.HandleHealthCheck())
+	root.Get("/livez", h.HandleHealthCheck())
+	root.Get("/readyz", h.HandleReadiness())
+	root.Get("/openapi.json", h.HandleOpenAPI(app))
+	if configs.GoEnv() != configure.EnvProduction {
+		root.Get("/docs", h.HandleDocs)
+	}
 
 	v1 := app.Group("/v1")
 	withAutMid := mid.JWTAuthentication(h.userStore, configs)
 
 	{
-		auth := v1.Group("/auth")
+		auth := v1.Group("/auth", h.rateLimit("auth", authBudget))
 		auth.Post("/", mid.WithValidation(validator, AuthRequestSchema), h.HandleAuthenticate(configs))
-		auth.Post("/signin", mid.WithValidation(validator, InsertUserRequestSchema), h.HandleSignIn)
+		auth.Post("/signin", h.idempotent(), mid.WithValidation(validator, InsertUserRequestSchema), h.HandleSignIn)
 	}
 
 	{
 		usersPrivate := v1.Group("/users")
-		usersPrivate.Get("/", withAutMid, mid.WithValidation(validator, GetUsersRequestSchema), h.HandleGetUsers)
-		usersPrivate.Post("/", mid.WithValidation(validator, InsertUserRequestSchema), h.HandlePostUser)
+		usersLimit := h.rateLimit("users", defaultBudget)
+		usersPrivate.Get("/", withAutMid, usersLimit, mid.WithValidation(validator, GetUsersRequestSchema), h.HandleGetUsers)
+		usersPrivate.Post("/", usersLimit, h.idempotent(), mid.WithValidation(validator, InsertUserRequestSchema), h.HandlePostUser)
 
 		userPrivate := usersPrivate.Group("/:id")
-		userPrivate.Get("/", h.HandleGetUser)
-		userPrivate.Put("/", mid.WithValidation(validator, UpdateUserRequestSchema), h.HandlePutUser)
-		userPrivate.Delete("/", h.HandleDeleteUser)
+		userPrivate.Get("/", usersLimit, h.HandleGetUser)
+		userPrivate.Put("/", withAutMid, usersLimit, mid.WithSelfOrAdmin("id"), h.idempotent(), mid.WithValidation(validator, UpdateUserRequestSchema), h.HandlePutUser)
+		userPrivate.Delete("/", withAutMid, usersLimit, mid.WithSelfOrAdmin("id"), h.idempotent(), h.HandleDeleteUser)
+	}
+
+	{
+		me := v1.Group("/me", withAutMid, h.rateLimit("me", defaultBudget))
+		me.Get("/export", h.HandleExportMe)
+		me.Post("/erasure", h.idempotent(), mid.WithValidation(validator, EraseMeRequestSchema), h.HandleEraseMe)
+
+		adminUsers := v1.Group("/admin/users", withAutMid, h.rateLimit("admin-users", defaultBudget), mid.WithAdminAuth)
+		adminUsers.Post("/:id/erasure", h.idempotent(), h.HandleEraseUser)
+		adminUsers.Put("/:id/roles", h.idempotent(), mid.WithValidation(validator, SetRolesRequestSchema), h.HandlePutUserRoles)
 	}
 
 	{
-		hotelsPrivate := v1.Group("/hotels", withAutMid)
+		hotelsPrivate := v1.Group("/hotels", withAutMid, h.rateLimit("hotels", defaultBudget))
 		hotelsPrivate.Get("/", mid.WithValidation(validator, GetHotelsQueryRequestSchema), h.HandleGetHotels)
 
 		hotelPrivate := hotelsPrivate.Group("/:hotelID", mid.WithValidation(validator, GetHotelRequestSchema))
 		hotelPrivate.Get("/", h.HandleGetHotel)
 		hotelPrivate.Get("/rooms", h.HandleGetRoomsByHotelID)
+		hotelPrivate.Get("/availability", mid.WithValidation(validator, GetAvailabilitySchema), h.HandleGetAvailability)
+		hotelPrivate.Post(
+			"/booking",
+			h.rateLimit("booking", bookingBudget),
+			h.idempotent(),
+			mid.WithValidation(validator, BookingRoomTypeRequestSchema),
+			h.HandleBookRoomType,
+		)
+		hotelPrivate.Put(
+			"/",
+			mid.WithAdminAuth,
+			h.idempotent(),
+			mid.WithValidation(validator, UpdateHotelRequestSchema),
+			h.HandlePutHotel,
+		)
+		hotelPrivate.Delete("/", mid.WithAdminAuth, h.idempotent(), h.HandleDeleteHotel)
 	}
 
 	{
 		roomsPrivate := v1.Group("/rooms", withAutMid)
-		roomsPrivate.Get("/", mid.WithValidation(validator, GetRoomsSchema), h.HandleGetRooms)
+		roomsPrivate.Get("/", h.rateLimit("rooms", roomsBudget), mid.WithValidation(validator, GetRoomsSchema), h.HandleGetRooms)
 
 		bookPrivate := roomsPrivate.Group("/:roomID") // TODO: add roomID validation
-		bookPrivate.Post("/booking", mid.WithValidation(validator, BookingRoomRequestSchema), h.HandleBookRoom)
+		bookPrivate.Post(
+			"/booking",
+			h.rateLimit("booking", bookingBudget),
+			h.idempotent(),
+			mid.WithValidation(validator, BookingRoomRequestSchema),
+			h.HandleBookRoom,
+		)
+		bookPrivate.Post(
+			"/holds",
+			h.rateLimit("booking", bookingBudget),
+			h.idempotent(),
+			mid.WithValidation(validator, HoldRoomRequestSchema),
+			h.HandlePostHold,
+		)
+		bookPrivate.Delete("/", mid.WithAdminAuth, h.idempotent(), h.HandleDeleteRoom)
+
+		holdsPrivate := v1.Group("/holds/:holdID", withAutMid, h.rateLimit("holds", defaultBudget))
+		holdsPrivate.Get("/", h.HandleGetHold)
+		holdsPrivate.Delete("/", h.idempotent(), h.HandleReleaseHold)
+		holdsPrivate.Post(
+			"/confirm",
+			h.rateLimit("booking", bookingBudget),
+			h.idempotent(),
+			mid.WithValidation(validator, ConfirmHoldRequestSchema),
+			h.HandleConfirmHold,
+		)
+		groupsPrivate := v1.Group("/groups", withAutMid, h.rateLimit("groups", defaultBudget))
+		groupsPrivate.Post(
+			"/",
+			h.rateLimit("booking", bookingBudget),
+			h.idempotent(),
+			mid.WithValidation(validator, GroupBookingRequestSchema),
+			h.HandlePostGroupBooking,
+		)
+		groupsPrivate.Get("/:groupID", h.HandleGetGroupBooking)
+		groupsPrivate.Put(
+			"/:groupID/bookings/:bookingID/cancel",
+			h.idempotent(),
+			mid.WithValidation(validator, CancelGroupRoomRequestSchema),
+			h.HandleCancelGroupRoom,
+		)
+
 		// TODO cancel a booking
-		adminBookings := v1.Group("/admin/bookings", withAutMid)
-		adminBookings.Get("/", mid.WithAdminAuth, h.HandleGetBookingsAsAdmin)
+		adminBookings := v1.Group("/admin/bookings", withAutMid, h.rateLimit("admin-bookings", defaultBudget))
+		adminBookings.Get("/", mid.WithAdminAuth, mid.WithValidation(validator, GetBookingsRequestSchema), h.HandleGetBookingsAsAdmin)
+		adminBookings.Post(
+			"/:bookingID/move",
+			mid.WithRole(types.RoleStaff),
+			h.idempotent(),
+			mid.WithValidation(validator, MoveBookingRequestSchema),
+			h.HandleMoveBooking,
+		)
+		adminBookings.Post("/:bookingID/check-in", mid.WithRole(types.RoleStaff), h.idempotent(), h.HandleCheckIn)
+		adminBookings.Post("/:bookingID/check-out", mid.WithRole(types.RoleStaff), h.idempotent(), h.HandleCheckOut)
+
+		v1.Post(
+			"/booking-lookup",
+			h.rateLimit("booking-lookup", lookupBudget),
+			mid.WithValidation(validator, BookingLookupRequestSchema),
+			h.HandleLookupBooking,
+		)
+
+		bookingsPrivate := v1.Group("/bookings", withAutMid, h.rateLimit("bookings", defaultBudget))
+		bookingsPrivate.Get("/", mid.WithValidation(validator, GetBookingsRequestSchema), h.HandleGetBookingsAsUser)
+		bookingsPrivate.Get("/:bookingID", mid.WithValidation(validator, GetBookingRequestSchema), h.HandleGetBooking)
+		bookingsPrivate.Put(
+			"/:bookingID/cancel",
+			h.idempotent(),
+			mid.WithValidation(validator, CancelBookingRequestSchema),
+			h.HandleCancelBooking,
+		)
+		bookingsPrivate.Get("/:bookingID/payments", h.HandleGetBookingPayments)
 
-		bookingsPrivate := v1.Group("/bookings", withAutMid)
-		bookingsPrivate.Get("/", h.HandleGetBookingsAsUser)
-		bookingsPrivate.Get("/:bookingID", h.HandleGetBooking)           // TODO: validate id
-		bookingsPrivate.Put("/:bookingID/cancel", h.HandleCancelBooking) // TODO: validate id
+		adminPayments := v1.Group("/admin/payments", withAutMid, h.rateLimit("admin-payments", defaultBudget), mid.WithAdminAuth)
+		adminPayments.Post(
+			"/:paymentID/refund",
+			h.idempotent(),
+			mid.WithValidation(validator, RefundPaymentRequestSchema),
+			h.HandleRefundPayment,
+		)
+	}
+
+	{
+		adminBlocks := v1.Group("/admin/rooms/:roomID/blocks", withAutMid, h.rateLimit("admin-blocks", defaultBudget), mid.WithAdminAuth)
+		adminBlocks.Get("/", h.HandleGetRoomBlocks)
+		adminBlocks.Post("/", h.idempotent(), mid.WithValidation(validator, RoomBlockRequestSchema), h.HandlePostRoomBlock)
+		adminBlocks.Delete("/:blockID", h.idempotent(), h.HandleDeleteRoomBlock)
+	}
+
+	{
+		// no group middleware, it would run for every /admin route registered
+		// after this one
+		housekeeping := v1.Group("/admin")
+		housekeepingLimit := h.rateLimit("admin-housekeeping", defaultBudget)
+		staff := mid.WithRole(types.RoleStaff)
+		housekeeping.Get(
+			"/hotels/:hotelID/housekeeping",
+			withAutMid,
+			housekeepingLimit,
+			staff,
+			mid.WithValidation(validator, HousekeepingTasksRequestSchema),
+			h.HandleGetHousekeepingTasks,
+		)
+		housekeeping.Put(
+			"/rooms/:roomID/housekeeping",
+			withAutMid,
+			housekeepingLimit,
+			staff,
+			h.idempotent(),
+			mid.WithValidation(validator, HousekeepingRequestSchema),
+			h.HandlePutHousekeeping,
+		)
+	}
+
+	{
+		reports := v1.Group("/admin/hotels/:hotelID/reports", withAutMid, h.rateLimit("admin-reports", defaultBudget), mid.WithRole(types.RoleStaff))
+		reports.Get("/:kind", mid.WithValidation(validator, DailyReportRequestSchema), h.HandleGetDailyReport)
+	}
+
+	{
+		analytics := v1.Group("/analytics", withAutMid, h.rateLimit("analytics", defaultBudget), mid.WithRole(types.RoleRevenueManager))
+		analytics.Get("/hotels/:hotelID", mid.WithValidation(validator, AnalyticsRequestSchema), h.HandleGetAnalytics)
+	}
+
+	{
+		adminAudit := v1.Group("/admin/audit", withAutMid, h.rateLimit("admin-audit", defaultBudget))
+		adminAudit.Get("/", mid.WithAdminAuth, mid.WithValidation(validator, GetAuditRequestSchema), h.HandleGetAuditEntries)
+	}
+
+	{
+		adminDeleted := v1.Group("/admin/deleted", withAutMid, h.rateLimit("admin-deleted", defaultBudget), mid.WithAdminAuth)
+		listDeleted := mid.WithValidation(validator, GetDeletedRequestSchema)
+		adminDeleted.Get("/users", listDeleted, h.HandleGetDeletedUsers)
+		adminDeleted.Post("/users/:id/restore", h.idempotent(), h.HandleRestoreUser)
+		adminDeleted.Get("/hotels", listDeleted, h.HandleGetDeletedHotels)
+		adminDeleted.Post("/hotels/:hotelID/restore", h.idempotent(), h.HandleRestoreHotel)
+		adminDeleted.Get("/rooms", listDeleted, h.HandleGetDeletedRooms)
+		adminDeleted.Post("/rooms/:roomID/restore", h.idempotent(), h.HandleRestoreRoom)
 	}
 
 	app.All("*", withAutMid, h.HandleNotFound)
 }
+
+// rateLimit is a no-op until a limiter is attached with WithRateLimiter.
+func (h *Handler) rateLimit(route string, policy ratelimit.Policy) fiber.Handler {
+	if h.rateLimiter == nil {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return mid.WithRateLimit(h.rateLimiter, route, policy)
+}
+
+// idempotent is a no-op when no idempotency store is configured.
+func (h *Handler) idempotent() fiber.Handler {
+	if h.idempotencyStore == nil {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return mid.WithIdempotency(h.idempotencyStore, idempotencyTTL)
+}
@@ -1,13 +1,20 @@
 package handler_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/cmd/svc-api/handler"
+	migrations "github.com/tnguven/hotel-reservation-app/db"
+	"github.com/tnguven/hotel-reservation-app/internals/health"
 	mid "github.com/tnguven/hotel-reservation-app/internals/middleware"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
 	"github.com/tnguven/hotel-reservation-app/internals/server"
 	"github.com/tnguven/hotel-reservation-app/internals/store"
@@ -25,7 +32,7 @@ type CollectionType string
 
 func (tdb *TestDb) TearDown(t *testing.T) {
 	ctx := context.Background()
-	errChan := make(chan error, 4)
+	errChan := make(chan error, 7)
 	events := []func(){
 		func() {
 			if err := tdb.Store.User.Drop(ctx); err != nil {
@@ -47,6 +54,21 @@ func (tdb *TestDb) TearDown(t *testing.T) {
 				errChan <- err
 			}
 		},
+		func() {
+			if err := tdb.Store.Payment.Drop(ctx); err != nil {
+				errChan <- err
+			}
+		},
+		func() {
+			if err := tdb.Store.Group.Drop(ctx); err != nil {
+				errChan <- err
+			}
+		},
+		func() {
+			if err := tdb.Store.Block.Drop(ctx); err != nil {
+				errChan <- err
+			}
+		},
 	}
 
 	for event := range utils.Parallel(events) {
@@ -74,6 +96,13 @@ func Setup(db *repo.MongoDatabase, configs *TestConfigs) (*TestDb, *fiber.App) {
 			Hotel:   hotelStore,
 			Room:    roomStore,
 			Booking: bookingStore,
+			Payment: store.NewMongoPaymentStore(db),
+			Hold:    store.NewMongoHoldStore(db, roomStore),
+			Group:   store.NewMongoGroupBookingStore(db),
+			Block:   store.NewMongoBlockStore(db, roomStore),
+
+			Idempotency: store.NewMongoIdempotencyStore(db),
+			Audit:       store.NewMongoAuditStore(db),
 		},
 		db: db.GetDb(),
 	}
@@ -81,7 +110,15 @@ func Setup(db *repo.MongoDatabase, configs *TestConfigs) (*TestDb, *fiber.App) {
 	app := server.NewServer(configs)
 
 	validator, _ := mid.NewValidator()
-	handlers := handler.NewHandler(tdb.Store)
+	readiness := health.NewReadiness(2*time.Second).
+		WithCheck("mongo", db.Ping).
+		WithCheck("replicaSet", db.CheckReplicaSet).
+		WithCheck("migrations", func(ctx context.Context) error {
+			return migrations.CheckMigrations(ctx, db.GetDb())
+		})
+	handlers := handler.NewHandler(tdb.Store).
+		WithReadiness(readiness).
+		WithPaymentProvider(payment.NewFakeProvider())
 	handlers.Register(app, configs, validator)
 
 	return tdb, app
@@ -95,3 +132,22 @@ func GetAutheVnticatedUser(c *fiber.Ctx) (*types.User, error) {
 
 	return user, nil
 }
+
+// send makes a request to app with payload as its json body.
+func send(t *testing.T, app *fiber.App, method, target, token string, payload any) *http.Response {
+	t.Helper()
+	b, _ := json.Marshal(payload)
+	testReq := utils.TestRequest{Method: method, Target: target, Token: token, Payload: bytes.NewReader(b)}
+	resp, err := app.Test(testReq.NewRequestWithHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func expectStatus(t *testing.T, resp *http.Response, status int) {
+	t.Helper()
+	if resp.StatusCode != status {
+		t.Fatalf("expected %d status code but received %d", status, resp.StatusCode)
+	}
+}
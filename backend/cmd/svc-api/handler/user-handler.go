@@ -1,29 +1,23 @@
 package handler
 
 import (
-	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
 
-	"github.com/gofiber/fiber/v2/log"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func (h *Handler) HandleGetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
-	user, err := h.userStore.GetByID(c.Context(), id)
+	user, err := h.userStore.GetByID(c.UserContext(), id)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return utils.NotFoundError()
-		}
-		log.Errorf("HandleGetUser: error getting user: %v", err)
-		return types.NewError(err, fiber.StatusInternalServerError, "Error getting user")
+		return storeError(err, "Error getting user")
 	}
 
+	setETag(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(types.ResGeneric{
 		Data:   user,
 		Status: fiber.StatusOK,
@@ -33,17 +27,13 @@ func (h *Handler) HandleGetUser(c *fiber.Ctx) error {
 func (h *Handler) HandleGetUsers(c *fiber.Ctx) error {
 	query, ok := c.Locals(getUsersRequestKey).(*types.QueryNumericPaginate)
 	if !ok {
-		log.Error("getUsers missing locals")
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getUsersRequestKey)
 		return utils.BadRequestError("")
 	}
 
-	users, total, err := h.userStore.GetUsers(c.Context(), query)
+	users, total, err := h.userStore.GetUsers(c.UserContext(), query)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return utils.NotFoundError()
-		}
-		log.Errorf("HandleGetUsers: error getting users: %v", err)
-		return types.NewError(err, fiber.StatusInternalServerError, "error getting users")
+		return storeError(err, "error getting users")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
@@ -70,18 +60,18 @@ func (h *Handler) HandlePostUser(c *fiber.Ctx) error {
 		return types.NewError(err, fiber.StatusInternalServerError, "")
 	}
 
-	insertedUser, err := h.userStore.InsertUser(c.Context(), user)
+	insertedUser, err := h.userStore.InsertUser(c.UserContext(), user)
 	if err != nil {
-		if mongo.IsDuplicateKeyError(err) {
-			return c.Status(fiber.StatusConflict).JSON(types.ResGeneric{
-				Msg:    "email already exist",
-				Status: fiber.StatusConflict,
-			})
-		}
-		log.Errorf("HandlePostUser: error inserting user: %v", err)
-		return types.NewError(err, fiber.StatusInternalServerError, "something went wrong")
+		return storeError(err, "something went wrong")
 	}
 
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserCreated,
+		TargetType: types.AuditTargetUser,
+		TargetID:   insertedUser.ID,
+	}, nil, insertedUser)
+
+	setETag(c, insertedUser.Version)
 	return c.Status(fiber.StatusCreated).JSON(types.ResGeneric{
 		Data:   insertedUser,
 		Status: fiber.StatusCreated,
@@ -91,11 +81,21 @@ func (h *Handler) HandlePostUser(c *fiber.Ctx) error {
 func (h *Handler) HandleDeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if err := h.userStore.DeleteUser(c.Context(), id); err != nil {
-		log.Errorf("HandleDeleteUser: error deleting user: %v", err)
-		return types.NewError(err, fiber.StatusInternalServerError, "error deleting user")
+	before, err := h.userStore.GetByID(c.UserContext(), id)
+	if err != nil {
+		return storeError(err, "error deleting user")
+	}
+
+	if err := h.userStore.DeleteUser(c.UserContext(), id); err != nil {
+		return storeError(err, "error deleting user")
 	}
 
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserDeleted,
+		TargetType: types.AuditTargetUser,
+		TargetID:   before.ID,
+	}, before, nil)
+
 	return c.Status(fiber.StatusOK).JSON(types.ResGeneric{
 		Msg:    fmt.Sprintf("User %s deleted", id),
 		Status: fiber.StatusOK,
@@ -108,26 +108,118 @@ func (h *Handler) HandlePutUser(c *fiber.Ctx) error {
 		params *types.UpdateUserParams
 	)
 	if err := c.BodyParser(&params); err != nil {
-		return types.NewError(err, fiber.StatusInternalServerError, "error parsing body")
+		return utils.BadRequestError("invalid request body")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
 
-	matchCount, updateErr := h.userStore.PutUser(c.Context(), params, id)
-	if updateErr != nil {
-		log.Errorf("HandlePutUser: error putting user: %v", updateErr)
-		return types.NewError(updateErr, fiber.StatusInternalServerError, "error updating user")
+	before, err := h.userStore.GetByID(c.UserContext(), id)
+	if err != nil {
+		return storeError(err, "error updating user")
 	}
 
-	if matchCount == 0 {
-		return types.Error{
-			ResGeneric: &types.ResGeneric{
-				Status: http.StatusNotFound,
-				Msg:    fmt.Sprintf("no user found with id %s", id),
-			},
-		}
+	user, err := h.userStore.PutUser(c.UserContext(), params, id, version)
+	if err != nil {
+		return storeError(err, "error updating user")
 	}
 
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserUpdated,
+		TargetType: types.AuditTargetUser,
+		TargetID:   user.ID,
+	}, before, user)
+
+	setETag(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(types.ResGeneric{
 		Msg:    fmt.Sprintf("User %s updated", id),
+		Data:   user,
+		Status: fiber.StatusOK,
+	})
+}
+
+// HandlePutUserRoles replaces the roles of a user and the hotels it works at,
+// empty lists take them all back.
+func (h *Handler) HandlePutUserRoles(c *fiber.Ctx) error {
+	req, ok := c.Locals(setRolesRequestKey).(*setRolesRequest)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", setRolesRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	before, err := h.userStore.GetByID(c.UserContext(), req.ID)
+	if err != nil {
+		return storeError(err, "error updating user roles")
+	}
+
+	user, err := h.userStore.SetRoles(c.UserContext(), req.ID, &types.SetRolesParams{
+		Roles:    req.Roles,
+		HotelIDs: req.HotelIDs,
+	}, version)
+	if err != nil {
+		return storeError(err, "error updating user roles")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserRolesChanged,
+		TargetType: types.AuditTargetUser,
+		TargetID:   user.ID,
+	}, before, user)
+
+	setETag(c, user.Version)
+	return c.Status(fiber.StatusOK).JSON(types.ResGeneric{
+		Data:   user,
+		Status: fiber.StatusOK,
+	})
+}
+
+func (h *Handler) HandleGetDeletedUsers(c *fiber.Ctx) error {
+	query, ok := c.Locals(getDeletedRequestKey).(*types.QueryNumericPaginate)
+	if !ok {
+		slog.ErrorContext(c.UserContext(), "locals field missing", "key", getDeletedRequestKey)
+		return utils.BadRequestError("")
+	}
+
+	users, total, err := h.userStore.GetDeletedUsers(c.UserContext(), query)
+	if err != nil {
+		return storeError(err, "Error getting deleted users")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(types.ResWithPaginate[types.ResNumericPaginate]{
+		ResGeneric: types.ResGeneric{
+			Data:   users,
+			Status: fiber.StatusOK,
+		},
+		Pagination: types.ResNumericPaginate{
+			Count: total,
+			Page:  query.Page,
+			Limit: int(query.Limit),
+		},
+	})
+}
+
+func (h *Handler) HandleRestoreUser(c *fiber.Ctx) error {
+	user, err := h.userStore.RestoreUser(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return storeError(err, "Error restoring user")
+	}
+
+	h.audit(c, &types.AuditEntry{
+		Action:     types.AuditUserRestored,
+		TargetType: types.AuditTargetUser,
+		TargetID:   user.ID,
+	}, nil, user)
+
+	setETag(c, user.Version)
+	return c.Status(fiber.StatusOK).JSON(&types.ResGeneric{
+		Data:   user,
 		Status: fiber.StatusOK,
 	})
 }
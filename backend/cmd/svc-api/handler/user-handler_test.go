@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/tokener"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -25,7 +29,7 @@ func TestPostUser(t *testing.T) {
 		type test struct {
 			desc     string
 			input    *types.CreateUserParams
-			expected *types.Error
+			expected *types.Problem
 			status   int
 		}
 
@@ -66,16 +70,16 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return all required fields error",
 				input:  partialInput,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email":     "required",
-							"FirstName": "required",
-							"LastName":  "required",
-							"Password":  "required",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email":     "required",
+						"FirstName": "required",
+						"LastName":  "required",
+						"Password":  "required",
 					},
 				},
 			},
@@ -83,13 +87,13 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return invalid email field error",
 				input:  invalidEmail,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Email": "email - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Email": "email - invalid",
 					},
 				},
 			},
@@ -97,14 +101,14 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName minimum field error",
 				input:  invalidMinNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "min - invalid",
-							"LastName":  "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "min - invalid",
+						"LastName":  "min - invalid",
 					},
 				},
 			},
@@ -112,14 +116,14 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidMaxNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "max - invalid",
-							"LastName":  "max - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "max - invalid",
+						"LastName":  "max - invalid",
 					},
 				},
 			},
@@ -127,14 +131,14 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidAlphaNames,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "alpha - invalid",
-							"LastName":  "alpha - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "alpha - invalid",
+						"LastName":  "alpha - invalid",
 					},
 				},
 			},
@@ -142,13 +146,13 @@ func TestPostUser(t *testing.T) {
 				desc:   "Should return invalid password min field error",
 				input:  invalidPassword,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"Password": "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"Password": "min - invalid",
 					},
 				},
 			},
@@ -175,7 +179,7 @@ func TestPostUser(t *testing.T) {
 
 			t.Run(tc.desc, func(t *testing.T) {
 				t.Parallel()
-				var body types.Error
+				var body types.Problem
 				if errDecode := json.NewDecoder(resp.Body).Decode(&body); errDecode != nil {
 					t.Fatal(errDecode)
 				}
@@ -318,6 +322,8 @@ func TestHandlePutUser(t *testing.T) {
 	tdb, app := Setup(mDatabase, config)
 	invalidMaxCharName := strings.Repeat("a", 49)
 	const target = "/v1/users"
+	admin := fixtures.AddUser(*tdb.Store, "put", "admin", true)
+	adminToken, _ := tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
 
 	t.Run("Validations", func(t *testing.T) {
 		t.Parallel()
@@ -342,7 +348,7 @@ func TestHandlePutUser(t *testing.T) {
 			id       string
 			input    *types.UpdateUserParams
 			desc     string
-			expected *types.Error
+			expected *types.Problem
 			status   int
 		}
 
@@ -352,14 +358,14 @@ func TestHandlePutUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName minimum field error",
 				input:  invalidMinFields,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "min - invalid",
-							"LastName":  "min - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "min - invalid",
+						"LastName":  "min - invalid",
 					},
 				},
 			},
@@ -368,14 +374,14 @@ func TestHandlePutUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidMaxFields,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "max - invalid",
-							"LastName":  "max - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "max - invalid",
+						"LastName":  "max - invalid",
 					},
 				},
 			},
@@ -384,14 +390,14 @@ func TestHandlePutUser(t *testing.T) {
 				desc:   "Should return invalid firstName and lastName maximum field error",
 				input:  invalidAlphaFields,
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"FirstName": "alpha - invalid",
-							"LastName":  "alpha - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"FirstName": "alpha - invalid",
+						"LastName":  "alpha - invalid",
 					},
 				},
 			},
@@ -400,13 +406,13 @@ func TestHandlePutUser(t *testing.T) {
 				input:  validParams,
 				desc:   "must return required and invalid fields",
 				status: 400,
-				expected: &types.Error{
-					ResGeneric: &types.ResGeneric{
-						Status: 400,
-						Msg:    "Bad Request",
-						Errors: map[string]interface{}{
-							"ID": "id - invalid",
-						},
+				expected: &types.Problem{
+					Type:   "about:blank",
+					Title:  "Bad Request",
+					Status: 400,
+					Code:   "validation_failed",
+					Errors: map[string]interface{}{
+						"ID": "id - invalid",
 					},
 				},
 			},
@@ -418,6 +424,7 @@ func TestHandlePutUser(t *testing.T) {
 				Method:  "PUT",
 				Target:  fmt.Sprintf("%s/%s", target, tc.id),
 				Payload: bytes.NewReader(b),
+				Token:   adminToken,
 			}
 			resp, err := app.Test(testReq.NewRequestWithHeader())
 			if err != nil {
@@ -433,7 +440,7 @@ func TestHandlePutUser(t *testing.T) {
 
 			t.Run(tc.desc, func(t *testing.T) {
 				t.Parallel()
-				var body types.Error
+				var body types.Problem
 				if errDecode := json.NewDecoder(resp.Body).Decode(&body); errDecode != nil {
 					t.Fatal(errDecode)
 				}
@@ -452,16 +459,24 @@ func TestHandlePutUser(t *testing.T) {
 			LastName:  "update",
 		}
 		b, _ := json.Marshal(params)
+		token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
 		testReq := utils.TestRequest{
 			Method:  "PUT",
 			Target:  fmt.Sprintf("%s/%s", target, user.ID.Hex()),
 			Payload: bytes.NewReader(b),
+			Token:   token,
 		}
-		resp, err := app.Test(testReq.NewRequestWithHeader())
+		req := testReq.NewRequestWithHeader()
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+		resp, err := app.Test(req)
 		if err != nil {
 			t.Fatal(err)
 		}
 
+		if etag := resp.Header.Get(fiber.HeaderETag); etag != `"2"` {
+			t.Errorf("expected the version to be bumped to \"2\", got %s", etag)
+		}
+
 		var result types.ResGeneric
 		if errDecode := json.NewDecoder(resp.Body).Decode(&result); errDecode != nil {
 			t.Fatal(errDecode)
@@ -483,21 +498,153 @@ func TestHandlePutUser(t *testing.T) {
 			Method:  "PUT",
 			Target:  fmt.Sprintf("%s/%s", target, obi),
 			Payload: bytes.NewReader(b),
+			Token:   adminToken,
 		}
-		resp, err := app.Test(testReq.NewRequestWithHeader())
+		req := testReq.NewRequestWithHeader()
+		req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+		resp, err := app.Test(req)
 		if err != nil {
 			t.Error(err)
 		}
 
-		var response types.ResGeneric
+		var response types.Problem
 		if errDecode := json.NewDecoder(resp.Body).Decode(&response); errDecode != nil {
 			t.Fatal(errDecode)
 		}
 
 		expectedError := fmt.Sprintf("no user found with id %s", obi)
 
-		if response.Msg != expectedError {
-			t.Errorf("expecting error %s but received %s", expectedError, response.Msg)
+		if response.Detail != expectedError {
+			t.Errorf("expecting error %s but received %s", expectedError, response.Detail)
+		}
+	})
+
+	t.Run("version preconditions", func(t *testing.T) {
+		user := fixtures.AddUser(*tdb.Store, "stale", "user", false)
+		b, _ := json.Marshal(types.UpdateUserParams{FirstName: "Stale"})
+
+		tests := []struct {
+			desc    string
+			ifMatch string
+			status  int
+			code    string
+		}{
+			{desc: "missing If-Match", status: fiber.StatusPreconditionRequired, code: "if_match_required"},
+			{desc: "malformed If-Match", ifMatch: "abc", status: fiber.StatusBadRequest, code: "bad_request"},
+			{desc: "stale version", ifMatch: `"7"`, status: fiber.StatusPreconditionFailed, code: "version_mismatch"},
+		}
+
+		for _, tc := range tests {
+			testReq := utils.TestRequest{
+				Method:  "PUT",
+				Target:  fmt.Sprintf("%s/%s", target, user.ID.Hex()),
+				Payload: bytes.NewReader(b),
+				Token:   adminToken,
+			}
+			req := testReq.NewRequestWithHeader()
+			if tc.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tc.ifMatch)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var problem types.Problem
+			if errDecode := json.NewDecoder(resp.Body).Decode(&problem); errDecode != nil {
+				t.Fatal(errDecode)
+			}
+
+			if resp.StatusCode != tc.status || problem.Code != tc.code {
+				t.Errorf("%s: expected %d %s, got %d %s", tc.desc, tc.status, tc.code, resp.StatusCode, problem.Code)
+			}
+		}
+	})
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	config := NewConfig()
+	tdb, app := Setup(mDatabase, config)
+
+	var (
+		user   = fixtures.AddUser(*tdb.Store, "soft", "delete", false)
+		admin  = fixtures.AddUser(*tdb.Store, "soft", "admin", true)
+		target = "/v1/users/" + user.ID.Hex()
+	)
+	adminToken, _ := tokener.GenerateJWT(admin.ID.Hex(), admin.IsAdmin, config)
+
+	send := func(t *testing.T, method, target, token string) *http.Response {
+		t.Helper()
+		testReq := utils.TestRequest{Method: method, Target: target, Token: token}
+		resp, err := app.Test(testReq.NewRequestWithHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	other := fixtures.AddUser(*tdb.Store, "soft", "other", false)
+	otherToken, _ := tokener.GenerateJWT(other.ID.Hex(), other.IsAdmin, config)
+	if resp := send(t, "DELETE", target, ""); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("expected an anonymous delete to be 401, received %d", resp.StatusCode)
+	}
+	if resp := send(t, "DELETE", target, otherToken); resp.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("expected deleting another user to be 403, received %d", resp.StatusCode)
+	}
+
+	userToken, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+	if resp := send(t, "DELETE", target, userToken); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+	}
+
+	t.Run("deleted user is hidden", func(t *testing.T) {
+		if resp := send(t, "GET", target, ""); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("expected 404 status code but received %d", resp.StatusCode)
+		}
+		if resp := send(t, "DELETE", target, adminToken); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("expected deleting twice to be 404, received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("admin lists and restores the deleted user", func(t *testing.T) {
+		resp := send(t, "GET", "/v1/admin/deleted/users", adminToken)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+
+		var list struct {
+			Data []types.User `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.ContainsFunc(list.Data, func(u types.User) bool { return u.ID == user.ID && u.DeletedAt != nil }) {
+			t.Fatalf("expected the deleted user in %+v", list.Data)
+		}
+
+		if resp := send(t, "POST", "/v1/admin/deleted/users/"+user.ID.Hex()+"/restore", adminToken); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("expected 200 status code but received %d", resp.StatusCode)
+		}
+		if resp := send(t, "GET", target, ""); resp.StatusCode != fiber.StatusOK {
+			t.Errorf("expected the restored user, received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("erased user cannot be restored", func(t *testing.T) {
+		erased := fixtures.AddUser(*tdb.Store, "soft", "erased", false)
+		if _, err := tdb.Store.User.EraseUser(t.Context(), erased.ID.Hex()); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp := send(t, "POST", "/v1/admin/deleted/users/"+erased.ID.Hex()+"/restore", adminToken); resp.StatusCode != fiber.StatusConflict {
+			t.Errorf("expected 409 status code but received %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("restore is admin only", func(t *testing.T) {
+		token, _ := tokener.GenerateJWT(user.ID.Hex(), user.IsAdmin, config)
+		if resp := send(t, "POST", "/v1/admin/deleted/users/"+user.ID.Hex()+"/restore", token); resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("expected 403 status code but received %d", resp.StatusCode)
 		}
 	})
 }
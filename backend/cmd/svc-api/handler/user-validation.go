@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
@@ -12,6 +13,7 @@ const (
 	updateUserRequestKey = "updateUserReqKey"
 	getUserRequestKey    = "getUserReqKey"
 	getUsersRequestKey   = "getUsersReqKey"
+	setRolesRequestKey   = "setRolesReqKey"
 )
 
 func InsertUserRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
@@ -62,3 +64,28 @@ func GetUsersRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
 
 	return types.NewQueryNumericPaginate(limit, page), getUsersRequestKey, nil
 }
+
+type setRolesRequest struct {
+	ID       string               `validate:"required,id" json:"-"`
+	Roles    []types.Role         `validate:"max=8,dive,oneof=revenue_manager staff" json:"roles"`
+	HotelIDs []primitive.ObjectID `validate:"max=50" json:"hotelIDs"`
+}
+
+func SetRolesRequestSchema(c *fiber.Ctx) (interface{}, string, error) {
+	var params types.SetRolesParams
+	if err := c.BodyParser(&params); err != nil {
+		return nil, setRolesRequestKey, utils.BadRequestError(err.Error())
+	}
+	if params.Roles == nil {
+		params.Roles = []types.Role{}
+	}
+	if params.HotelIDs == nil {
+		params.HotelIDs = []primitive.ObjectID{}
+	}
+
+	return &setRolesRequest{
+		ID:       c.Params("id"),
+		Roles:    params.Roles,
+		HotelIDs: params.HotelIDs,
+	}, setRolesRequestKey, nil
+}
@@ -2,36 +2,46 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/tnguven/hotel-reservation-app/cmd/svc-api/handler"
+	"github.com/tnguven/hotel-reservation-app/db"
+	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/health"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
 	"github.com/tnguven/hotel-reservation-app/internals/middleware"
 	"github.com/tnguven/hotel-reservation-app/internals/must"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/ratelimit"
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
 	"github.com/tnguven/hotel-reservation-app/internals/server"
 	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 )
 
-// @description Sample API
-
-// @BasePath /api/v1
-
-// @schemes http https
-// @produce application/json
-// @consumes application/json
+const (
+	readinessTimeout = 2 * time.Second
+	drainPeriod      = 5 * time.Second
+)
 
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("warn can not load .env file")
+	envErr := godotenv.Load()
+	configs := configure.MustLoad("svc-api", os.Args[1:])
+	slog.SetDefault(logger.New(os.Stdout, configs.LogLevel()))
+	if envErr != nil {
+		slog.Warn("can not load .env file", logger.Err(envErr))
 	}
+	slog.Debug("loaded configs", "configs", configs)
+
+	rootCtx := context.Background()
+	shutdownTracing := must.Panic(tracing.Setup(rootCtx, configs.ServiceName(), configs.TracingExporter()))
 
 	var (
-		rootCtx      = context.Background()
-		configs      = NewConfig().Validate().Debug()
 		mongodb      = repo.NewMongoDatabase(rootCtx, configs)
 		route        = server.NewServer(configs)
 		userStore    = store.NewMongoUserStore(mongodb)
@@ -40,19 +50,42 @@ func main() {
 		bookingStore = store.NewMongoBookingStore(mongodb, roomStore)
 	)
 
+	// the indexes are idempotent, each release brings its database up to
+	// SchemaVersion. A failure leaves the instance not ready.
+	if err := db.CreateIndexes(rootCtx, mongodb.GetDb()); err != nil {
+		slog.Error("creating indexes failed", logger.Err(err))
+	}
+
+	readiness := health.NewReadiness(readinessTimeout).
+		WithCheck("mongo", mongodb.Ping).
+		WithCheck("replicaSet", mongodb.CheckReplicaSet).
+		WithCheck("migrations", func(ctx context.Context) error {
+			return db.CheckMigrations(ctx, mongodb.GetDb())
+		})
+
 	handlers := handler.NewHandler(&store.Stores{
 		Hotel:   hotelStore,
 		Room:    roomStore,
 		User:    userStore,
 		Booking: bookingStore,
-	})
+		Payment: store.NewMongoPaymentStore(mongodb),
+		Hold:    store.NewMongoHoldStore(mongodb, roomStore),
+		Group:   store.NewMongoGroupBookingStore(mongodb),
+		Block:   store.NewMongoBlockStore(mongodb, roomStore),
+
+		Idempotency: store.NewMongoIdempotencyStore(mongodb),
+		Audit:       store.NewMongoAuditStore(mongodb),
+	}).WithReadiness(readiness).
+		WithRateLimiter(newRateLimiter(configs, mongodb)).
+		WithPaymentProvider(newPaymentProvider(configs))
 
 	validator := must.Panic(middleware.NewValidator())
 	handlers.Register(route, configs, validator)
 
 	go func() {
 		if err := route.Listen(configs.ListenAddr()); err != nil && err != http.ErrServerClosed {
-			log.Panicf("⚠️ server listen error: %s", err)
+			slog.Error("server listen error", logger.Err(err))
+			panic(err)
 		}
 	}()
 
@@ -61,8 +94,39 @@ func main() {
 			mongodb.CloseConnection(rootCtx)
 		}()
 
-		if err := route.Shutdown(); err != nil {
-			log.Printf("server shutdown failed: %s", err)
+		// fail /readyz first and give the load balancer time to stop sending traffic
+		readiness.Drain()
+		select {
+		case <-time.After(drainPeriod):
+		case <-shutdownCtx.Done():
+		}
+
+		if err := route.ShutdownWithContext(shutdownCtx); err != nil {
+			slog.ErrorContext(shutdownCtx, "server shutdown failed", logger.Err(err))
+		}
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.ErrorContext(shutdownCtx, "tracing shutdown failed", logger.Err(err))
 		}
 	})
 }
+
+func newRateLimiter(configs configure.RateLimit, mongodb *repo.MongoDatabase) *ratelimit.Limiter {
+	switch configs.RateLimitBackend() {
+	case "mongo":
+		return ratelimit.NewLimiter(ratelimit.NewMongoStore(mongodb))
+	case "memory":
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	default:
+		return nil
+	}
+}
+
+func newPaymentProvider(configs configure.Payment) payment.Provider {
+	switch configs.PaymentProvider() {
+	case payment.FakeProviderName:
+		return payment.NewFakeProvider()
+	default:
+		return nil
+	}
+}
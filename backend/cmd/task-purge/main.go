@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+)
+
+// task-purge removes for good the users, hotels and rooms soft deleted for
+// longer than the retention period. Run it periodically, from cron or a
+// kubernetes CronJob.
+func main() {
+	envErr := godotenv.Load()
+	configs := configure.MustLoad("task-purge", os.Args[1:])
+	slog.SetDefault(logger.New(os.Stdout, configs.LogLevel()))
+	if envErr != nil {
+		slog.Warn("can not load .env file", logger.Err(envErr))
+	}
+
+	var (
+		ctx        = context.Background()
+		mongodb    = repo.NewMongoDatabase(ctx, configs)
+		hotelStore = store.NewMongoHotelStore(mongodb)
+		roomStore  = store.NewMongoRoomStore(mongodb, hotelStore)
+		userStore  = store.NewMongoUserStore(mongodb)
+		before     = time.Now().Add(-configs.PurgeRetention())
+	)
+	defer mongodb.CloseConnection(ctx)
+
+	// rooms go first, a hotel is only purged once none of its rooms is left
+	purges := []struct {
+		collection string
+		purge      func(context.Context, time.Time) (int64, error)
+	}{
+		{"rooms", roomStore.PurgeDeletedRooms},
+		{"hotels", hotelStore.PurgeDeletedHotels},
+		{"users", userStore.PurgeDeletedUsers},
+	}
+
+	failed := false
+	for _, p := range purges {
+		count, err := p.purge(ctx, before)
+		if err != nil {
+			slog.ErrorContext(ctx, "purge failed", "collection", p.collection, logger.Err(err))
+			failed = true
+			continue
+		}
+		slog.InfoContext(ctx, "purged soft deleted documents", "collection", p.collection, "count", count, "deletedBefore", before)
+	}
+
+	if failed {
+		mongodb.CloseConnection(ctx)
+		os.Exit(1)
+	}
+}
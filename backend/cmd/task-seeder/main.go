@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"math"
 	"math/rand"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/tnguven/hotel-reservation-app/db"
 	"github.com/tnguven/hotel-reservation-app/db/fixtures"
+	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
 	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
@@ -22,9 +25,12 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	configs := configure.MustLoad("task-seeder", os.Args[1:])
+	slog.SetDefault(logger.New(os.Stdout, configs.LogLevel()))
+	slog.Debug("loaded configs", "configs", configs)
+
 	var (
 		ctx          = context.Background()
-		configs      = NewConfig().Validate().Debug()
 		mongodb      = repo.NewMongoDatabase(ctx, configs)
 		userStore    = store.NewMongoUserStore(mongodb)
 		hotelStore   = store.NewMongoHotelStore(mongodb)
@@ -34,7 +40,7 @@ func main() {
 
 	defer func() {
 		mongodb.CloseConnection(ctx)
-		fmt.Println("Shutting down...")
+		slog.Info("shutting down...")
 	}()
 
 	dbStore := store.Stores{
@@ -50,9 +56,9 @@ func main() {
 	bookingStore.Drop(ctx)
 
 	admin := fixtures.AddUser(dbStore, "Test", "test", true)
-	fmt.Println("admin => ", admin.ID)
+	slog.Info("seeded admin", "userID", admin.ID.Hex())
 	user := fixtures.AddUser(dbStore, "Test1", "Test2", false)
-	fmt.Println("user => ", user.ID)
+	slog.Info("seeded user", "userID", user.ID.Hex())
 	fixtures.AddUser(dbStore, "Test3", "Test3", false)
 	fixtures.AddUser(dbStore, "Test4", "Test4", false)
 
@@ -131,7 +137,7 @@ func main() {
 						time.Now().AddDate(0, 0, rngInt(11, 22)),
 					)
 					if booked != nil {
-						bookedIds = append(bookedIds, fmt.Sprintf("%s\n", booked.ID.Hex()))
+						bookedIds = append(bookedIds, booked.ID.Hex())
 					}
 				}
 			}()
@@ -139,8 +145,12 @@ func main() {
 	}
 
 	wg.Wait()
-	fmt.Println(bookedIds)
-	db.CreateIndexes(ctx, mongodb.GetDb())
+	slog.Info("seeded bookings", "bookingIDs", bookedIds)
+	if err := db.CreateIndexes(ctx, mongodb.GetDb()); err != nil {
+		slog.Error("creating indexes failed", logger.Err(err))
+		mongodb.CloseConnection(ctx)
+		os.Exit(1)
+	}
 }
 
 func rngFloat(min float64, max float64) float64 {
@@ -2,7 +2,7 @@ package db
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,11 +12,19 @@ import (
 
 func CreateIndexes(ctx context.Context, db *mongo.Database) error {
 	var wg sync.WaitGroup
-	errChan := make(chan error, 2)
+	errChan := make(chan error, 10)
 
-	wg.Add(2)
+	wg.Add(10)
 	go createBookingIndexes(ctx, db, &wg, errChan)
 	go createUsersIndexes(ctx, db, &wg, errChan)
+	go createRateLimitIndexes(ctx, db, &wg, errChan)
+	go createIdempotencyIndexes(ctx, db, &wg, errChan)
+	go createAuditIndexes(ctx, db, &wg, errChan)
+	go createSoftDeleteIndexes(ctx, db, &wg, errChan)
+	go createPaymentIndexes(ctx, db, &wg, errChan)
+	go createHoldIndexes(ctx, db, &wg, errChan)
+	go createGroupIndexes(ctx, db, &wg, errChan)
+	go createBlockIndexes(ctx, db, &wg, errChan)
 
 	wg.Wait()
 	close(errChan)
@@ -27,7 +35,12 @@ func CreateIndexes(ctx context.Context, db *mongo.Database) error {
 		}
 	}
 
-	return nil
+	// the unique code index must exist before codes are drawn
+	if err := backfillConfirmationCodes(ctx, db); err != nil {
+		return err
+	}
+
+	return recordSchemaVersion(ctx, db)
 }
 
 func createBookingIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
@@ -45,7 +58,25 @@ func createBookingIndexes(ctx context.Context, db *mongo.Database, wg *sync.Wait
 		},
 	}
 
-	indexModels := []mongo.IndexModel{roomIDIndexModel, userIDIndexModel}
+	// the booking lists sort on the stay dates
+	fromDateIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "fromDate", Value: 1}, {Key: "_id", Value: 1}},
+	}
+
+	// the bookings made before confirmation codes have none until
+	// backfillConfirmationCodes, the store matches collisions by the name
+	codeIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetName("code_1").SetUnique(true).SetSparse(true),
+	}
+
+	// the parts of a split stay are joined to their original booking
+	splitFromIDIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "splitFromID", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	indexModels := []mongo.IndexModel{roomIDIndexModel, userIDIndexModel, fromDateIndexModel, codeIndexModel, splitFromIDIndexModel}
 
 	for _, model := range indexModels {
 		_, err := bookingCollection.Indexes().CreateOne(ctx, model)
@@ -55,7 +86,7 @@ func createBookingIndexes(ctx context.Context, db *mongo.Database, wg *sync.Wait
 		}
 	}
 
-	log.Println("Created index bookings.roomID, bookings.userID fields")
+	slog.InfoContext(ctx, "created indexes", "collection", "bookings", "fields", []string{"roomID", "userID", "fromDate", "code", "splitFromID"})
 }
 
 func createUsersIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
@@ -79,5 +110,158 @@ func createUsersIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGr
 		}
 	}
 
-	log.Println("Created index users.email fields")
+	slog.InfoContext(ctx, "created indexes", "collection", "users", "fields", []string{"email"})
+}
+
+func createRateLimitIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	rateLimitCollection := db.Collection("rate_limits")
+	expireAtIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "expireAt", Value: 1},
+		},
+		Options: options.Index().SetExpireAfterSeconds(0), // drop the window once it is over
+	}
+
+	if _, err := rateLimitCollection.Indexes().CreateOne(ctx, expireAtIndexModel); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "rate_limits", "fields", []string{"expireAt"})
+}
+
+func createIdempotencyIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	idempotencyCollection := db.Collection("idempotency_keys")
+	expireAtIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "expireAt", Value: 1},
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if _, err := idempotencyCollection.Indexes().CreateOne(ctx, expireAtIndexModel); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "idempotency_keys", "fields", []string{"expireAt"})
+}
+
+func createAuditIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	auditCollection := db.Collection("audit_log")
+	indexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "targetType", Value: 1}, {Key: "targetID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "actorID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}
+
+	if _, err := auditCollection.Indexes().CreateMany(ctx, indexModels); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "audit_log", "fields", []string{"createdAt", "targetType,targetID", "actorID"})
+}
+
+// createSoftDeleteIndexes serves the admin listings of deleted documents and
+// the purge, sparse so the live documents stay out of the index.
+func createSoftDeleteIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	collections := []string{"users", "hotels", "rooms"}
+	for _, name := range collections {
+		deletedAtIndexModel := mongo.IndexModel{
+			Keys:    bson.D{{Key: "deletedAt", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		}
+
+		if _, err := db.Collection(name).Indexes().CreateOne(ctx, deletedAtIndexModel); err != nil {
+			errChan <- err
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collections", collections, "fields", []string{"deletedAt"})
+}
+
+func createPaymentIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	paymentCollection := db.Collection("payments")
+	bookingIDIndexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "bookingID", Value: 1}, {Key: "createdAt", Value: 1}},
+	}
+
+	if _, err := paymentCollection.Indexes().CreateOne(ctx, bookingIDIndexModel); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "payments", "fields", []string{"bookingID,createdAt"})
+}
+
+// createHoldIndexes lets mongo delete the expired holds, the TTL monitor runs
+// every minute so the queries still filter on expiresAt.
+func createHoldIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	holdCollection := db.Collection("holds")
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{Keys: bson.D{{Key: "roomID", Value: 1}, {Key: "fromDate", Value: 1}}},
+	}
+
+	if _, err := holdCollection.Indexes().CreateMany(ctx, indexModels); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "holds", "fields", []string{"expiresAt (ttl)", "roomID,fromDate"})
+}
+
+func createGroupIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	groupIndexModels := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reference", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userID", Value: 1}}},
+	}
+	if _, err := db.Collection("groups").Indexes().CreateMany(ctx, groupIndexModels); err != nil {
+		errChan <- err
+		return
+	}
+
+	groupIDIndexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "groupID", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}
+	if _, err := db.Collection("bookings").Indexes().CreateOne(ctx, groupIDIndexModel); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "groups", "fields", []string{"reference", "userID", "bookings.groupID"})
+}
+
+func createBlockIndexes(ctx context.Context, db *mongo.Database, wg *sync.WaitGroup, errChan chan<- error) {
+	defer wg.Done()
+
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "roomID", Value: 1}, {Key: "fromDate", Value: 1}},
+	}
+	if _, err := db.Collection("room_blocks").Indexes().CreateOne(ctx, indexModel); err != nil {
+		errChan <- err
+		return
+	}
+
+	slog.InfoContext(ctx, "created indexes", "collection", "room_blocks", "fields", []string{"roomID,fromDate"})
 }
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"math/rand"
 	"strings"
 	"time"
 
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
 	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -96,7 +98,7 @@ func AddBooking(
 	}
 	insertedBooking, err := store.Booking.InsertBooking(context.TODO(), booking)
 	if err != nil && !isDup(err) {
-		log.Println(err)
+		slog.Error("add booking failed", "roomID", rid, logger.Err(err))
 		return nil
 	}
 
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SchemaVersion must be bumped whenever CreateIndexes gains new indexes or
+// backfills, the readiness probe refuses traffic until the database has
+// caught up.
+const SchemaVersion = 13
+
+const (
+	// codeAttempts bounds the codes drawn for one booking, like the store does
+	codeAttempts = 5
+
+	migrationsCollection = "migrations"
+	schemaDocID          = "schema"
+)
+
+type schemaState struct {
+	Version   int       `bson:"version"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+func recordSchemaVersion(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(migrationsCollection).UpdateOne(
+		ctx,
+		bson.M{"_id": schemaDocID},
+		// an older release still running during a rollout must not move the
+		// version back
+		bson.M{"$max": bson.M{"version": SchemaVersion}, "$set": bson.M{"appliedAt": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func CheckMigrations(ctx context.Context, db *mongo.Database) error {
+	var state schemaState
+	err := db.Collection(migrationsCollection).FindOne(ctx, bson.M{"_id": schemaDocID}).Decode(&state)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New("no migrations applied")
+	}
+	if err != nil {
+		return err
+	}
+
+	if state.Version < SchemaVersion {
+		return fmt.Errorf("schema version %d is behind %d", state.Version, SchemaVersion)
+	}
+
+	return nil
+}
+
+// backfillConfirmationCodes gives a code to the bookings made before codes
+// existed. Only the code is written, a duplicate key is a code drawn twice and
+// the booking draws again.
+func backfillConfirmationCodes(ctx context.Context, db *mongo.Database) error {
+	bookings := db.Collection("bookings")
+	cur, err := bookings.Find(ctx, bson.M{"code": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	count := 0
+	for cur.Next(ctx) {
+		var booking struct {
+			ID any `bson:"_id"`
+		}
+		if err := cur.Decode(&booking); err != nil {
+			return err
+		}
+
+		for attempt := 1; ; attempt++ {
+			code, err := types.NewConfirmationCode()
+			if err != nil {
+				return err
+			}
+			_, err = bookings.UpdateOne(ctx,
+				bson.M{"_id": booking.ID, "code": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"code": code}},
+			)
+			if err == nil {
+				break
+			}
+			if !mongo.IsDuplicateKeyError(err) || attempt == codeAttempts {
+				return fmt.Errorf("backfilling the code of booking %v: %w", booking.ID, err)
+			}
+		}
+		count++
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		slog.InfoContext(ctx, "backfilled confirmation codes", "bookings", count)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package analytics turns the booking facts of a hotel into the revenue
+// figures of each period.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sums holds the counts of a period before the ratios are worked out.
+type sums struct {
+	types.AnalyticsFigures
+	stayNights int64
+}
+
+func (s *sums) add(o *sums) {
+	s.RoomNights += o.RoomNights
+	s.NightsSold += o.NightsSold
+	s.Revenue += o.Revenue
+	s.Arrivals += o.Arrivals
+	s.Cancellations += o.Cancellations
+	s.stayNights += o.stayNights
+	s.Booked += o.Booked
+	s.BookedNights += o.BookedNights
+}
+
+func (s *sums) figures() types.AnalyticsFigures {
+	f := s.AnalyticsFigures
+	if f.RoomNights > 0 {
+		f.Occupancy = round(float64(f.NightsSold)/float64(f.RoomNights), 4)
+		f.RevPAR = divide(f.Revenue, f.RoomNights)
+	}
+	if f.NightsSold > 0 {
+		f.ADR = divide(f.Revenue, f.NightsSold)
+	}
+	if f.Arrivals > 0 {
+		f.CancellationRate = round(float64(f.Cancellations)/float64(f.Arrivals), 4)
+	}
+	if stays := f.Arrivals - f.Cancellations; stays > 0 {
+		f.AverageStay = round(float64(s.stayNights)/float64(stays), 2)
+	}
+	return f
+}
+
+// Summarize groups the facts by period and room type. Every period of the
+// range is listed, the ones without bookings too. Facts of rooms missing from
+// rooms are left out, like their nights are left out of the capacity. A
+// deleted room adds its nights to the capacity until its deletion.
+func Summarize(
+	hotelID primitive.ObjectID,
+	params *types.AnalyticsParam,
+	rooms []*types.Room,
+	facts []*types.BookingFact,
+) *types.HotelAnalytics {
+	var (
+		roomsByID = make(map[primitive.ObjectID]*types.Room, len(rooms))
+		seen      = map[types.RoomType]bool{}
+		roomTypes []types.RoomType
+	)
+	for _, room := range rooms {
+		roomsByID[room.ID] = room
+		if !seen[room.Type] {
+			seen[room.Type] = true
+			roomTypes = append(roomTypes, room.Type)
+		}
+	}
+	sort.Slice(roomTypes, func(i, j int) bool { return roomTypes[i] < roomTypes[j] })
+
+	var (
+		starts []time.Time
+		counts = map[time.Time]map[types.RoomType]*sums{}
+	)
+	for start := PeriodStart(params.From, params.Period); start.Before(params.Till); start = next(start, params.Period) {
+		from, till := later(start, params.From), earlier(next(start, params.Period), params.Till)
+
+		starts = append(starts, start)
+		counts[start] = make(map[types.RoomType]*sums, len(roomTypes))
+		for _, roomType := range roomTypes {
+			counts[start][roomType] = &sums{}
+		}
+		for _, room := range rooms {
+			roomTill := till
+			if room.DeletedAt != nil {
+				roomTill = earlier(till, PeriodStart(*room.DeletedAt, types.AnalyticsDay))
+			}
+			counts[start][room.Type].RoomNights += nightsBetween(from, roomTill)
+		}
+	}
+
+	for _, fact := range facts {
+		room, ok := roomsByID[fact.RoomID]
+		if !ok {
+			continue
+		}
+		s, ok := counts[fact.Start.UTC()][room.Type]
+		if !ok {
+			continue
+		}
+		s.add(&sums{
+			AnalyticsFigures: types.AnalyticsFigures{
+				NightsSold: fact.NightsSold,
+				// nights booked before rates were kept are priced at the current one
+				Revenue:       fact.Revenue + fact.UnratedNights*payment.NightlyAmount(room),
+				Arrivals:      fact.Arrivals,
+				Cancellations: fact.Cancellations,
+				Booked:        fact.Booked,
+				BookedNights:  fact.BookedNights,
+			},
+			stayNights: fact.StayNights,
+		})
+	}
+
+	result := &types.HotelAnalytics{
+		HotelID:  hotelID,
+		From:     params.From,
+		Till:     params.Till,
+		Period:   params.Period,
+		Currency: payment.Currency,
+		Periods:  make([]*types.AnalyticsBucket, 0, len(starts)),
+	}
+	var total sums
+	for _, start := range starts {
+		var period sums
+		bucket := &types.AnalyticsBucket{Start: start, RoomTypes: make([]*types.RoomTypeFigures, 0, len(roomTypes))}
+		for _, roomType := range roomTypes {
+			s := counts[start][roomType]
+			period.add(s)
+			bucket.RoomTypes = append(bucket.RoomTypes, &types.RoomTypeFigures{Type: roomType, AnalyticsFigures: s.figures()})
+		}
+		bucket.AnalyticsFigures = period.figures()
+		total.add(&period)
+		result.Periods = append(result.Periods, bucket)
+	}
+	result.Total = total.figures()
+
+	return result
+}
+
+// PeriodStart is the start of the period holding t, in UTC. Weeks start on
+// Monday, the way the store groups them.
+func PeriodStart(t time.Time, period types.AnalyticsPeriod) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case types.AnalyticsWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case types.AnalyticsMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func next(start time.Time, period types.AnalyticsPeriod) time.Time {
+	switch period {
+	case types.AnalyticsWeek:
+		return start.AddDate(0, 0, 7)
+	case types.AnalyticsMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func nightsBetween(from, till time.Time) int64 {
+	if !from.Before(till) {
+		return 0
+	}
+	return int64(math.Round(till.Sub(from).Hours() / 24))
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func divide(a, b int64) int64 {
+	return int64(math.Round(float64(a) / float64(b)))
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow10(places)
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// a Thursday
+	at := time.Date(2025, 3, 13, 15, 30, 0, 0, time.UTC)
+
+	for period, want := range map[types.AnalyticsPeriod]time.Time{
+		types.AnalyticsDay:   time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+		types.AnalyticsWeek:  time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		types.AnalyticsMonth: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got := PeriodStart(at, period); !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s", period, want, got)
+		}
+	}
+
+	sunday := time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)
+	if got := PeriodStart(sunday, types.AnalyticsWeek); !got.Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a sunday in the week of the monday before, got %s", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var (
+		king   = &types.Room{ID: primitive.NewObjectID(), Type: types.KingRoomType, BasePrice: 100}
+		king2  = &types.Room{ID: primitive.NewObjectID(), Type: types.KingRoomType, BasePrice: 100, Price: 120}
+		suite  = &types.Room{ID: primitive.NewObjectID(), Type: types.SuiteRoomType, BasePrice: 300}
+		monday = time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		params = &types.AnalyticsParam{From: monday.AddDate(0, 0, 3), Till: monday.AddDate(0, 0, 10), Period: types.AnalyticsWeek}
+	)
+
+	result := Summarize(primitive.NewObjectID(), params, []*types.Room{king, king2, suite}, []*types.BookingFact{
+		// booked at 90.00 before the rate went up
+		{Start: monday, RoomID: king.ID, NightsSold: 2, Revenue: 2 * 9000, Arrivals: 2, Cancellations: 1, StayNights: 2, Booked: 1, BookedNights: 2},
+		// booked before rates were kept, priced at the current rate
+		{Start: monday, RoomID: king2.ID, NightsSold: 4, UnratedNights: 4, Arrivals: 1, StayNights: 4},
+		{Start: monday.AddDate(0, 0, 7), RoomID: suite.ID, NightsSold: 3, Revenue: 3 * 30000, Arrivals: 1, StayNights: 3},
+		{Start: monday, RoomID: primitive.NewObjectID(), NightsSold: 7},
+	})
+
+	if len(result.Periods) != 2 {
+		t.Fatalf("expected 2 weeks, got %d", len(result.Periods))
+	}
+
+	first := result.Periods[0]
+	// thursday to sunday, 4 nights of 3 rooms
+	if first.RoomNights != 12 || first.NightsSold != 6 {
+		t.Fatalf("unexpected first week %+v", first.AnalyticsFigures)
+	}
+	if first.Revenue != 2*9000+4*12000 || first.ADR != 11000 || first.RevPAR != 5500 {
+		t.Fatalf("unexpected revenue %+v", first.AnalyticsFigures)
+	}
+	if first.Occupancy != 0.5 || first.CancellationRate != 0.3333 || first.AverageStay != 3 {
+		t.Fatalf("unexpected ratios %+v", first.AnalyticsFigures)
+	}
+	if len(first.RoomTypes) != 2 || first.RoomTypes[0].Type != types.KingRoomType || first.RoomTypes[0].RoomNights != 8 {
+		t.Fatalf("unexpected room types %+v", first.RoomTypes)
+	}
+	if first.RoomTypes[1].NightsSold != 0 || first.RoomTypes[1].Occupancy != 0 {
+		t.Fatalf("expected an empty suite week, got %+v", first.RoomTypes[1])
+	}
+
+	second := result.Periods[1]
+	// monday to wednesday
+	if second.RoomNights != 9 || second.RoomTypes[1].Occupancy != 1 {
+		t.Fatalf("unexpected second week %+v", second.AnalyticsFigures)
+	}
+
+	if result.Total.RoomNights != 21 || result.Total.NightsSold != 9 || result.Total.Revenue != 156000 || result.Total.Booked != 1 {
+		t.Fatalf("unexpected total %+v", result.Total)
+	}
+}
+
+func TestSummarizeDeletedRoom(t *testing.T) {
+	var (
+		monday  = time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		deleted = monday.AddDate(0, 0, 2).Add(15 * time.Hour)
+		king    = &types.Room{ID: primitive.NewObjectID(), Type: types.KingRoomType, BasePrice: 100, DeletedAt: &deleted}
+		params  = &types.AnalyticsParam{From: monday, Till: monday.AddDate(0, 0, 4), Period: types.AnalyticsDay}
+	)
+
+	result := Summarize(primitive.NewObjectID(), params, []*types.Room{king}, []*types.BookingFact{
+		{Start: monday, RoomID: king.ID, NightsSold: 1, Revenue: 10000},
+	})
+
+	// the room counts the two nights before the day it was deleted
+	if result.Total.RoomNights != 2 || result.Periods[2].RoomNights != 0 {
+		t.Fatalf("unexpected capacity %+v", result.Total)
+	}
+	if result.Total.NightsSold != 1 || result.Total.Revenue != 10000 {
+		t.Fatalf("expected the nights of the deleted room, got %+v", result.Total)
+	}
+}
@@ -0,0 +1,183 @@
+package configure
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Config is shared by every binary. Each leaf field can be set, in increasing
+// order of precedence, by its default, the config file (yaml/toml key), the
+// environment variable (env tag) and the command line flag (flag tag).
+type Config struct {
+	Env       string          `yaml:"env" toml:"env" env:"ENV" flag:"env" usage:"environment: development, test or production"`
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Mongo     MongoConfig     `yaml:"mongo" toml:"mongo"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rateLimit" toml:"rateLimit"`
+	Purge     PurgeConfig     `yaml:"purge" toml:"purge"`
+	Payment   PaymentConfig   `yaml:"payment" toml:"payment"`
+
+	service string
+}
+
+type ServerConfig struct {
+	ListenAddr string `yaml:"listenAddr" toml:"listenAddr" env:"LISTEN_ADDR" flag:"listen-addr" usage:"port or host:port to listen on"`
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri" toml:"uri" env:"MONGO_URI" flag:"mongo-uri" usage:"mongodb connection string" secret:"uri"`
+	Database string `yaml:"database" toml:"database" env:"MONGO_DATABASE" flag:"mongo-database" usage:"mongodb database name"`
+}
+
+type JWTConfig struct {
+	Secret        string `yaml:"secret" toml:"secret" env:"JWT_SECRET" flag:"jwt-secret" usage:"secret signing the api tokens" secret:"true"`
+	ExpireInHours int64  `yaml:"expireInHours" toml:"expireInHours" env:"EXPIRE_IN_HOURS" flag:"jwt-expire-hours" usage:"api token lifetime in hours"`
+}
+
+type LogConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"WITH_LOG" flag:"log" usage:"log every request"`
+	Level   string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"none, stdout or otlp"`
+}
+
+type RateLimitConfig struct {
+	Backend string `yaml:"backend" toml:"backend" env:"RATE_LIMIT_BACKEND" flag:"rate-limit-backend" usage:"none, memory or mongo"`
+}
+
+type PurgeConfig struct {
+	RetentionDays int64 `yaml:"retentionDays" toml:"retentionDays" env:"PURGE_RETENTION_DAYS" flag:"purge-retention-days" usage:"days a soft deleted record is kept before the purge removes it"`
+}
+
+type PaymentConfig struct {
+	Provider string `yaml:"provider" toml:"provider" env:"PAYMENT_PROVIDER" flag:"payment-provider" usage:"none or fake"`
+}
+
+func Default(service string) *Config {
+	return &Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			ListenAddr: "5000",
+		},
+		Mongo: MongoConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "hotel_io_dev",
+		},
+		JWT: JWTConfig{
+			Secret:        insecureJWTSecret,
+			ExpireInHours: 72,
+		},
+		Log: LogConfig{
+			Enabled: true,
+			Level:   "info",
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+		},
+		Purge: PurgeConfig{
+			RetentionDays: 30,
+		},
+		Payment: PaymentConfig{
+			Provider: "fake",
+		},
+		service: service,
+	}
+}
+
+func (conf *Config) DbName() string {
+	return conf.Mongo.Database
+}
+
+func (conf *Config) DbURI() string {
+	return conf.Mongo.URI
+}
+
+func (conf *Config) DbUriWithDbName() string {
+	return fmt.Sprintf("%s/%s", conf.DbURI(), conf.DbName())
+}
+
+func (conf *Config) JWTSecret() string {
+	return conf.JWT.Secret
+}
+
+func (conf *Config) TokenExpHour() int64 {
+	return conf.JWT.ExpireInHours
+}
+
+// ListenAddr accepts a bare port for compatibility with LISTEN_ADDR=5000.
+func (conf *Config) ListenAddr() string {
+	if strings.Contains(conf.Server.ListenAddr, ":") {
+		return conf.Server.ListenAddr
+	}
+	return ":" + conf.Server.ListenAddr
+}
+
+func (conf *Config) GoEnv() string {
+	return conf.Env
+}
+
+func (conf *Config) WithLog() bool {
+	return conf.Log.Enabled
+}
+
+func (conf *Config) LogLevel() string {
+	return conf.Log.Level
+}
+
+func (conf *Config) ServiceName() string {
+	return conf.service
+}
+
+func (conf *Config) TracingExporter() string {
+	return conf.Tracing.Exporter
+}
+
+func (conf *Config) RateLimitBackend() string {
+	return conf.RateLimit.Backend
+}
+
+func (conf *Config) PurgeRetention() time.Duration {
+	return time.Duration(conf.Purge.RetentionDays) * 24 * time.Hour
+}
+
+func (conf *Config) PaymentProvider() string {
+	return conf.Payment.Provider
+}
+
+func (conf *Config) IsProduction() bool {
+	return conf.Env == EnvProduction
+}
+
+func (conf *Config) LogValue() slog.Value {
+	redacted := conf.Redacted()
+	return slog.GroupValue(
+		slog.String("service", redacted.service),
+		slog.String("env", redacted.Env),
+		slog.String("listenAddr", redacted.Server.ListenAddr),
+		slog.String("mongoURI", redacted.Mongo.URI),
+		slog.String("mongoDatabase", redacted.Mongo.Database),
+		slog.String("jwtSecret", redacted.JWT.Secret),
+		slog.Int64("expireInHours", redacted.JWT.ExpireInHours),
+		slog.Bool("log", redacted.Log.Enabled),
+		slog.String("logLevel", redacted.Log.Level),
+		slog.String("tracing", redacted.Tracing.Exporter),
+		slog.String("rateLimit", redacted.RateLimit.Backend),
+		slog.Int64("purgeRetentionDays", redacted.Purge.RetentionDays),
+		slog.String("paymentProvider", redacted.Payment.Provider),
+	)
+}
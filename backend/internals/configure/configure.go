@@ -3,7 +3,7 @@ package configure
 type (
 	DbConfig interface {
 		DbName() string
-		DbUri() string
+		DbURI() string
 		DbUriWithDbName() string
 	}
 
@@ -19,8 +19,22 @@ type (
 		ListenAddr() string
 	}
 
+	Tracing interface {
+		ServiceName() string
+		TracingExporter() string
+	}
+
+	RateLimit interface {
+		RateLimitBackend() string
+	}
+
+	Payment interface {
+		PaymentProvider() string
+	}
+
 	Common interface {
 		GoEnv() string
 		WithLog() bool
+		LogLevel() string
 	}
 )
@@ -0,0 +1,213 @@
+package configure
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const configFileEnv = "CONFIG_FILE"
+
+// Load builds the configuration of a binary from the defaults, the optional
+// config file (--config or CONFIG_FILE), the environment and the flags in args.
+// Every problem found on the way is reported at once in a *ValidationError.
+func Load(service string, args []string) (*Config, error) {
+	conf := Default(service)
+
+	fs := flag.NewFlagSet(service, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configFile := fs.String("config", os.Getenv(configFileEnv), "path to a yaml or toml config file")
+
+	flagValues := map[string]string{}
+	eachField(conf, func(f field) {
+		if f.flag == "" {
+			return
+		}
+		fs.Func(f.flag, f.usage, func(value string) error {
+			flagValues[f.flag] = value
+			return nil
+		})
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	verr := &ValidationError{}
+
+	if *configFile != "" {
+		if err := loadFile(conf, *configFile); err != nil {
+			verr.add("config", err.Error())
+		}
+	}
+
+	eachField(conf, func(f field) {
+		if value, ok := os.LookupEnv(f.env); ok && f.env != "" {
+			if err := f.set(value); err != nil {
+				verr.add(f.path, fmt.Sprintf("invalid %s: %v", f.env, err))
+			}
+		}
+	})
+
+	eachField(conf, func(f field) {
+		if value, ok := flagValues[f.flag]; ok {
+			if err := f.set(value); err != nil {
+				verr.add(f.path, fmt.Sprintf("invalid --%s: %v", f.flag, err))
+			}
+		}
+	})
+
+	if err := conf.Validate(); err != nil {
+		var fieldErrs *ValidationError
+		if errors.As(err, &fieldErrs) {
+			verr.Fields = append(verr.Fields, fieldErrs.Fields...)
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return conf, verr
+	}
+
+	return conf, nil
+}
+
+func loadFile(conf *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(conf); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(content), conf)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
+
+type field struct {
+	path   string
+	env    string
+	flag   string
+	usage  string
+	secret string
+	value  reflect.Value
+}
+
+func (f field) set(raw string) error {
+	switch f.value.Kind() {
+	case reflect.String:
+		f.value.SetString(raw)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(v)
+	case reflect.Int, reflect.Int64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(v)
+	default:
+		return fmt.Errorf("unsupported kind %s", f.value.Kind())
+	}
+	return nil
+}
+
+// eachField walks the exported leaf fields of conf, the path is built from the
+// yaml keys so errors name the same key users write in the config file.
+func eachField(conf *Config, fn func(field)) {
+	walk(reflect.ValueOf(conf).Elem(), "", fn)
+}
+
+func walk(v reflect.Value, prefix string, fn func(field)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		path := sf.Tag.Get("yaml")
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		if sf.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), path, fn)
+			continue
+		}
+
+		fn(field{
+			path:   path,
+			env:    sf.Tag.Get("env"),
+			flag:   sf.Tag.Get("flag"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret"),
+			value:  v.Field(i),
+		})
+	}
+}
+
+// MustLoad is the entry point of the binaries: it serves "config print" and
+// exits with every validation error listed when the configuration is invalid.
+func MustLoad(service string, args []string) *Config {
+	args, printConfig := PrintCommand(args)
+
+	conf, err := Load(service, args)
+	if conf == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if printConfig {
+		if printErr := conf.Print(os.Stdout); printErr != nil {
+			fmt.Fprintln(os.Stderr, printErr)
+			os.Exit(1)
+		}
+	}
+
+	if err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			fmt.Fprintln(os.Stderr, "invalid configuration:")
+			for _, f := range verr.Fields {
+				fmt.Fprintf(os.Stderr, "  - %s\n", f.Error())
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+
+	if printConfig {
+		os.Exit(0)
+	}
+
+	return conf
+}
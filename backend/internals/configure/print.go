@@ -0,0 +1,55 @@
+package configure
+
+import (
+	"io"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// PrintCommand reports whether args start with "config print" and returns the
+// remaining flags.
+func PrintCommand(args []string) ([]string, bool) {
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		return args[2:], true
+	}
+	return args, false
+}
+
+// Redacted returns a copy safe to print or log, secrets are masked and the
+// password of connection strings removed.
+func (conf *Config) Redacted() *Config {
+	clone := *conf
+	eachField(&clone, func(f field) {
+		switch f.secret {
+		case "true":
+			if f.value.String() != "" {
+				f.value.SetString(redacted)
+			}
+		case "uri":
+			f.value.SetString(redactURI(f.value.String()))
+		}
+	})
+	return &clone
+}
+
+func (conf *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+
+	return encoder.Encode(conf.Redacted())
+}
+
+func redactURI(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
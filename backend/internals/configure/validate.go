@@ -0,0 +1,91 @@
+package configure
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	insecureJWTSecret  = "top_secret"
+	minJWTSecretLength = 32
+)
+
+// insecureJWTSecrets are the defaults shipped with the repo and the compose file.
+var insecureJWTSecrets = []string{"", insecureJWTSecret, "secret"}
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError lists every invalid field instead of stopping at the first one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: msg})
+}
+
+func (conf *Config) Validate() error {
+	verr := &ValidationError{}
+
+	oneOf := func(field, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			verr.add(field, fmt.Sprintf("%q must be one of %s", value, strings.Join(allowed, ", ")))
+		}
+	}
+
+	oneOf("env", conf.Env, EnvDevelopment, EnvTest, EnvProduction)
+	if conf.Server.ListenAddr == "" {
+		verr.add("server.listenAddr", "missing listen addr")
+	}
+	if conf.Mongo.URI == "" {
+		verr.add("mongo.uri", "missing mongo database URI")
+	}
+	if conf.Mongo.Database == "" {
+		verr.add("mongo.database", "missing mongo database name")
+	}
+	if conf.JWT.ExpireInHours <= 0 {
+		verr.add("jwt.expireInHours", "must be greater than 0")
+	}
+	oneOf("log.level", conf.Log.Level, "debug", "info", "warn", "error")
+	oneOf("tracing.exporter", conf.Tracing.Exporter, "none", "stdout", "otlp")
+	oneOf("rateLimit.backend", conf.RateLimit.Backend, "none", "memory", "mongo")
+	if conf.Purge.RetentionDays <= 0 {
+		verr.add("purge.retentionDays", "must be greater than 0")
+	}
+	oneOf("payment.provider", conf.Payment.Provider, "none", "fake")
+
+	if conf.IsProduction() {
+		if slices.Contains(insecureJWTSecrets, conf.JWT.Secret) {
+			verr.add("jwt.secret", "the default secret is not allowed in production")
+		} else if len(conf.JWT.Secret) < minJWTSecretLength {
+			verr.add("jwt.secret", fmt.Sprintf("must be at least %d characters in production", minJWTSecretLength))
+		}
+		if conf.Tracing.Exporter == "stdout" {
+			verr.add("tracing.exporter", "stdout exporter is not allowed in production")
+		}
+		if conf.Payment.Provider == "fake" {
+			verr.add("payment.provider", "the fake provider is not allowed in production")
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrDraining = errors.New("shutting down")
+
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Readiness runs the dependency checks behind /readyz. Once Drain is called it
+// reports not ready regardless of the checks so load balancers stop routing
+// traffic before the server shuts down.
+type Readiness struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+func NewReadiness(timeout time.Duration) *Readiness {
+	return &Readiness{timeout: timeout}
+}
+
+func (r *Readiness) WithCheck(name string, check Check) *Readiness {
+	r.checks = append(r.checks, namedCheck{name: name, check: check})
+	return r
+}
+
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+func (r *Readiness) IsDraining() bool {
+	return r.draining.Load()
+}
+
+// Check runs every check concurrently under the configured timeout and returns
+// the error of each failing check keyed by name.
+func (r *Readiness) Check(ctx context.Context) (map[string]error, bool) {
+	if r.IsDraining() {
+		return map[string]error{"server": ErrDraining}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var (
+		results = make(map[string]error, len(r.checks))
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	wg.Add(len(r.checks))
+	for _, c := range r.checks {
+		go func() {
+			defer wg.Done()
+			err := c.check(ctx)
+			mu.Lock()
+			results[c.name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	ready := true
+	for _, err := range results {
+		if err != nil {
+			ready = false
+		}
+	}
+
+	return results, ready
+}
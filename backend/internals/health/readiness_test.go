@@ -0,0 +1,53 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/health"
+)
+
+func TestReadiness(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failing := func(context.Context) error { return errors.New("down") }
+
+	t.Run("ready when every check passes", func(t *testing.T) {
+		r := health.NewReadiness(time.Second).WithCheck("a", ok).WithCheck("b", ok)
+		if _, ready := r.Check(context.Background()); !ready {
+			t.Fatal("expected ready")
+		}
+	})
+
+	t.Run("not ready when a check fails", func(t *testing.T) {
+		r := health.NewReadiness(time.Second).WithCheck("a", ok).WithCheck("b", failing)
+		results, ready := r.Check(context.Background())
+		if ready {
+			t.Fatal("expected not ready")
+		}
+		if results["b"] == nil || results["a"] != nil {
+			t.Fatalf("unexpected results %+v", results)
+		}
+	})
+
+	t.Run("checks are bounded by the timeout", func(t *testing.T) {
+		slow := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		r := health.NewReadiness(10*time.Millisecond).WithCheck("slow", slow)
+		if _, ready := r.Check(context.Background()); ready {
+			t.Fatal("expected not ready")
+		}
+	})
+
+	t.Run("not ready while draining", func(t *testing.T) {
+		r := health.NewReadiness(time.Second).WithCheck("a", ok)
+		r.Drain()
+		results, ready := r.Check(context.Background())
+		if ready || !errors.Is(results["server"], health.ErrDraining) {
+			t.Fatalf("expected draining, got %+v", results)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("adds the request id from the context", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.New(&buf, "info")
+
+		ctx := logger.WithRequestID(context.Background(), "req-1")
+		log.InfoContext(ctx, "hello")
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line["requestID"] != "req-1" {
+			t.Fatalf("expected requestID req-1, got %v", line["requestID"])
+		}
+	})
+
+	t.Run("redacts secrets and passwords", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.New(&buf, "info")
+
+		log.Info("configs",
+			"jwtSecret", "top_secret",
+			slog.Group("user", "email", "foo@bar.com", "password", "foo_bar"),
+		)
+
+		var line struct {
+			JWTSecret string `json:"jwtSecret"`
+			User      struct {
+				Email    string `json:"email"`
+				Password string `json:"password"`
+			} `json:"user"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		if line.JWTSecret != "[REDACTED]" {
+			t.Fatalf("expected jwtSecret to be redacted, got %s", line.JWTSecret)
+		}
+		if line.User.Password != "[REDACTED]" {
+			t.Fatalf("expected password to be redacted, got %s", line.User.Password)
+		}
+		if line.User.Email != "foo@bar.com" {
+			t.Fatalf("expected email to be kept, got %s", line.User.Email)
+		}
+	})
+
+	t.Run("respects the configured level", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := logger.New(&buf, "warn")
+
+		log.Info("skipped")
+		if buf.Len() != 0 {
+			t.Fatalf("expected info to be dropped, got %s", buf.String())
+		}
+	})
+}
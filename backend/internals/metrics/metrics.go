@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "hotel"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "mongo",
+		Name:      "command_duration_seconds",
+		Help:      "MongoDB command latency reported by the driver.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "status"})
+
+	BookingsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "booking",
+		Name:      "created_total",
+		Help:      "Bookings successfully created.",
+	})
+
+	BookingConflicts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "booking",
+		Name:      "conflicts_total",
+		Help:      "Booking attempts rejected because the room was not available.",
+	})
+
+	BookingsCanceled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "booking",
+		Name:      "canceled_total",
+		Help:      "Bookings canceled, by who canceled them.",
+	}, []string{"by"})
+
+	PaymentAuthorizations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "payment",
+		Name:      "authorizations_total",
+		Help:      "Card authorizations by outcome: authorized, declined, action_required or failed.",
+	}, []string{"outcome"})
+
+	PaymentRefunds = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "payment",
+		Name:      "refunds_total",
+		Help:      "Refunds accepted by the payment provider.",
+	})
+
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "failures_total",
+		Help:      "Rejected authentication attempts by reason.",
+	}, []string{"reason"})
+)
+
+const (
+	CanceledByUser  = "user"
+	CanceledByAdmin = "admin"
+	CanceledUnpaid  = "unpaid"
+
+	AuthMissingToken       = "missing_token"
+	AuthInvalidToken       = "invalid_token"
+	AuthUnknownUser        = "unknown_user"
+	AuthInvalidCredentials = "invalid_credentials"
+)
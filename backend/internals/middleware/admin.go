@@ -14,3 +14,27 @@ func WithAdminAuth(c *fiber.Ctx) error {
 
 	return c.Next()
 }
+
+// WithRole lets through the admins and the users holding role.
+func WithRole(role types.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Context().UserValue("user").(*types.User)
+		if !ok || !user.HasRole(role) {
+			return utils.AccessForbiddenError()
+		}
+
+		return c.Next()
+	}
+}
+
+// WithSelfOrAdmin lets through the admins and the user whose id is in param.
+func WithSelfOrAdmin(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Context().UserValue("user").(*types.User)
+		if !ok || !(user.IsAdmin || user.ID.Hex() == c.Params(param)) {
+			return utils.AccessForbiddenError()
+		}
+
+		return c.Next()
+	}
+}
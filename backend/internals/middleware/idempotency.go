@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// WithIdempotency replays the first response given to an Idempotency-Key so a
+// client retrying after a timeout does not book twice. Keys are scoped to the
+// user resolved by JWTAuthentication, or to the IP on public routes, and the
+// same key sent with another method, path or body is refused with a 422.
+// Requests without the header are not tracked.
+func WithIdempotency(idempotencyStore store.IdempotencyStore, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return utils.BadRequestError("Idempotency-Key must be at most 255 characters")
+		}
+
+		ctx := c.UserContext()
+		scopedKey := idempotencyScope(c) + ":" + key
+		record, reserved, err := idempotencyStore.Reserve(ctx, scopedKey, requestHash(c), ttl)
+		if err != nil {
+			return types.NewError(err, fiber.StatusInternalServerError, "can not check the Idempotency-Key")
+		}
+
+		if !reserved {
+			return replay(c, record)
+		}
+
+		if err = c.Next(); err != nil {
+			// render the error here so the stored body is the one the client sees
+			if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			// nothing happened for sure, let the client retry with the same key
+			if releaseErr := idempotencyStore.Release(ctx, scopedKey); releaseErr != nil {
+				slog.WarnContext(ctx, "release idempotency key failed", logger.Err(releaseErr))
+			}
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if completeErr := idempotencyStore.Complete(ctx, scopedKey, status, body, contentType); completeErr != nil {
+			slog.WarnContext(ctx, "store idempotent response failed", logger.Err(completeErr))
+		}
+
+		return nil
+	}
+}
+
+func replay(c *fiber.Ctx, record *types.IdempotencyRecord) error {
+	if record.RequestHash != requestHash(c) {
+		return utils.IdempotencyKeyReusedError()
+	}
+	if record.State != types.IdempotencyCompleted {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return utils.IdempotencyKeyInProgressError()
+	}
+
+	c.Set(IdempotentReplayedHeader, "true")
+	c.Set(fiber.HeaderContentType, record.ContentType)
+	return c.Status(record.ResponseStatus).Send(record.ResponseBody)
+}
+
+func idempotencyScope(c *fiber.Ctx) string {
+	if user, ok := c.Locals("user").(*types.User); ok {
+		return "user:" + user.ID.Hex()
+	}
+	return "ip:" + c.IP()
+}
+
+func requestHash(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*types.IdempotencyRecord
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, key, requestHash string, ttl time.Duration) (*types.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		copied := *record
+		return &copied, false, nil
+	}
+
+	record := &types.IdempotencyRecord{Key: key, RequestHash: requestHash, State: types.IdempotencyProcessing}
+	s.records[key] = record
+	return record, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, key string, status int, body []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[key]
+	record.State = types.IdempotencyCompleted
+	record.ResponseStatus = status
+	record.ResponseBody = body
+	record.ContentType = contentType
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func TestWithIdempotency(t *testing.T) {
+	calls := 0
+	failing := true
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			problem := Problem(err)
+			return c.Status(problem.Status).JSON(problem, types.ProblemContentType)
+		},
+	})
+	app.Use(WithIdempotency(&memoryIdempotencyStore{records: map[string]*types.IdempotencyRecord{}}, time.Hour))
+	app.Post("/bookings", func(c *fiber.Ctx) error {
+		calls++
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"call": calls})
+	})
+	app.Post("/flaky", func(c *fiber.Ctx) error {
+		calls++
+		if failing {
+			return fiber.ErrServiceUnavailable
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	send := func(t *testing.T, path, key, body string) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	t.Run("replays the first response", func(t *testing.T) {
+		calls = 0
+		first, firstBody := send(t, "/bookings", "key-1", `{"room":1}`)
+		second, secondBody := send(t, "/bookings", "key-1", `{"room":1}`)
+
+		if calls != 1 {
+			t.Fatalf("expected the handler to run once, ran %d times", calls)
+		}
+		if second.StatusCode != first.StatusCode || secondBody != firstBody {
+			t.Errorf("expected %d %s, replayed %d %s", first.StatusCode, firstBody, second.StatusCode, secondBody)
+		}
+		if second.Header.Get(IdempotentReplayedHeader) != "true" {
+			t.Errorf("expected the %s header on the replay", IdempotentReplayedHeader)
+		}
+	})
+
+	t.Run("refuses the key with another payload", func(t *testing.T) {
+		send(t, "/bookings", "key-2", `{"room":1}`)
+		resp, body := send(t, "/bookings", "key-2", `{"room":2}`)
+
+		if resp.StatusCode != fiber.StatusUnprocessableEntity {
+			t.Fatalf("expected 422, got %d", resp.StatusCode)
+		}
+		if !strings.Contains(body, "idempotency_key_reused") {
+			t.Errorf("expected the idempotency_key_reused code, got %s", body)
+		}
+	})
+
+	t.Run("releases the key on server errors", func(t *testing.T) {
+		calls = 0
+		failing = true
+		resp, _ := send(t, "/flaky", "key-3", `{}`)
+		if resp.StatusCode != fiber.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d", resp.StatusCode)
+		}
+
+		failing = false
+		resp, _ = send(t, "/flaky", "key-3", `{}`)
+		if resp.StatusCode != fiber.StatusCreated || calls != 2 {
+			t.Errorf("expected the retry to run the handler, got %d after %d calls", resp.StatusCode, calls)
+		}
+	})
+
+	t.Run("ignores requests without a key", func(t *testing.T) {
+		calls = 0
+		send(t, "/bookings", "", `{"room":1}`)
+		send(t, "/bookings", "", `{"room":1}`)
+
+		if calls != 2 {
+			t.Errorf("expected the handler to run twice, ran %d times", calls)
+		}
+	})
+}
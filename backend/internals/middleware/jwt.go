@@ -1,11 +1,12 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
 	"github.com/tnguven/hotel-reservation-app/internals/store"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 )
@@ -14,17 +15,20 @@ func JWTAuthentication(userStore store.UserStore, configs configure.Secrets) fib
 	return func(c *fiber.Ctx) error {
 		token, ok := c.GetReqHeaders()["X-Api-Token"]
 		if !ok {
+			metrics.AuthFailures.WithLabelValues(metrics.AuthMissingToken).Inc()
 			return utils.UnauthorizedError()
 		}
 
 		claims, err := validateToken(token[0], configs.JWTSecret())
 		if err != nil {
+			metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidToken).Inc()
 			return utils.BadRequestError("missing api token")
 		}
 
 		userID := claims["id"].(string)
-		user, err := userStore.GetByID(c.Context(), userID)
+		user, err := userStore.GetByID(c.UserContext(), userID)
 		if err != nil {
+			metrics.AuthFailures.WithLabelValues(metrics.AuthUnknownUser).Inc()
 			return utils.UnauthorizedError()
 		}
 
@@ -37,7 +41,7 @@ func JWTAuthentication(userStore store.UserStore, configs configure.Secrets) fib
 func validateToken(tokenStr string, secret string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Println("invalid signing method", t.Header["alg"])
+			slog.Warn("invalid signing method", "alg", t.Header["alg"])
 			return nil, utils.UnauthorizedError()
 		}
 		return []byte(secret), nil
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
+)
+
+// WithMetrics records latency and status per route template (/v1/bookings/:bookingID)
+// rather than per raw path to keep the label cardinality bounded.
+func WithMetrics(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	route := c.Route().Path
+	status := responseStatus(c, err)
+
+	metrics.HTTPRequestDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+	metrics.HTTPRequests.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Inc()
+
+	return err
+}
+
+// responseStatus resolves the status before the error handler has written it,
+// since the handler runs after the middleware chain.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+
+	return Problem(err).Status
+}
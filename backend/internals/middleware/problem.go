@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+// Problem maps an error returned down the handler chain to the RFC 7807 body
+// written by the server error handler. Only the messages meant for clients are
+// copied, the wrapped driver errors stay in the logs.
+func Problem(err error) *types.Problem {
+	var storeErr *store.Error
+	if errors.As(err, &storeErr) {
+		status := storeStatus(storeErr.Kind)
+		return types.NewProblem(status, problemCode(storeErr.Code, status), storeErr.Msg)
+	}
+
+	var apiErr *types.Error
+	if errors.As(err, &apiErr) && apiErr.ResGeneric != nil {
+		problem := types.NewProblem(apiErr.Status, problemCode(apiErr.Code, apiErr.Status), "")
+		if apiErr.Msg != problem.Title {
+			problem.Detail = apiErr.Msg
+		}
+		problem.Errors = apiErr.Errors
+		return problem
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		problem := types.NewProblem(fiberErr.Code, problemCode("", fiberErr.Code), "")
+		if fiberErr.Message != problem.Title {
+			problem.Detail = fiberErr.Message
+		}
+		return problem
+	}
+
+	return types.NewProblem(http.StatusInternalServerError, problemCode("", http.StatusInternalServerError), "")
+}
+
+func storeStatus(kind error) int {
+	switch kind {
+	case store.ErrNotFound:
+		return http.StatusNotFound
+	case store.ErrConflict:
+		return http.StatusConflict
+	case store.ErrForbidden:
+		return http.StatusForbidden
+	case store.ErrValidation:
+		return http.StatusBadRequest
+	case store.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// problemCode falls back to the snake cased status text: not_found, conflict...
+func problemCode(code string, status int) string {
+	if code != "" {
+		return code
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
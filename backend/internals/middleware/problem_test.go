@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/store"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestProblem(t *testing.T) {
+	tests := []struct {
+		desc   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{
+			desc:   "store not found",
+			err:    store.NotFoundError(store.CodeBookingNotFound, "no booking found"),
+			status: http.StatusNotFound,
+			code:   store.CodeBookingNotFound,
+			detail: "no booking found",
+		},
+		{
+			desc:   "wrapped store conflict",
+			err:    fmt.Errorf("cancel: %w", store.ConflictError(store.CodeBookingAlreadyCanceled, "already canceled")),
+			status: http.StatusConflict,
+			code:   store.CodeBookingAlreadyCanceled,
+			detail: "already canceled",
+		},
+		{
+			desc:   "stale version",
+			err:    store.PreconditionFailedError(store.CodeVersionMismatch, "modified"),
+			status: http.StatusPreconditionFailed,
+			code:   store.CodeVersionMismatch,
+			detail: "modified",
+		},
+		{
+			desc:   "store validation hides the cause",
+			err:    store.ValidationError(store.CodeInvalidID, "invalid id x", errors.New("encoding/hex: invalid byte")),
+			status: http.StatusBadRequest,
+			code:   store.CodeInvalidID,
+			detail: "invalid id x",
+		},
+		{
+			desc:   "api error without code",
+			err:    types.NewError(mongo.ErrClientDisconnected, http.StatusInternalServerError, "error getting user"),
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+			detail: "error getting user",
+		},
+		{
+			desc:   "api error with the status text",
+			err:    &types.Error{ResGeneric: &types.ResGeneric{Status: http.StatusTooManyRequests, Msg: "Too Many Requests"}},
+			status: http.StatusTooManyRequests,
+			code:   "too_many_requests",
+		},
+		{
+			desc:   "fiber error",
+			err:    fiber.ErrMethodNotAllowed,
+			status: http.StatusMethodNotAllowed,
+			code:   "method_not_allowed",
+		},
+		{
+			desc:   "unknown error",
+			err:    mongo.ErrClientDisconnected,
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			problem := Problem(tc.err)
+			if problem.Status != tc.status || problem.Code != tc.code || problem.Detail != tc.detail {
+				t.Errorf("expected %d %s %q, got %d %s %q", tc.status, tc.code, tc.detail, problem.Status, problem.Code, problem.Detail)
+			}
+			if problem.Type != "about:blank" || problem.Title != http.StatusText(tc.status) {
+				t.Errorf("unexpected type and title %q %q", problem.Type, problem.Title)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/ratelimit"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"github.com/tnguven/hotel-reservation-app/internals/utils"
+)
+
+// WithRateLimit applies the route budget to the caller. Authenticated callers
+// are counted per user, resolved from their API token by JWTAuthentication,
+// so it must run after it on private routes. Anonymous callers are counted per IP.
+func WithRateLimit(limiter *ratelimit.Limiter, route string, policy ratelimit.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		identity, tier := rateLimitIdentity(c)
+		limit := policy.For(tier)
+
+		result, err := limiter.Allow(c.UserContext(), route+":"+identity, limit)
+		if err != nil {
+			// fail open, an unavailable counter store should not take the API down
+			slog.WarnContext(c.UserContext(), "rate limiter unavailable", "route", route, logger.Err(err))
+			return c.Next()
+		}
+
+		resetIn := max(int(time.Until(result.ResetAt).Seconds()+0.5), 0)
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(resetIn))
+		c.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(int(limit.Window.Seconds())))
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(resetIn))
+			return utils.TooManyRequestsError()
+		}
+
+		return c.Next()
+	}
+}
+
+func rateLimitIdentity(c *fiber.Ctx) (string, ratelimit.Tier) {
+	user, ok := c.Locals("user").(*types.User)
+	if !ok {
+		return "ip:" + c.IP(), ratelimit.AnonymousTier
+	}
+
+	if user.IsAdmin {
+		return "user:" + user.ID.Hex(), ratelimit.AdminTier
+	}
+
+	return "user:" + user.ID.Hex(), ratelimit.AuthenticatedTier
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// WithRequestID reuses the caller's X-Request-Id or generates a new one and
+// stores it on the request context so every log line down to the stores
+// carries it.
+func WithRequestID(c *fiber.Ctx) error {
+	id := c.Get(RequestIDHeader)
+	if id == "" || len(id) > 128 {
+		id = utils.UUIDv4()
+	}
+
+	c.Set(RequestIDHeader, id)
+	c.Context().SetUserValue(logger.RequestIDKey, id)
+	c.SetUserContext(logger.WithRequestID(c.UserContext(), id))
+
+	return c.Next()
+}
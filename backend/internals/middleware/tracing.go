@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing starts a server span per request, continuing the caller's trace
+// when a traceparent header is present. Handlers pick it up through
+// c.UserContext().
+func WithTracing(c *fiber.Ctx) error {
+	headers := propagation.HeaderCarrier{}
+	for key, values := range c.GetReqHeaders() {
+		headers[key] = values
+	}
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headers)
+
+	ctx, span := tracing.Start(ctx, c.Method()+" "+c.Path(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Method()),
+			semconv.URLPath(c.Path()),
+			semconv.ClientAddress(c.IP()),
+		),
+	)
+	defer span.End()
+
+	c.SetUserContext(ctx)
+	err := c.Next()
+
+	route := c.Route().Path
+	status := responseStatus(c, err)
+	span.SetName(c.Method() + " " + route)
+	span.SetAttributes(
+		semconv.HTTPRoute(route),
+		semconv.HTTPResponseStatusCode(status),
+	)
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("status %d", status))
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
@@ -1,10 +1,11 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
 	"github.com/tnguven/hotel-reservation-app/internals/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -41,7 +42,7 @@ func WithValidation(v *Validator, getSchema SchemaFunc) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		schema, name, err := getSchema(c)
 		if err != nil {
-			fmt.Println("ERROR HERE", err.Error())
+			slog.DebugContext(c.UserContext(), "request schema failed", "path", c.Path(), logger.Err(err))
 			return err
 		}
 
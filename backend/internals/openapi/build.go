@@ -0,0 +1,207 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+const (
+	Version         = "3.0.3"
+	apiKeyScheme    = "apiKey"
+	apiKeyHeader    = "X-Api-Token"
+	jsonContentType = "application/json"
+)
+
+// Doc documents one registered route, see Key for the lookup key.
+type Doc struct {
+	Summary string
+	Tags    []string
+	// Auth requires the X-Api-Token header
+	Auth bool
+	// Idempotent routes accept the optional Idempotency-Key header
+	Idempotent bool
+	// IfMatch routes require the ETag of the resource in the If-Match header
+	IfMatch bool
+	// Body and Query are the request schemas checked by mid.WithValidation
+	Body  any
+	Query any
+	// Status of the successful response, 200 when zero
+	Status int
+	// Data is wrapped in the types.ResGeneric envelope, Pagination is added
+	// next to it for the paginated listings
+	Data       any
+	Pagination any
+	// Raw responses are not wrapped in the envelope, ContentType defaults to json
+	Raw         any
+	ContentType string
+}
+
+var maxIdempotencyKeyLength = 255
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+
+// Key is the "METHOD /path" a route is documented under, fiber params are
+// written the OpenAPI way: /v1/users/{id}.
+func Key(method, path string) string {
+	return method + " " + normalizePath(path)
+}
+
+func normalizePath(path string) string {
+	path = pathParam.ReplaceAllString(path, "{$1}")
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// Build generates the document for the routes registered on the app. The keys
+// of the routes without a Doc are returned so callers can refuse to ship them.
+func Build(info Info, routes []fiber.Route, docs map[string]Doc) (*Document, []string) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				apiKeyScheme: {Type: "apiKey", In: "header", Name: apiKeyHeader},
+			},
+		},
+	}
+
+	var undocumented []string
+	seen := map[string]bool{}
+	for _, route := range routes {
+		// HEAD is registered along every GET and "*" is the not found fallback
+		if route.Method == fiber.MethodHead || strings.Contains(route.Path, "*") {
+			continue
+		}
+
+		key := Key(route.Method, route.Path)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		routeDoc, ok := docs[key]
+		if !ok {
+			undocumented = append(undocumented, key)
+			continue
+		}
+
+		path := normalizePath(route.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = operation(path, routeDoc)
+	}
+
+	sort.Strings(undocumented)
+	return doc, undocumented
+}
+
+func operation(path string, doc Doc) *Operation {
+	op := &Operation{
+		Summary:   doc.Summary,
+		Tags:      doc.Tags,
+		Responses: map[string]*Response{},
+	}
+
+	for _, name := range pathParams(path) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Pattern: objectIDPattern},
+		})
+	}
+
+	if doc.Idempotent {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:   "Idempotency-Key",
+			In:     "header",
+			Schema: &Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength},
+		})
+	}
+
+	if doc.IfMatch {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     "If-Match",
+			In:       "header",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	query := QueryParameters(doc.Query)
+	sort.Slice(query, func(i, j int) bool { return query[i].Name < query[j].Name })
+	op.Parameters = append(op.Parameters, query...)
+
+	if doc.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{jsonContentType: {Schema: SchemaOf(doc.Body)}},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = successResponse(status, doc)
+	op.Responses["default"] = &Response{
+		Description: "error",
+		Content:     map[string]MediaType{types.ProblemContentType: {Schema: SchemaOf(types.Problem{})}},
+	}
+
+	if doc.Auth {
+		op.Security = []map[string][]any{{apiKeyScheme: {}}}
+		op.Responses[strconv.Itoa(http.StatusUnauthorized)] = &Response{
+			Description: "missing or invalid " + apiKeyHeader,
+			Content:     map[string]MediaType{types.ProblemContentType: {Schema: SchemaOf(types.Problem{})}},
+		}
+	}
+
+	return op
+}
+
+func successResponse(status int, doc Doc) *Response {
+	contentType := doc.ContentType
+	if contentType == "" {
+		contentType = jsonContentType
+	}
+
+	var schema *Schema
+	if doc.Raw != nil {
+		schema = SchemaOf(doc.Raw)
+	} else {
+		schema = SchemaOf(types.ResGeneric{})
+		schema.Properties["data"] = SchemaOf(doc.Data)
+		if doc.Pagination != nil {
+			// types.ResWithPaginate has no json tag on the field
+			schema.Properties["Pagination"] = SchemaOf(doc.Pagination)
+		}
+	}
+
+	return &Response{
+		Description: http.StatusText(status),
+		Content:     map[string]MediaType{contentType: {Schema: schema}},
+	}
+}
+
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+	return params
+}
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type bookRequest struct {
+	FromDate  time.Time `validate:"required" json:"fromDate"`
+	NumPerson int       `validate:"required,min=1,max=20" json:"countPerson"`
+	Email     string    `validate:"omitempty,email" json:"email"`
+}
+
+type pageQuery struct {
+	Limit int64 `query:"limit" validate:"max=100"`
+	Skip  int64 `query:"-"`
+}
+
+func TestBuild(t *testing.T) {
+	app := fiber.New()
+	noop := func(c *fiber.Ctx) error { return nil }
+	rooms := app.Group("/v1/rooms")
+	rooms.Get("/", noop)
+	rooms.Post("/:roomID/booking", noop)
+	app.Delete("/v1/users/:id", noop)
+	app.All("*", noop)
+
+	doc, undocumented := Build(Info{Title: "test", Version: "1"}, app.GetRoutes(true), map[string]Doc{
+		"GET /v1/rooms":                   {Summary: "list", Query: pageQuery{}},
+		"POST /v1/rooms/{roomID}/booking": {Summary: "book", Auth: true, Body: bookRequest{}, Status: 201},
+	})
+
+	if !reflect.DeepEqual(undocumented, []string{"DELETE /v1/users/{id}"}) {
+		t.Errorf("unexpected undocumented routes %v", undocumented)
+	}
+
+	list := (*doc.Paths["/v1/rooms"])["get"]
+	if list == nil {
+		t.Fatalf("expected GET /v1/rooms in %v", doc.Paths)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Name != "limit" || *list.Parameters[0].Schema.Maximum != 100 {
+		t.Errorf("unexpected query parameters %+v", list.Parameters)
+	}
+
+	book := (*doc.Paths["/v1/rooms/{roomID}/booking"])["post"]
+	if book == nil {
+		t.Fatalf("expected POST /v1/rooms/{roomID}/booking in %v", doc.Paths)
+	}
+	if len(book.Parameters) != 1 || book.Parameters[0].In != "path" || book.Parameters[0].Name != "roomID" {
+		t.Errorf("unexpected path parameters %+v", book.Parameters)
+	}
+	if _, ok := book.Responses["201"]; !ok {
+		t.Errorf("expected a 201 response, got %v", book.Responses)
+	}
+	if len(book.Security) != 1 {
+		t.Errorf("expected the api key to be required")
+	}
+
+	body := book.RequestBody.Content[jsonContentType].Schema
+	if !reflect.DeepEqual(body.Required, []string{"fromDate", "countPerson"}) {
+		t.Errorf("unexpected required fields %v", body.Required)
+	}
+	if body.Properties["fromDate"].Format != "date-time" {
+		t.Errorf("expected fromDate to be a date-time, got %+v", body.Properties["fromDate"])
+	}
+	if body.Properties["email"].Format != "email" {
+		t.Errorf("expected email format, got %+v", body.Properties["email"])
+	}
+	if *body.Properties["countPerson"].Minimum != 1 || *body.Properties["countPerson"].Maximum != 20 {
+		t.Errorf("unexpected countPerson bounds %+v", body.Properties["countPerson"])
+	}
+}
@@ -0,0 +1,174 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const objectIDPattern = "^[0-9a-fA-F]{24}$"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// SchemaOf describes v from its json tags, the validate tags of the request
+// schemas become the matching constraints.
+func SchemaOf(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return schemaOfType(reflect.TypeOf(v), "json")
+}
+
+func schemaOfType(t reflect.Type, tagName string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem(), tagName)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem(), tagName)}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addFields(schema, t, tagName)
+		return schema
+	default:
+		// interface{} fields accept anything
+		return &Schema{}
+	}
+}
+
+func addFields(schema *Schema, t reflect.Type, tagName string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(schema, ft, tagName)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name := fieldName(f, tagName)
+		if name == "-" {
+			continue
+		}
+
+		fieldSchema := schemaOfType(f.Type, tagName)
+		if applyValidation(fieldSchema, f.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+func fieldName(f reflect.StructField, tagName string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tagName), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// applyValidation maps the go-playground validator tags and reports whether
+// the field is required.
+func applyValidation(schema *Schema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "id":
+			schema.Pattern = objectIDPattern
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]+$"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, v)
+			}
+		case "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			setBound(schema, name, n)
+		}
+	}
+	return required
+}
+
+func setBound(schema *Schema, bound string, n float64) {
+	if schema.Type == "string" {
+		length := int(n)
+		if bound == "min" {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+		return
+	}
+
+	if bound == "min" {
+		schema.Minimum = &n
+	} else {
+		schema.Maximum = &n
+	}
+}
+
+// QueryParameters describes the query string of a request schema from its
+// query tags.
+func QueryParameters(v any) []Parameter {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema := &Schema{Properties: map[string]*Schema{}}
+	addFields(schema, t, "query")
+
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	params := make([]Parameter, 0, len(schema.Properties))
+	for name, s := range schema.Properties {
+		params = append(params, Parameter{Name: name, In: "query", Required: required[name], Schema: s})
+	}
+	return params
+}
@@ -0,0 +1,173 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+const FakeProviderName = "fake"
+
+// Card numbers with a fixed outcome on the fake provider, every other number
+// passing the Luhn check is authorized.
+const (
+	FakeCardDeclined          = "4000000000000002"
+	FakeCardInsufficientFunds = "4000000000009995"
+	// FakeCard3DS asks for a challenge, answer it with FakeChallengeSuccess
+	FakeCard3DS          = "4000000000003220"
+	FakeChallengeSuccess = "authenticated"
+)
+
+type fakeCharge struct {
+	amount   int64
+	captured int64
+	refunded int64
+	voided   bool
+}
+
+// FakeProvider is a deterministic in memory provider for development and
+// tests, it never talks to a network.
+type FakeProvider struct {
+	mu      sync.Mutex
+	seq     int
+	charges map[string]*fakeCharge
+	now     func() time.Time
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{charges: make(map[string]*fakeCharge), now: time.Now}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) Authorize(_ context.Context, req AuthorizeRequest) (Result, error) {
+	if req.Amount <= 0 {
+		return Result{}, fmt.Errorf("%w: amount must be positive", ErrProvider)
+	}
+
+	if code := p.decline(req); code != "" {
+		return Result{Status: StatusDeclined, DeclineCode: code}, nil
+	}
+	if req.Card.Number == FakeCard3DS && req.ChallengeResponse == "" {
+		return Result{Status: StatusActionRequired}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	ref := fmt.Sprintf("fake_%06d", p.seq)
+	p.charges[ref] = &fakeCharge{amount: req.Amount}
+
+	return Result{Reference: ref, Status: StatusAuthorized}, nil
+}
+
+func (p *FakeProvider) decline(req AuthorizeRequest) string {
+	switch {
+	case !luhn(req.Card.Number):
+		return DeclineInvalidNumber
+	case expired(req.Card, p.now()):
+		return DeclineExpiredCard
+	case req.Card.Number == FakeCardDeclined:
+		return DeclineCardDeclined
+	case req.Card.Number == FakeCardInsufficientFunds:
+		return DeclineInsufficientFunds
+	case req.Card.Number == FakeCard3DS && req.ChallengeResponse != "" && req.ChallengeResponse != FakeChallengeSuccess:
+		return DeclineAuthenticationFailed
+	}
+	return ""
+}
+
+func (p *FakeProvider) Capture(_ context.Context, reference string, amount int64) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, err := p.charge(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if charge.voided || charge.captured > 0 || amount <= 0 || amount > charge.amount {
+		return Result{}, fmt.Errorf("%w: cannot capture %d on %s", ErrProvider, amount, reference)
+	}
+
+	charge.captured = amount
+	return Result{Reference: reference, Status: StatusCaptured}, nil
+}
+
+func (p *FakeProvider) Refund(_ context.Context, reference string, amount int64) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, err := p.charge(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if amount <= 0 || amount > charge.captured-charge.refunded {
+		return Result{}, fmt.Errorf("%w: cannot refund %d on %s", ErrProvider, amount, reference)
+	}
+
+	charge.refunded += amount
+	return Result{Reference: reference, Status: StatusRefunded}, nil
+}
+
+func (p *FakeProvider) Void(_ context.Context, reference string) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	charge, err := p.charge(reference)
+	if err != nil {
+		return Result{}, err
+	}
+	if charge.captured > 0 {
+		return Result{}, fmt.Errorf("%w: %s is captured, refund it instead", ErrProvider, reference)
+	}
+
+	charge.voided = true
+	return Result{Reference: reference, Status: StatusVoided}, nil
+}
+
+func (p *FakeProvider) charge(reference string) (*fakeCharge, error) {
+	charge, ok := p.charges[reference]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown reference %s", ErrProvider, reference)
+	}
+	return charge, nil
+}
+
+func luhn(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// expired cards are valid until the end of their expiry month.
+func expired(card types.Card, now time.Time) bool {
+	if card.ExpMonth < 1 || card.ExpMonth > 12 {
+		return true
+	}
+	end := time.Date(card.ExpYear, time.Month(card.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+	return !now.Before(end)
+}
@@ -0,0 +1,125 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+func TestFakeProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := NewFakeProvider()
+	provider.now = func() time.Time { return time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	card := func(number string) types.Card {
+		return types.Card{Number: number, ExpMonth: 12, ExpYear: 2030, CVC: "123"}
+	}
+
+	t.Run("authorizes by card number", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			card      types.Card
+			challenge string
+			status    Status
+			decline   string
+		}{
+			{"valid card", card("4242424242424242"), "", StatusAuthorized, ""},
+			{"declined card", card(FakeCardDeclined), "", StatusDeclined, DeclineCardDeclined},
+			{"insufficient funds", card(FakeCardInsufficientFunds), "", StatusDeclined, DeclineInsufficientFunds},
+			{"invalid number", card("4242424242424241"), "", StatusDeclined, DeclineInvalidNumber},
+			{"expired card", types.Card{Number: "4242424242424242", ExpMonth: 12, ExpYear: 2024}, "", StatusDeclined, DeclineExpiredCard},
+			{"3ds challenge", card(FakeCard3DS), "", StatusActionRequired, ""},
+			{"3ds answered", card(FakeCard3DS), FakeChallengeSuccess, StatusAuthorized, ""},
+			{"3ds failed", card(FakeCard3DS), "wrong", StatusDeclined, DeclineAuthenticationFailed},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				result, err := provider.Authorize(ctx, AuthorizeRequest{
+					Amount:            1000,
+					Currency:          Currency,
+					Card:              tc.card,
+					ChallengeResponse: tc.challenge,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Status != tc.status || result.DeclineCode != tc.decline {
+					t.Fatalf("expected %s %q, got %+v", tc.status, tc.decline, result)
+				}
+				if (result.Reference != "") != (tc.status == StatusAuthorized) {
+					t.Fatalf("unexpected reference %q", result.Reference)
+				}
+			})
+		}
+	})
+
+	t.Run("captures, refunds and voids", func(t *testing.T) {
+		auth, _ := provider.Authorize(ctx, AuthorizeRequest{Amount: 1000, Currency: Currency, Card: card("4242424242424242")})
+
+		if _, err := provider.Capture(ctx, auth.Reference, 1000); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.Void(ctx, auth.Reference); !errors.Is(err, ErrProvider) {
+			t.Fatalf("expected a captured charge not to be voided, got %v", err)
+		}
+		if _, err := provider.Refund(ctx, auth.Reference, 600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.Refund(ctx, auth.Reference, 600); !errors.Is(err, ErrProvider) {
+			t.Fatalf("expected to refund no more than captured, got %v", err)
+		}
+
+		other, _ := provider.Authorize(ctx, AuthorizeRequest{Amount: 1000, Currency: Currency, Card: card("4242424242424242")})
+		if _, err := provider.Void(ctx, other.Reference); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.Capture(ctx, other.Reference, 1000); !errors.Is(err, ErrProvider) {
+			t.Fatalf("expected a voided charge not to be captured, got %v", err)
+		}
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	from := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	room := &types.Room{BasePrice: 80, Price: 99.9}
+
+	if total := StayAmount(room, from, from.Add(3*24*time.Hour)); total != 29970 {
+		t.Fatalf("expected 3 nights at 99.90, got %d", total)
+	}
+	if total := StayAmount(&types.Room{BasePrice: 80}, from, from.Add(2*time.Hour)); total != 8000 {
+		t.Fatalf("expected one night at the base price, got %d", total)
+	}
+	if charge := ChargeAmount(types.PayDeposit, 10000); charge != 2000 {
+		t.Fatalf("expected a 20%% deposit, got %d", charge)
+	}
+	if charge := ChargeAmount(types.PayAtHotel, 10000); charge != 0 {
+		t.Fatalf("expected nothing charged, got %d", charge)
+	}
+
+	tests := []struct {
+		name     string
+		payment  types.Payment
+		before   time.Duration
+		byAdmin  bool
+		expected int64
+	}{
+		{"early cancel", types.Payment{Total: 10000, Captured: 10000}, 72 * time.Hour, false, 10000},
+		{"late cancel keeps the deposit", types.Payment{Total: 10000, Captured: 10000}, 24 * time.Hour, false, 8000},
+		{"late cancel of a deposit", types.Payment{Total: 10000, Captured: 2000}, 24 * time.Hour, false, 0},
+		{"admin cancel", types.Payment{Total: 10000, Captured: 10000}, time.Hour, true, 10000},
+		{"partially refunded", types.Payment{Total: 10000, Captured: 10000, Refunded: 9000}, 24 * time.Hour, false, 0},
+		{"nothing captured", types.Payment{Total: 10000}, 72 * time.Hour, false, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if refund := RefundAmount(&tc.payment, from, from.Add(-tc.before), tc.byAdmin); refund != tc.expected {
+				t.Fatalf("expected a refund of %d, got %d", tc.expected, refund)
+			}
+		})
+	}
+}
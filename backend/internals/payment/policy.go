@@ -0,0 +1,85 @@
+package payment
+
+import (
+	"math"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+const (
+	Currency = "EUR"
+	// DepositPercent of the stay is charged by the deposit mode, and kept on
+	// late cancellations
+	DepositPercent = 20
+	// FreeCancellation is how long before check in a guest can still cancel
+	// for a full refund
+	FreeCancellation = 48 * time.Hour
+)
+
+// Nights counts a started day as a night, a stay is at least one night.
+func Nights(from, till time.Time) int64 {
+	nights := int64(math.Ceil(till.Sub(from).Hours() / 24))
+	return max(nights, 1)
+}
+
+// NightlyAmount is the current rate of the room in minor units. Price is the
+// rate, BasePrice is used when no rate is set.
+func NightlyAmount(room *types.Room) int64 {
+	return int64(math.Round(room.Rate() * 100))
+}
+
+// StayAmount is the price of the stay in minor units, at the current rate.
+func StayAmount(room *types.Room, from, till time.Time) int64 {
+	return NightlyAmount(room) * Nights(from, till)
+}
+
+// ChargeAmount is what the mode takes when booking.
+func ChargeAmount(mode types.PaymentMode, total int64) int64 {
+	switch mode {
+	case types.PayNow:
+		return total
+	case types.PayDeposit:
+		return deposit(total)
+	default:
+		return 0
+	}
+}
+
+// RefundAmount applies the cancellation policy to a payment: an admin cancel
+// or a cancel before the free cancellation window refunds everything, later
+// the deposit share of the stay is kept.
+func RefundAmount(p *types.Payment, checkIn, now time.Time, byAdmin bool) int64 {
+	refundable := p.Refundable()
+	if refundable <= 0 {
+		return 0
+	}
+	if byAdmin || checkIn.Sub(now) >= FreeCancellation {
+		return refundable
+	}
+
+	return max(refundable-deposit(p.Total), 0)
+}
+
+func deposit(total int64) int64 {
+	return total * DepositPercent / 100
+}
+
+// Balance is what the guest still owes on a stay. Voided payments are ignored,
+// and a booking without payments, made before payments existed, owes the stay.
+// Refunds are goodwill and never add to the balance.
+func Balance(stay int64, payments []*types.Payment) int64 {
+	balance, live := int64(0), false
+	for _, p := range payments {
+		if p.Status == types.PaymentVoided {
+			continue
+		}
+		live = true
+		balance += p.Total - p.Captured
+	}
+	if !live {
+		return stay
+	}
+
+	return max(balance, 0)
+}
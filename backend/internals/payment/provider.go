@@ -0,0 +1,59 @@
+package payment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+)
+
+// ErrProvider reports that the provider could not process the call, unlike a
+// decline the outcome is unknown and the call can be retried.
+var ErrProvider = errors.New("payment provider failure")
+
+type Status string
+
+const (
+	StatusAuthorized     Status = "authorized"
+	StatusActionRequired Status = "action_required"
+	StatusDeclined       Status = "declined"
+	StatusCaptured       Status = "captured"
+	StatusRefunded       Status = "refunded"
+	StatusVoided         Status = "voided"
+)
+
+// Decline codes sent to the api clients, never rename them.
+const (
+	DeclineCardDeclined         = "card_declined"
+	DeclineInsufficientFunds    = "insufficient_funds"
+	DeclineInvalidNumber        = "invalid_number"
+	DeclineExpiredCard          = "expired_card"
+	DeclineAuthenticationFailed = "authentication_failed"
+)
+
+type AuthorizeRequest struct {
+	// Amount in minor units of Currency
+	Amount   int64
+	Currency string
+	Card     types.Card
+	// ChallengeResponse answers the 3DS challenge of a previous attempt
+	ChallengeResponse string
+}
+
+// Result is the answer of the provider, DeclineCode is only set on declines.
+type Result struct {
+	Reference   string
+	Status      Status
+	DeclineCode string
+}
+
+// Provider is the payment gateway. An authorization holds the amount on the
+// card, capture takes it, void releases an authorization that was not
+// captured and refund gives back captured money.
+type Provider interface {
+	Name() string
+	Authorize(context.Context, AuthorizeRequest) (Result, error)
+	Capture(ctx context.Context, reference string, amount int64) (Result, error)
+	Refund(ctx context.Context, reference string, amount int64) (Result, error)
+	Void(ctx context.Context, reference string) (Result, error)
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type memoryWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// MemoryStore keeps the counters in process, good for a single instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]*memoryWindow)}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	start := windowStart(now, window)
+	windowKey := key + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	w, ok := s.windows[windowKey]
+	if !ok {
+		w = &memoryWindow{resetAt: start.Add(window)}
+		s.windows[windowKey] = w
+	}
+	w.count++
+
+	return w.count, w.resetAt, nil
+}
+
+// sweep drops the expired windows, called with the lock held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, w := range s.windows {
+		if !now.Before(w.resetAt) {
+			delete(s.windows, key)
+		}
+	}
+	s.lastSweep = now
+}
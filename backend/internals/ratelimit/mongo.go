@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const rateLimitCollection = "rate_limits"
+
+// MongoStore shares the counters between instances. Expired windows are
+// removed by the TTL index on expireAt created in db.CreateIndexes.
+type MongoStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoStore(mongodb *repo.MongoDatabase) *MongoStore {
+	return &MongoStore{coll: mongodb.Coll(rateLimitCollection)}
+}
+
+func (s *MongoStore) Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	start := windowStart(now, window)
+	resetAt := start.Add(window)
+
+	var counter struct {
+		Count int `bson:"count"`
+	}
+	err := s.coll.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key + ":" + strconv.FormatInt(start.Unix(), 10)},
+		bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"expireAt": resetAt},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return counter.Count, resetAt, nil
+}
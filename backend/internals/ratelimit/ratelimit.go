@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Tier string
+
+const (
+	AnonymousTier     Tier = "anonymous"
+	AuthenticatedTier Tier = "authenticated"
+	AdminTier         Tier = "admin"
+)
+
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Policy is the budget of a single route for each kind of caller.
+type Policy struct {
+	Anonymous     Limit
+	Authenticated Limit
+	Admin         Limit
+}
+
+func (p Policy) For(tier Tier) Limit {
+	switch tier {
+	case AdminTier:
+		return p.Admin
+	case AuthenticatedTier:
+		return p.Authenticated
+	default:
+		return p.Anonymous
+	}
+}
+
+// Store counts hits per key in fixed windows. Increment returns the hit count
+// of the window that now falls into, including this hit.
+type Store interface {
+	Increment(ctx context.Context, key string, window time.Duration, now time.Time) (int, time.Time, error)
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	count, resetAt, err := l.store.Increment(ctx, key, limit.Window, l.now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:   count <= limit.Requests,
+		Limit:     limit.Requests,
+		Remaining: max(limit.Requests-count, 0),
+		ResetAt:   resetAt,
+	}, nil
+}
+
+func windowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
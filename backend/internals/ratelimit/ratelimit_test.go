@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Window: time.Minute}
+	now := time.Date(2025, 1, 1, 10, 0, 30, 0, time.UTC)
+
+	limiter := NewLimiter(NewMemoryStore())
+	limiter.now = func() time.Time { return now }
+
+	t.Run("allows the budget then rejects", func(t *testing.T) {
+		for i, expected := range []bool{true, true, false} {
+			result, err := limiter.Allow(ctx, "rooms:ip:1.1.1.1", limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != expected {
+				t.Fatalf("request %d: expected allowed=%v", i+1, expected)
+			}
+		}
+	})
+
+	t.Run("reports the window reset", func(t *testing.T) {
+		result, _ := limiter.Allow(ctx, "rooms:ip:1.1.1.1", limit)
+		expected := time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC)
+		if !result.ResetAt.Equal(expected) || result.Remaining != 0 {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	})
+
+	t.Run("keys are counted separately", func(t *testing.T) {
+		result, _ := limiter.Allow(ctx, "rooms:ip:2.2.2.2", limit)
+		if !result.Allowed || result.Remaining != 1 {
+			t.Fatalf("unexpected result %+v", result)
+		}
+	})
+
+	t.Run("a new window resets the budget", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		result, _ := limiter.Allow(ctx, "rooms:ip:1.1.1.1", limit)
+		if !result.Allowed {
+			t.Fatalf("expected the next window to allow, got %+v", result)
+		}
+	})
+}
+
+func TestPolicyFor(t *testing.T) {
+	policy := Policy{
+		Anonymous:     Limit{Requests: 1},
+		Authenticated: Limit{Requests: 2},
+		Admin:         Limit{Requests: 3},
+	}
+	if policy.For(AnonymousTier).Requests != 1 || policy.For(AuthenticatedTier).Requests != 2 || policy.For(AdminTier).Requests != 3 {
+		t.Fatal("unexpected tier mapping")
+	}
+}
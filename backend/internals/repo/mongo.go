@@ -2,13 +2,18 @@ package repo
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 
 	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
 	"github.com/tnguven/hotel-reservation-app/internals/must"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 var mdClient *mongo.Client
@@ -22,7 +27,12 @@ func NewMongoDatabase(ctx context.Context, conf configure.DbConfig) *MongoDataba
 	if mdClient == nil {
 		mdClient = must.Panic(mongo.Connect(
 			ctx,
-			options.Client().ApplyURI(conf.DbUriWithDbName()),
+			options.Client().
+				ApplyURI(conf.DbUriWithDbName()).
+				SetMonitor(combineMonitors(
+					metrics.NewCommandMonitor(),
+					otelmongo.NewMonitor(),
+				)),
 		))
 	}
 
@@ -31,14 +41,17 @@ func NewMongoDatabase(ctx context.Context, conf configure.DbConfig) *MongoDataba
 		db:     mdClient.Database(conf.DbName()),
 	}
 
-	db.CheckConnection(ctx)
+	// the readiness probe reports an unreachable database, don't crash the process for it
+	if err := db.CheckConnection(ctx); err != nil {
+		slog.WarnContext(ctx, "MongoDB is not reachable yet", logger.Err(err))
+	}
 	return db
 }
 
 func (d *MongoDatabase) CloseConnection(ctx context.Context) {
-	log.Println("shutting mongodb")
+	slog.InfoContext(ctx, "shutting mongodb")
 	if err := d.client.Disconnect(ctx); err != nil {
-		log.Printf("close mongodb failed: %v", err)
+		slog.ErrorContext(ctx, "close mongodb failed", logger.Err(err))
 	}
 }
 
@@ -47,15 +60,62 @@ func (d *MongoDatabase) Coll(name string) *mongo.Collection {
 	return coll
 }
 
-func (d *MongoDatabase) CheckConnection(ctx context.Context) {
-	// Send a ping to confirm a successful connection
-	if err := d.db.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err(); err != nil {
-		panic(err)
+func (d *MongoDatabase) CheckConnection(ctx context.Context) error {
+	if err := d.Ping(ctx); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "connected to MongoDB", "db", d.db.Name())
+	return nil
+}
+
+func (d *MongoDatabase) Ping(ctx context.Context) error {
+	return d.db.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err()
+}
+
+// CheckReplicaSet verifies the node is part of a replica set, bookings rely on
+// multi-document transactions which a standalone mongod rejects.
+func (d *MongoDatabase) CheckReplicaSet(ctx context.Context) error {
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	if err := d.db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" {
+		return errors.New("mongodb is not running as a replica set")
 	}
 
-	log.Println("✅ connected to MongoDB")
+	return nil
 }
 
 func (d *MongoDatabase) GetDb() *mongo.Database {
 	return d.db
 }
+
+// combineMonitors fans the driver events out, the client accepts a single monitor.
+func combineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,125 @@
+// Package report builds the front desk lists of a hotel.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Lines joins the bookings with their room, guest and payments, in the order
+// of the bookings. A split stay is priced and paid as a whole: parts holds the
+// live bookings of the stays, and the payments sit on the original booking.
+func Lines(
+	bookings []*types.Booking,
+	parts []*types.Booking,
+	rooms map[primitive.ObjectID]*types.Room,
+	users map[primitive.ObjectID]*types.User,
+	payments map[primitive.ObjectID][]*types.Payment,
+) []*types.ReportLine {
+	stays := make(map[primitive.ObjectID][]*types.Booking, len(parts))
+	for _, p := range parts {
+		stays[p.OriginalID()] = append(stays[p.OriginalID()], p)
+	}
+
+	lines := make([]*types.ReportLine, 0, len(bookings))
+	for _, b := range bookings {
+		line := &types.ReportLine{
+			BookingID:    b.ID,
+			RoomID:       b.RoomID,
+			CountPerson:  b.CountPerson,
+			FromDate:     b.FromDate,
+			TillDate:     b.TillDate,
+			CheckedInAt:  b.CheckedInAt,
+			CheckedOutAt: b.CheckedOutAt,
+			PaymentMode:  b.PaymentMode,
+			Currency:     payment.Currency,
+		}
+		if line.PaymentMode == "" {
+			line.PaymentMode = types.PayAtHotel
+		}
+		if user, ok := users[b.UserID]; ok {
+			line.GuestName = user.FirstName + " " + user.LastName
+		}
+
+		if room, ok := rooms[b.RoomID]; ok {
+			line.RoomType = room.Type
+		}
+
+		original := b.OriginalID()
+		stay, ok := stays[original]
+		if !ok {
+			stay = []*types.Booking{b}
+		}
+		amount := int64(0)
+		for _, part := range stay {
+			if room, ok := rooms[part.RoomID]; ok {
+				amount += payment.StayAmount(room, part.FromDate, part.TillDate)
+			}
+		}
+		line.Balance = payment.Balance(amount, payments[original])
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+var csvHeader = []string{
+	"booking_id",
+	"room_id",
+	"room_type",
+	"guest_name",
+	"count_person",
+	"from_date",
+	"till_date",
+	"checked_in_at",
+	"checked_out_at",
+	"payment_mode",
+	"balance",
+	"currency",
+}
+
+// WriteCSV writes the lines with a header row, amounts in major units.
+func WriteCSV(w io.Writer, lines []*types.ReportLine) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		record := []string{
+			line.BookingID.Hex(),
+			line.RoomID.Hex(),
+			string(line.RoomType),
+			line.GuestName,
+			strconv.Itoa(line.CountPerson),
+			line.FromDate.UTC().Format(time.RFC3339),
+			line.TillDate.UTC().Format(time.RFC3339),
+			optionalTime(line.CheckedInAt),
+			optionalTime(line.CheckedOutAt),
+			string(line.PaymentMode),
+			fmt.Sprintf("%d.%02d", line.Balance/100, line.Balance%100),
+			line.Currency,
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func optionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
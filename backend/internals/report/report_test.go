@@ -0,0 +1,89 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLines(t *testing.T) {
+	from := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	room := &types.Room{ID: primitive.NewObjectID(), Type: types.KingRoomType, BasePrice: 100}
+	guest := &types.User{ID: primitive.NewObjectID(), FirstName: "Ada", LastName: "Byron"}
+
+	booking := func(mode types.PaymentMode) *types.Booking {
+		return &types.Booking{
+			ID:          primitive.NewObjectID(),
+			RoomID:      room.ID,
+			UserID:      guest.ID,
+			CountPerson: 2,
+			FromDate:    from,
+			TillDate:    from.AddDate(0, 0, 2),
+			PaymentMode: mode,
+		}
+	}
+	paid, deposit, legacy, voided := booking(types.PayNow), booking(types.PayDeposit), booking(""), booking(types.PayAtHotel)
+
+	// a split stay owes its nights in both rooms, the deposit was paid on the
+	// original booking
+	suite := &types.Room{ID: primitive.NewObjectID(), Type: types.SuiteRoomType, BasePrice: 150}
+	split, remainder := booking(types.PayDeposit), booking(types.PayDeposit)
+	split.TillDate = from.AddDate(0, 0, 1)
+	remainder.RoomID, remainder.FromDate, remainder.SplitFromID = suite.ID, split.TillDate, &split.ID
+	unpaid, unpaidRest := booking(types.PayAtHotel), booking(types.PayAtHotel)
+	unpaid.TillDate = from.AddDate(0, 0, 1)
+	unpaidRest.RoomID, unpaidRest.FromDate, unpaidRest.SplitFromID = suite.ID, unpaid.TillDate, &unpaid.ID
+
+	lines := Lines(
+		[]*types.Booking{paid, deposit, legacy, voided, remainder, unpaidRest},
+		[]*types.Booking{split, remainder, unpaid, unpaidRest},
+		map[primitive.ObjectID]*types.Room{room.ID: room, suite.ID: suite},
+		map[primitive.ObjectID]*types.User{guest.ID: guest},
+		map[primitive.ObjectID][]*types.Payment{
+			paid.ID:    {{Status: types.PaymentPartiallyRefunded, Total: 20000, Captured: 20000, Refunded: 1000}},
+			deposit.ID: {{Status: types.PaymentCaptured, Total: 20000, Captured: 4000}},
+			voided.ID:  {{Status: types.PaymentVoided, Total: 20000}},
+			split.ID:   {{Status: types.PaymentCaptured, Total: 25000, Captured: 5000}},
+		},
+	)
+
+	expected := []struct {
+		balance int64
+		mode    types.PaymentMode
+	}{
+		{0, types.PayNow},
+		{16000, types.PayDeposit},
+		{20000, types.PayAtHotel},
+		{20000, types.PayAtHotel},
+		{20000, types.PayDeposit},
+		{25000, types.PayAtHotel},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines but got %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if line.Balance != expected[i].balance || line.PaymentMode != expected[i].mode {
+			t.Errorf("line %d: expected balance %d in %s, got %d in %s",
+				i, expected[i].balance, expected[i].mode, line.Balance, line.PaymentMode)
+		}
+		if line.GuestName != "Ada Byron" || line.RoomType == "" {
+			t.Errorf("line %d: unexpected guest or room %+v", i, line)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteCSV(&out, lines[1:2]); err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(rows) != 2 || !strings.HasPrefix(rows[0], "booking_id,") {
+		t.Fatalf("expected a header and one row, got %q", out.String())
+	}
+	if !strings.HasSuffix(rows[1], ",deposit,160.00,EUR") || !strings.Contains(rows[1], ",Ada Byron,2,2025-03-10T14:00:00Z,") {
+		t.Fatalf("unexpected row %q", rows[1])
+	}
+}
@@ -1,11 +1,17 @@
 package server
 
 import (
+	"errors"
+	"log/slog"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/etag"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/tnguven/hotel-reservation-app/internals/configure"
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/middleware"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 )
 
@@ -16,20 +22,26 @@ type ErrorResponse struct {
 func NewServer(configs configure.Common) *fiber.App {
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			if response, ok := err.(*types.Error); ok {
-				return c.Status(response.Status).JSON(&response)
+			problem := middleware.Problem(err)
+			if problem.Status >= fiber.StatusInternalServerError {
+				attrs := []any{"code", problem.Code, logger.Err(err)}
+				if cause := errors.Unwrap(err); cause != nil {
+					attrs = append(attrs, "cause", cause.Error())
+				}
+				slog.ErrorContext(c.UserContext(), "request failed", attrs...)
 			}
 
-			return c.Status(fiber.StatusInternalServerError).
-				JSON(types.NewError(err, fiber.StatusInternalServerError, ""))
+			return c.Status(problem.Status).JSON(problem, types.ProblemContentType)
 		},
 	})
 
+	app.Use(middleware.WithRequestID)
+	app.Use(middleware.WithTracing)
+	app.Use(middleware.WithMetrics)
+
 	if configs.WithLog() {
 		app.Use(etag.New())
-		app.Use(logger.New(logger.Config{
-			Format: "${pid} ${status} - ${method} ${path}\n",
-		}))
+		app.Use(middleware.WithRequestLog)
 	}
 
 	if configs.GoEnv() != "production" {
@@ -40,5 +52,7 @@ func NewServer(configs configure.Common) *fiber.App {
 		}))
 	}
 
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
 	return app
 }
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetBookingFacts sums the bookings of the rooms per room and period in one
+// aggregation. The nights of a stay are counted on calendar days, a stay
+// counts at least one night.
+func (ms *MongoBookingStore) GetBookingFacts(
+	ctx context.Context,
+	roomIDs []primitive.ObjectID,
+	params *types.AnalyticsParam,
+) (_ []*types.BookingFact, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingFacts")
+	defer tracing.End(span, &err)
+
+	if len(roomIDs) == 0 {
+		return []*types.BookingFact{}, nil
+	}
+
+	var (
+		period = func(date any) bson.M {
+			trunc := bson.M{"date": date, "unit": params.Period, "timezone": "UTC"}
+			if params.Period == types.AnalyticsWeek {
+				trunc["startOfWeek"] = "monday"
+			}
+			return bson.M{"$dateTrunc": trunc}
+		}
+		day = func(date any) bson.M {
+			return bson.M{"$dateTrunc": bson.M{"date": date, "unit": "day", "timezone": "UTC"}}
+		}
+		nights = func(from, till string) bson.M {
+			return bson.M{"$max": bson.A{1, bson.M{"$dateDiff": bson.M{
+				"startDate": day(from),
+				"endDate":   day(till),
+				"unit":      "day",
+			}}}}
+		}
+		canceled = bson.M{"$eq": bson.A{"$canceled", true}}
+		inRange  = bson.M{"$gte": params.From, "$lt": params.Till}
+		// unpack flattens the group key back into the fact fields
+		unpack = bson.M{"$replaceWith": bson.M{"$mergeObjects": bson.A{"$$ROOT", "$_id"}}}
+	)
+
+	pipeline := bson.A{
+		// only the bookings one of the facets can use: the stays over the
+		// range, the arrivals in it and the bookings made during it
+		bson.M{"$match": bson.M{
+			"roomID": bson.M{"$in": roomIDs},
+			"$or": bson.A{
+				bson.M{"fromDate": bson.M{"$lt": params.Till}, "tillDate": bson.M{"$gt": params.From}},
+				bson.M{"fromDate": inRange},
+				bson.M{"_id": bson.M{
+					"$gte": primitive.NewObjectIDFromTimestamp(params.From),
+					"$lt":  primitive.NewObjectIDFromTimestamp(params.Till),
+				}},
+			},
+		}},
+		bson.M{"$set": bson.M{"nights": nights("$fromDate", "$tillDate"), "createdAt": bson.M{"$toDate": "$_id"}}},
+		// a split stay is one arrival and one booking: its remainders add their
+		// nights to the original booking
+		bson.M{"$lookup": bson.M{
+			"from":         bookingCollection,
+			"localField":   "_id",
+			"foreignField": "splitFromID",
+			"pipeline":     bson.A{bson.M{"$match": bson.M{"canceled": bson.M{"$ne": true}}}},
+			"as":           "parts",
+		}},
+		bson.M{"$set": bson.M{"stay": bson.M{"$add": bson.A{"$nights", bson.M{"$sum": bson.M{"$map": bson.M{
+			"input": "$parts",
+			"in":    nights("$$this.fromDate", "$$this.tillDate"),
+		}}}}}}},
+		bson.M{"$facet": bson.M{
+			"sold": bson.A{
+				bson.M{"$match": bson.M{
+					"canceled": bson.M{"$ne": true},
+					"fromDate": bson.M{"$lt": params.Till},
+					"tillDate": bson.M{"$gt": params.From},
+				}},
+				bson.M{"$project": bson.M{"roomID": 1, "nightlyRate": 1, "night": bson.M{"$map": bson.M{
+					"input": bson.M{"$range": bson.A{0, "$nights"}},
+					"in":    bson.M{"$dateAdd": bson.M{"startDate": day("$fromDate"), "unit": "day", "amount": "$$this"}},
+				}}}},
+				bson.M{"$unwind": "$night"},
+				bson.M{"$match": bson.M{"night": inRange}},
+				bson.M{"$group": bson.M{
+					"_id":        bson.M{"start": period("$night"), "roomID": "$roomID"},
+					"nightsSold": bson.M{"$sum": 1},
+					"revenue":    bson.M{"$sum": bson.M{"$ifNull": bson.A{"$nightlyRate", 0}}},
+					// the bookings made before rates were kept
+					"unratedNights": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$nightlyRate", 0}}, 0, 1}}},
+				}},
+				unpack,
+			},
+			"arrivals": bson.A{
+				bson.M{"$match": bson.M{"fromDate": inRange, "splitFromID": bson.M{"$exists": false}}},
+				bson.M{"$group": bson.M{
+					"_id":           bson.M{"start": period("$fromDate"), "roomID": "$roomID"},
+					"arrivals":      bson.M{"$sum": 1},
+					"cancellations": bson.M{"$sum": bson.M{"$cond": bson.A{canceled, 1, 0}}},
+					"stayNights":    bson.M{"$sum": bson.M{"$cond": bson.A{canceled, 0, "$stay"}}},
+				}},
+				unpack,
+			},
+			"pace": bson.A{
+				bson.M{"$match": bson.M{"createdAt": inRange, "splitFromID": bson.M{"$exists": false}}},
+				bson.M{"$group": bson.M{
+					"_id":          bson.M{"start": period("$createdAt"), "roomID": "$roomID"},
+					"booked":       bson.M{"$sum": 1},
+					"bookedNights": bson.M{"$sum": "$stay"},
+				}},
+				unpack,
+			},
+		}},
+	}
+
+	cur, err := ms.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var facets []struct {
+		Sold     []*types.BookingFact `bson:"sold"`
+		Arrivals []*types.BookingFact `bson:"arrivals"`
+		Pace     []*types.BookingFact `bson:"pace"`
+	}
+	if err := cur.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+
+	facts := []*types.BookingFact{}
+	for _, f := range facets {
+		facts = append(facts, f.Sold...)
+		facts = append(facts, f.Arrivals...)
+		facts = append(facts, f.Pace...)
+	}
+
+	return facts, nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCollection = "audit_log"
+
+// AuditStore is append only on purpose, do not add update or delete methods.
+// RedactUser is the one exception, the law asks to erase personal data.
+type AuditStore interface {
+	Append(context.Context, *types.AuditEntry) error
+	GetAuditEntries(context.Context, *types.GetAuditRequest) ([]*types.AuditEntry, int64, error)
+	// GetUserAuditEntries returns the entries made by the user or about it
+	GetUserAuditEntries(context.Context, primitive.ObjectID) ([]*types.AuditEntry, error)
+	// RedactUser drops the changes recorded about the user and the IP of its
+	// requests, the entries themselves stay
+	RedactUser(context.Context, primitive.ObjectID) (int64, error)
+}
+
+type MongoAuditStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoAuditStore(mongodb *repo.MongoDatabase) *MongoAuditStore {
+	return &MongoAuditStore{
+		coll: mongodb.Coll(auditCollection),
+	}
+}
+
+func (ms *MongoAuditStore) Append(ctx context.Context, entry *types.AuditEntry) (err error) {
+	ctx, span := tracing.Start(ctx, "AuditStore.Append")
+	defer tracing.End(span, &err)
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := ms.coll.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (ms *MongoAuditStore) GetAuditEntries(
+	ctx context.Context,
+	req *types.GetAuditRequest,
+) (_ []*types.AuditEntry, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "AuditStore.GetAuditEntries")
+	defer tracing.End(span, &err)
+
+	filter, err := auditFilter(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := ms.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(req.Skip).
+		SetLimit(req.Limit)
+	cur, err := ms.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []*types.AuditEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (ms *MongoAuditStore) GetUserAuditEntries(ctx context.Context, userID primitive.ObjectID) (_ []*types.AuditEntry, err error) {
+	ctx, span := tracing.Start(ctx, "AuditStore.GetUserAuditEntries")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, userAuditFilter(userID), options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*types.AuditEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (ms *MongoAuditStore) RedactUser(ctx context.Context, userID primitive.ObjectID) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "AuditStore.RedactUser")
+	defer tracing.End(span, &err)
+
+	about, err := ms.coll.UpdateMany(ctx, bson.M{
+		"targetType": types.AuditTargetUser,
+		"targetID":   userID,
+	}, bson.M{
+		"$unset": bson.M{"changes": ""},
+		"$set":   bson.M{"redacted": true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	by, err := ms.coll.UpdateMany(ctx, bson.M{"actorID": userID}, bson.M{
+		"$set": bson.M{"ip": "", "redacted": true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return about.ModifiedCount + by.ModifiedCount, nil
+}
+
+func userAuditFilter(userID primitive.ObjectID) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"actorID": userID},
+		bson.M{"targetType": types.AuditTargetUser, "targetID": userID},
+	}}
+}
+
+func auditFilter(req *types.GetAuditRequest) (bson.M, error) {
+	filter := bson.M{}
+
+	if req.ActorID != "" {
+		oid, err := objectID(req.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		filter["actorID"] = oid
+	}
+	if req.TargetID != "" {
+		oid, err := objectID(req.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		filter["targetID"] = oid
+	}
+	if req.Action != "" {
+		filter["action"] = req.Action
+	}
+	if req.TargetType != "" {
+		filter["targetType"] = req.TargetType
+	}
+
+	createdAt := bson.M{}
+	if !req.From.IsZero() {
+		createdAt["$gte"] = req.From
+	}
+	if !req.Till.IsZero() {
+		createdAt["$lt"] = req.Till
+	}
+	if len(createdAt) > 0 {
+		filter["createdAt"] = createdAt
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// overlapping matches the stays sharing at least a night with from - till.
+func overlapping(roomID primitive.ObjectID, from, till time.Time) bson.M {
+	return bson.M{
+		"roomID":   roomID,
+		"fromDate": bson.M{"$lt": till},
+		"tillDate": bson.M{"$gt": from},
+	}
+}
+
+// heldByOthers counts the live holds of other guests on the stay, the holds of
+// the guest itself never block it.
+func heldByOthers(
+	ctx context.Context,
+	holds *mongo.Collection,
+	roomID primitive.ObjectID,
+	from, till time.Time,
+	userID primitive.ObjectID,
+) (int64, error) {
+	filter := overlapping(roomID, from, till)
+	filter["userID"] = bson.M{"$ne": userID}
+	filter["expiresAt"] = bson.M{"$gt": time.Now().UTC()}
+	return holds.CountDocuments(ctx, filter)
+}
+
+// checkBlocks refuses a stay on the nights a room is out of service.
+func checkBlocks(ctx context.Context, db *mongo.Database, roomID primitive.ObjectID, from, till time.Time) error {
+	blocks, err := db.Collection(blockCollection).CountDocuments(ctx, overlapping(roomID, from, till))
+	if err != nil {
+		return err
+	}
+	if blocks > 0 {
+		return ConflictError(CodeRoomNotAvailable, "room is out of service")
+	}
+	return nil
+}
+
+// bookableRoom checks the room and its hotel are not deleted.
+func bookableRoom(ctx context.Context, rooms RoomStore, db *mongo.Database, roomID string) (*types.Room, error) {
+	room, err := rooms.GetRoomByID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	hotels, err := db.Collection(hotelCollection).CountDocuments(ctx, notDeleted(bson.M{"_id": room.HotelID}))
+	if err != nil {
+		return nil, err
+	}
+	if hotels == 0 {
+		return nil, NotFoundError(CodeRoomNotFound, "no room found with id "+roomID)
+	}
+	return room, nil
+}
+
+// freeRooms keeps the rooms without a booking, a block or a hold of another
+// guest on the stay, sorted by rate then id so the cheapest room is assigned first.
+func freeRooms(ctx context.Context, db *mongo.Database, rooms []*types.Room, params *types.FreeRoomsParam) ([]*types.Room, error) {
+	if len(rooms) == 0 {
+		return []*types.Room{}, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+	}
+	stay := bson.M{
+		"roomID":   bson.M{"$in": ids},
+		"fromDate": bson.M{"$lt": params.TillDate},
+		"tillDate": bson.M{"$gt": params.FromDate},
+	}
+
+	booked := bson.M{"canceled": bson.M{"$ne": true}}
+	for k, v := range stay {
+		booked[k] = v
+	}
+	bookedIDs, err := db.Collection(bookingCollection).Distinct(ctx, "roomID", booked)
+	if err != nil {
+		return nil, err
+	}
+
+	held := bson.M{"userID": bson.M{"$ne": params.UserID}, "expiresAt": bson.M{"$gt": time.Now().UTC()}}
+	for k, v := range stay {
+		held[k] = v
+	}
+	heldIDs, err := db.Collection(holdCollection).Distinct(ctx, "roomID", held)
+	if err != nil {
+		return nil, err
+	}
+
+	blockedIDs, err := db.Collection(blockCollection).Distinct(ctx, "roomID", stay)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := map[primitive.ObjectID]bool{}
+	for _, id := range append(append(bookedIDs, heldIDs...), blockedIDs...) {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			taken[oid] = true
+		}
+	}
+
+	free := []*types.Room{}
+	for _, room := range rooms {
+		if !taken[room.ID] {
+			free = append(free, room)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		return free[i].Rate() < free[j].Rate()
+	})
+
+	return free, nil
+}
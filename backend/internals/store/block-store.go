@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const blockCollection = "room_blocks"
+
+// BlockStore takes rooms out of service. Blocks are not versioned, a wrong
+// block is deleted and created again.
+type BlockStore interface {
+	Dropper
+
+	InsertBlock(context.Context, *types.RoomBlockParam) (*types.RoomBlock, error)
+	GetBlocksByRoomID(context.Context, string) ([]*types.RoomBlock, error)
+	// DeleteBlock returns the deleted block of the room
+	DeleteBlock(ctx context.Context, roomID, blockID string) (*types.RoomBlock, error)
+}
+
+type MongoBlockStore struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+
+	RoomStore
+}
+
+func NewMongoBlockStore(mongodb *repo.MongoDatabase, roomStore RoomStore) *MongoBlockStore {
+	return &MongoBlockStore{
+		db:   mongodb.GetDb(),
+		coll: mongodb.Coll(blockCollection),
+
+		RoomStore: roomStore,
+	}
+}
+
+func (ms *MongoBlockStore) InsertBlock(ctx context.Context, params *types.RoomBlockParam) (_ *types.RoomBlock, err error) {
+	ctx, span := tracing.Start(ctx, "BlockStore.InsertBlock")
+	defer tracing.End(span, &err)
+
+	room, err := bookableRoom(ctx, ms.RoomStore, ms.db, params.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &types.RoomBlock{
+		RoomID:    room.ID,
+		HotelID:   room.HotelID,
+		Type:      params.Type,
+		Reason:    params.Reason,
+		FromDate:  params.FromDate,
+		TillDate:  params.TillDate,
+		CreatedBy: params.CreatedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	session, err := ms.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// the guests already booked are moved first, a block never silently
+	// overbooks a stay
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		booked := overlapping(room.ID, params.FromDate, params.TillDate)
+		booked["canceled"] = bson.M{"$ne": true}
+		bookings, err := ms.db.Collection(bookingCollection).CountDocuments(sessCtx, booked)
+		if err != nil {
+			return nil, err
+		}
+		if bookings > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, fmt.Sprintf("the room has %d bookings in the range, move them first", bookings))
+		}
+
+		blocks, err := ms.coll.CountDocuments(sessCtx, overlapping(room.ID, params.FromDate, params.TillDate))
+		if err != nil {
+			return nil, err
+		}
+		if blocks > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "the room is already blocked in the range")
+		}
+
+		res, err := ms.coll.InsertOne(sessCtx, block)
+		if err != nil {
+			return nil, err
+		}
+		block.ID = res.InsertedID.(primitive.ObjectID)
+		return nil, nil
+	}, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (ms *MongoBlockStore) GetBlocksByRoomID(ctx context.Context, roomID string) (_ []*types.RoomBlock, err error) {
+	ctx, span := tracing.Start(ctx, "BlockStore.GetBlocksByRoomID")
+	defer tracing.End(span, &err)
+
+	roomOID, err := objectID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, err := ms.coll.Find(ctx, bson.M{"roomID": roomOID}, options.Find().SetSort(bson.D{{Key: "fromDate", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []*types.RoomBlock{}
+	if err := cur.All(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+func (ms *MongoBlockStore) DeleteBlock(ctx context.Context, roomID, blockID string) (_ *types.RoomBlock, err error) {
+	ctx, span := tracing.Start(ctx, "BlockStore.DeleteBlock")
+	defer tracing.End(span, &err)
+
+	roomOID, err := objectID(roomID)
+	if err != nil {
+		return nil, err
+	}
+	blockOID, err := objectID(blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	var block types.RoomBlock
+	if err := ms.coll.FindOneAndDelete(ctx, bson.M{"_id": blockOID, "roomID": roomOID}).Decode(&block); err != nil {
+		return nil, notFound(err, CodeBlockNotFound, "no block found with id "+blockID)
+	}
+
+	return &block, nil
+}
+
+func (ms *MongoBlockStore) Drop(ctx context.Context) error {
+	slog.InfoContext(ctx, "dropping collection", "collection", blockCollection)
+	return ms.coll.Drop(ctx)
+}
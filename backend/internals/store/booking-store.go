@@ -4,16 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"net/http"
-
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const bookingCollection = "bookings"
@@ -24,10 +33,40 @@ type BookingStore interface {
 	InsertBooking(context.Context, *types.BookingParam) (*types.Booking, error)
 	GetBookingsByRoomID(context.Context, *types.BookingParam) ([]*types.Booking, error)
 	GetBookingsByID(context.Context, string) (*types.Booking, error)
-	GetBookingsAsAdmin(context.Context) ([]*types.Booking, error)
+	// GetBookingByCode finds a booking by its confirmation code and the last
+	// name of its guest, for the guests without an account session
+	GetBookingByCode(ctx context.Context, code, lastName string) (*types.Booking, error)
+	// GetBookingDetail joins the documents of expand to the booking in one
+	// aggregation, the deleted ones too
+	GetBookingDetail(ctx context.Context, id string, expand []types.BookingExpand) (*types.BookingDetail, error)
+	// GetBookings pages through the bookings matching the request, it returns
+	// the count of all the matches and the cursor of the next page
+	GetBookings(context.Context, *types.GetBookingsRequest) ([]*types.Booking, int64, string, error)
 	GetBookingsAsUser(context.Context, *types.User) ([]*types.Booking, error)
-	CancelBookingByUserID(context.Context, string, primitive.ObjectID) error
-	CancelBookingByAdmin(context.Context, string) error
+	CancelBookingByUserID(context.Context, string, primitive.ObjectID) (*types.Booking, error)
+	CancelBookingByAdmin(context.Context, string) (*types.Booking, error)
+	// CancelUnpaidBooking drops a booking whose payment failed, it is no cancel
+	// made by the guest or an admin
+	CancelUnpaidBooking(context.Context, string) (*types.Booking, error)
+	// EraseUserBookings marks the bookings of an erased user, they are kept
+	// for accounting
+	EraseUserBookings(context.Context, primitive.ObjectID) (int64, error)
+	// MoveBooking moves a stay to another room of the hotel. The remainder
+	// booking is returned when the stay was split.
+	MoveBooking(context.Context, *types.MoveBookingParam) (*types.Booking, *types.Booking, error)
+	// CheckIn needs a room ready for the guest, see HousekeepingStatus.Ready
+	CheckIn(context.Context, string) (*types.Booking, error)
+	// CheckOut leaves the room dirty
+	CheckOut(context.Context, string) (*types.Booking, error)
+	// GetStayParts lists the live bookings of the stays started by the
+	// original bookings, the originals included
+	GetStayParts(context.Context, []primitive.ObjectID) ([]*types.Booking, error)
+	// GetHotelBookingsOn lists the live bookings of the report on the day,
+	// by arrival
+	GetHotelBookingsOn(ctx context.Context, hotelID string, kind types.ReportKind, day time.Time) ([]*types.Booking, error)
+	// GetBookingFacts sums the bookings of the rooms per room and period,
+	// canceled ones included
+	GetBookingFacts(context.Context, []primitive.ObjectID, *types.AnalyticsParam) ([]*types.BookingFact, error)
 }
 
 type MongoBookingStore struct {
@@ -46,11 +85,24 @@ func NewMongoBookingStore(mongodb *repo.MongoDatabase, roomStore RoomStore) *Mon
 	}
 }
 
-func (ms *MongoBookingStore) InsertBooking(ctx context.Context, params *types.BookingParam) (*types.Booking, error) {
+func (ms *MongoBookingStore) InsertBooking(ctx context.Context, params *types.BookingParam) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.InsertBooking")
+	defer tracing.End(span, &err)
+
 	booking, err := types.NewBookingFromParams(params)
 	if err != nil {
-		return nil, fmt.Errorf("NewBookingFromParams failed %w", err)
+		return nil, ValidationError(CodeInvalidID, "invalid room id "+params.RoomID, err)
+	}
+	if !booking.TillDate.After(booking.FromDate) {
+		return nil, ValidationError(CodeInvalidStayDates, "the stay must end after it starts", nil)
+	}
+
+	// deleted rooms, and the rooms of deleted hotels, can not be booked
+	room, err := bookableRoom(ctx, ms.RoomStore, ms.db, params.RoomID)
+	if err != nil {
+		return nil, err
 	}
+	booking.NightlyRate = payment.NightlyAmount(room)
 
 	// Start a session
 	session, err := ms.db.Client().StartSession()
@@ -59,20 +111,53 @@ func (ms *MongoBookingStore) InsertBooking(ctx context.Context, params *types.Bo
 	}
 	defer session.EndSession(ctx)
 
-	var ErrRoomNotAvailable = errors.New("room is not available")
-
 	// Define transaction function
+	attempt := 0
 	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
-		roomIsAvailable, err := ms.GetBookingsByRoomID(ctx, params)
+		// WithTransaction re-runs the callback on transient errors, surface every retry on the span
+		attempt++
+		if attempt > 1 {
+			span.AddEvent("transaction retry", trace.WithAttributes(attribute.Int("attempt", attempt)))
+		}
+
+		booked := overlapping(booking.RoomID, booking.FromDate, booking.TillDate)
+		booked["canceled"] = bson.M{"$ne": true}
+		count, err := ms.coll.CountDocuments(sessCtx, booked)
 		if err != nil {
 			return nil, err
 		}
-		if len(roomIsAvailable) > 0 {
-			return nil, ErrRoomNotAvailable
+		if count > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room is not available")
 		}
 
+		if params.HoldID != "" {
+			if err := ms.releaseConfirmedHold(sessCtx, params, booking); err != nil {
+				return nil, err
+			}
+		}
+		held, err := heldByOthers(sessCtx, ms.db.Collection(holdCollection), booking.RoomID, booking.FromDate, booking.TillDate, booking.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room is held by another guest")
+		}
+		if err := checkBlocks(sessCtx, ms.db, booking.RoomID, booking.FromDate, booking.TillDate); err != nil {
+			return nil, err
+		}
+
+		if booking.Code, err = types.NewConfirmationCode(); err != nil {
+			return nil, err
+		}
 		insertedBooking, err := ms.coll.InsertOne(sessCtx, booking)
 		if err != nil {
+			var serverErr mongo.ServerError
+			if errors.As(err, &serverErr) && serverErr.HasErrorLabel(driver.TransientTransactionError) {
+				span.AddEvent("transient transaction error", trace.WithAttributes(
+					attribute.Int("attempt", attempt),
+					attribute.String("error", err.Error()),
+				))
+			}
 			return nil, err
 		}
 
@@ -84,25 +169,56 @@ func (ms *MongoBookingStore) InsertBooking(ctx context.Context, params *types.Bo
 
 	// Run transaction
 	// WithTransaction will rollback if mongo returns an error
-	result, err := session.WithTransaction(
-		ctx,
-		callback,
-		txnOptions,
-	)
+	// a taken confirmation code aborts the transaction, the callback runs
+	// again with a new one
+	var result interface{}
+	err = retryCodeCollision(func() (err error) {
+		result, err = session.WithTransaction(ctx, callback, txnOptions)
+		return err
+	})
 	if err != nil {
-		log.Println("Transaction error:", err)
-		if errors.Is(err, ErrRoomNotAvailable) {
-			return nil, types.NewError(err, http.StatusConflict, "room is not abailable")
+		slog.ErrorContext(ctx, "booking transaction failed", "roomID", params.RoomID, logger.Err(err))
+		if errors.Is(err, ErrConflict) {
+			metrics.BookingConflicts.Inc()
 		}
-		return nil, types.NewError(err, http.StatusInternalServerError, "error processing booking transaction")
+		return nil, err
 	}
 
 	booking.ID = result.(*mongo.InsertOneResult).InsertedID.(primitive.ObjectID)
+	span.SetAttributes(attribute.Int("transaction.attempts", attempt))
+	metrics.BookingsCreated.Inc()
 	return booking, nil
 }
 
-func (ms *MongoBookingStore) GetBookingsByRoomID(ctx context.Context, params *types.BookingParam) ([]*types.Booking, error) {
-	roomOID, err := primitive.ObjectIDFromHex(params.RoomID)
+// releaseConfirmedHold removes the hold a booking confirms, the hold must still
+// be live and held by the same guest on the same room.
+func (ms *MongoBookingStore) releaseConfirmedHold(ctx context.Context, params *types.BookingParam, booking *types.Booking) error {
+	holdOID, err := objectID(params.HoldID)
+	if err != nil {
+		return err
+	}
+
+	res, err := ms.db.Collection(holdCollection).DeleteOne(ctx, bson.M{
+		"_id":       holdOID,
+		"userID":    booking.UserID,
+		"roomID":    booking.RoomID,
+		"expiresAt": bson.M{"$gt": time.Now().UTC()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundError(CodeHoldNotFound, "the hold "+params.HoldID+" expired or does not exist")
+	}
+
+	return nil
+}
+
+func (ms *MongoBookingStore) GetBookingsByRoomID(ctx context.Context, params *types.BookingParam) (_ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingsByRoomID")
+	defer tracing.End(span, &err)
+
+	roomOID, err := objectID(params.RoomID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,36 +240,266 @@ func (ms *MongoBookingStore) GetBookingsByRoomID(ctx context.Context, params *ty
 	return bookings, nil
 }
 
-func (ms *MongoBookingStore) GetBookingsByID(ctx context.Context, id string) (*types.Booking, error) {
-	bookingID, err := primitive.ObjectIDFromHex(id)
+func (ms *MongoBookingStore) GetBookingsByID(ctx context.Context, id string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingsByID")
+	defer tracing.End(span, &err)
+
+	bookingID, err := objectID(id)
 	if err != nil {
 		return nil, err
 	}
 
 	var booking *types.Booking
 	if err := ms.coll.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking); err != nil {
-		return nil, err
+		return nil, notFound(err, CodeBookingNotFound, "no booking found with id "+id)
 	}
 
 	return booking, nil
 }
 
-func (ms *MongoBookingStore) GetBookingsAsAdmin(ctx context.Context) ([]*types.Booking, error) {
-	// TODO add pagination
-	cur, err := ms.coll.Find(ctx, bson.M{})
+func (ms *MongoBookingStore) GetBookingDetail(
+	ctx context.Context,
+	id string,
+	expand []types.BookingExpand,
+) (_ *types.BookingDetail, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingDetail")
+	defer tracing.End(span, &err)
+
+	bookingID, err := objectID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	var bookings []*types.Booking
+	join := func(from, localField, as string) bson.A {
+		return bson.A{
+			bson.M{"$lookup": bson.M{"from": from, "localField": localField, "foreignField": "_id", "as": as}},
+			bson.M{"$unwind": bson.M{"path": "$" + as, "preserveNullAndEmptyArrays": true}},
+		}
+	}
+
+	// the room is always joined for the hotel id
+	pipeline := bson.A{bson.M{"$match": bson.M{"_id": bookingID}}}
+	pipeline = append(pipeline, join(roomCollection, "roomID", "room")...)
+	pipeline = append(pipeline, bson.M{"$set": bson.M{"hotelID": "$room.hotelID"}})
+	if slices.Contains(expand, types.ExpandHotel) {
+		pipeline = append(pipeline, join(hotelCollection, "hotelID", "hotel")...)
+	}
+	if slices.Contains(expand, types.ExpandUser) {
+		pipeline = append(pipeline, join(userCollection, "userID", "user")...)
+	}
+	if !slices.Contains(expand, types.ExpandRoom) {
+		pipeline = append(pipeline, bson.M{"$unset": "room"})
+	}
+
+	cur, err := ms.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var details []*types.BookingDetail
+	if err := cur.All(ctx, &details); err != nil {
+		return nil, err
+	}
+	if len(details) == 0 {
+		return nil, NotFoundError(CodeBookingNotFound, "no booking found with id "+id)
+	}
+
+	return details[0], nil
+}
+
+func (ms *MongoBookingStore) GetBookings(
+	ctx context.Context,
+	req *types.GetBookingsRequest,
+) (_ []*types.Booking, _ int64, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookings")
+	defer tracing.End(span, &err)
+
+	filter, err := ms.bookingsFilter(ctx, req)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if filter == nil {
+		return []*types.Booking{}, 0, "", nil
+	}
+
+	total, err := ms.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	field, order := bookingsSort(req.Sort)
+	if req.LastID != "" {
+		after, err := ms.bookingsAfter(ctx, req.LastID, field, order)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(req.Limit)
+	cur, err := ms.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	bookings := []*types.Booking{}
 	if err := cur.All(ctx, &bookings); err != nil {
+		return nil, 0, "", err
+	}
+
+	var lastID string
+	if len(bookings) > 0 {
+		lastID = bookings[len(bookings)-1].ID.Hex()
+	}
+
+	return bookings, total, lastID, nil
+}
+
+// bookingsFilter turns the request into a filter, nil when nothing can match
+// like an email no user has.
+func (ms *MongoBookingStore) bookingsFilter(ctx context.Context, req *types.GetBookingsRequest) (bson.M, error) {
+	filter := bson.M{}
+
+	if req.UserID != "" {
+		oid, err := objectID(req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		filter["userID"] = oid
+	}
+	if req.Email != "" {
+		// deleted users included, their bookings are still listed
+		var user types.User
+		err := ms.db.Collection(userCollection).FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if userID, ok := filter["userID"]; ok && userID != user.ID {
+			return nil, nil
+		}
+		filter["userID"] = user.ID
+	}
+
+	var rooms bson.A
+	if req.HotelID != "" {
+		hotelID, err := objectID(req.HotelID)
+		if err != nil {
+			return nil, err
+		}
+		if rooms, err = ms.db.Collection(roomCollection).Distinct(ctx, "_id", bson.M{"hotelID": hotelID}); err != nil {
+			return nil, err
+		}
+		if len(rooms) == 0 {
+			return nil, nil
+		}
+	}
+	if req.RoomID != "" {
+		roomID, err := objectID(req.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		if rooms != nil && !slices.Contains(rooms, any(roomID)) {
+			return nil, nil
+		}
+		rooms = bson.A{roomID}
+	}
+	if rooms != nil {
+		filter["roomID"] = bson.M{"$in": rooms}
+	}
+
+	switch req.Status {
+	case types.BookingConfirmed:
+		filter["canceled"] = bson.M{"$ne": true}
+		filter["checkedInAt"] = bson.M{"$exists": false}
+	case types.BookingCheckedIn:
+		filter["canceled"] = bson.M{"$ne": true}
+		filter["checkedInAt"] = bson.M{"$exists": true}
+		filter["checkedOutAt"] = bson.M{"$exists": false}
+	case types.BookingCheckedOut:
+		filter["checkedOutAt"] = bson.M{"$exists": true}
+	case types.BookingCanceled:
+		filter["canceled"] = true
+	}
+
+	if !req.FromDate.IsZero() {
+		filter["tillDate"] = bson.M{"$gt": req.FromDate}
+	}
+	if !req.TillDate.IsZero() {
+		filter["fromDate"] = bson.M{"$lt": req.TillDate}
+	}
+
+	// the ids carry the creation time
+	created := bson.M{}
+	if !req.CreatedFrom.IsZero() {
+		created["$gte"] = primitive.NewObjectIDFromTimestamp(req.CreatedFrom)
+	}
+	if !req.CreatedTill.IsZero() {
+		created["$lt"] = primitive.NewObjectIDFromTimestamp(req.CreatedTill)
+	}
+	if len(created) > 0 {
+		filter["_id"] = created
+	}
+
+	return filter, nil
+}
+
+// bookingsSort maps the sort of the request on a field and an order, the
+// newest bookings first by default.
+func bookingsSort(sort string) (string, int) {
+	order := 1
+	if strings.HasPrefix(sort, "-") {
+		order, sort = -1, sort[1:]
+	}
+
+	switch sort {
+	case "fromDate", "tillDate":
+		return sort, order
+	case "createdAt":
+		return "_id", order
+	default:
+		return "_id", -1
+	}
+}
+
+// bookingsAfter keeps the bookings sorted after lastID, ties on the field are
+// broken by id.
+func (ms *MongoBookingStore) bookingsAfter(ctx context.Context, lastID, field string, order int) (bson.M, error) {
+	oid, err := objectID(lastID)
+	if err != nil {
+		return nil, ValidationError(CodeInvalidID, "invalid lastID "+lastID, err)
+	}
+
+	op := "$gt"
+	if order < 0 {
+		op = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{op: oid}}, nil
+	}
+
+	var last bson.M
+	err = ms.coll.FindOne(ctx, bson.M{"_id": oid}, options.FindOne().SetProjection(bson.M{field: 1})).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ValidationError(CodeInvalidID, "no booking found with lastID "+lastID, err)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	return bookings, nil
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: last[field]}},
+		bson.M{field: last[field], "_id": bson.M{op: oid}},
+	}}, nil
 }
 
-func (ms *MongoBookingStore) GetBookingsAsUser(ctx context.Context, user *types.User) ([]*types.Booking, error) {
+func (ms *MongoBookingStore) GetBookingsAsUser(ctx context.Context, user *types.User) (_ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingsAsUser")
+	defer tracing.End(span, &err)
+
 	cur, err := ms.coll.Find(ctx, bson.M{"userID": user.ID})
 	if err != nil {
 		return nil, err
@@ -167,49 +513,461 @@ func (ms *MongoBookingStore) GetBookingsAsUser(ctx context.Context, user *types.
 	return bookings, nil
 }
 
-func (ms *MongoBookingStore) CancelBookingByUserID(ctx context.Context, bookingId string, userId primitive.ObjectID) error {
-	bookingOID, err := primitive.ObjectIDFromHex(bookingId)
+func (ms *MongoBookingStore) CancelBookingByUserID(
+	ctx context.Context,
+	bookingId string,
+	userId primitive.ObjectID,
+) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.CancelBookingByUserID")
+	defer tracing.End(span, &err)
+
+	bookingOID, err := objectID(bookingId)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	booking, err := ms.cancel(ctx, bson.M{"_id": bookingOID, "userID": userId}, bookingId)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BookingsCanceled.WithLabelValues(metrics.CanceledByUser).Inc()
+
+	return booking, nil
+}
+
+func (ms *MongoBookingStore) CancelBookingByAdmin(ctx context.Context, bookingId string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.CancelBookingByAdmin")
+	defer tracing.End(span, &err)
+
+	bookingOID, err := objectID(bookingId)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := ms.cancel(ctx, bson.M{"_id": bookingOID}, bookingId)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BookingsCanceled.WithLabelValues(metrics.CanceledByAdmin).Inc()
+
+	return booking, nil
+}
+
+func (ms *MongoBookingStore) CancelUnpaidBooking(ctx context.Context, bookingId string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.CancelUnpaidBooking")
+	defer tracing.End(span, &err)
+
+	bookingOID, err := objectID(bookingId)
+	if err != nil {
+		return nil, err
 	}
+	booking, err := ms.cancel(ctx, bson.M{"_id": bookingOID}, bookingId)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BookingsCanceled.WithLabelValues(metrics.CanceledUnpaid).Inc()
+
+	return booking, nil
+}
+
+// cancel bumps the version, so the update always modifies the document: an
+// already canceled booking is excluded by the filter instead. The canceled
+// booking is returned.
+func (ms *MongoBookingStore) cancel(ctx context.Context, filter bson.M, bookingId string) (*types.Booking, error) {
 	param := types.CancelBookingParam{Canceled: true}
-	resp, err := ms.coll.UpdateOne(ctx, bson.M{"_id": bookingOID, "userID": userId}, bson.M{
+	update := bson.M{
 		"$set": param.ToBsonMap(),
+		"$inc": bson.M{"version": 1},
+	}
+
+	active := bson.M{"canceled": bson.M{"$ne": true}}
+	for k, v := range filter {
+		active[k] = v
+	}
+
+	var booking types.Booking
+	err := ms.coll.FindOneAndUpdate(ctx, active, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&booking)
+	if err == nil {
+		return &booking, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	count, err := ms.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, NotFoundError(CodeBookingNotFound, "no booking found with id "+bookingId)
+	}
+	return nil, ConflictError(CodeBookingAlreadyCanceled, "booking is already canceled")
+}
+
+func (ms *MongoBookingStore) EraseUserBookings(ctx context.Context, userID primitive.ObjectID) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.EraseUserBookings")
+	defer tracing.End(span, &err)
+
+	// a booking holds no personal field beside the user id, which now points
+	// at the anonymized user: unset here any personal field added later
+	res, err := ms.coll.UpdateMany(ctx, bson.M{
+		"userID":   userID,
+		"erasedAt": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"erasedAt": time.Now().UTC()},
+		"$inc": bson.M{"version": 1},
 	})
 	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (ms *MongoBookingStore) MoveBooking(
+	ctx context.Context,
+	params *types.MoveBookingParam,
+) (_ *types.Booking, _ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.MoveBooking")
+	defer tracing.End(span, &err)
+
+	bookingOID, err := objectID(params.BookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := bookableRoom(ctx, ms.RoomStore, ms.db, params.RoomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := ms.db.Client().StartSession()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var moved, remainder *types.Booking
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		moved, remainder = nil, nil
+
+		var booking types.Booking
+		if err := ms.coll.FindOne(sessCtx, versionFilter(bookingOID, params.Version)).Decode(&booking); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, staleOrMissing(sessCtx, ms.coll, bookingOID, CodeBookingNotFound, "no booking found with id "+params.BookingID)
+			}
+			return nil, err
+		}
+		if booking.Canceled {
+			return nil, ConflictError(CodeBookingAlreadyCanceled, "a canceled booking can not be moved")
+		}
+		if booking.CheckedOutAt != nil {
+			return nil, ConflictError(CodeInvalidStayState, "the guest already checked out")
+		}
+
+		date := params.Date
+		if date.IsZero() {
+			date = booking.FromDate
+		}
+		if date.Before(booking.FromDate) || !date.Before(booking.TillDate) {
+			return nil, ValidationError(CodeInvalidMove, "the move date must fall within the stay", nil)
+		}
+		if target.ID == booking.RoomID {
+			return nil, ValidationError(CodeInvalidMove, "the booking is already in room "+params.RoomID, nil)
+		}
+
+		// the current room may be deleted since, it still tells the hotel
+		var current types.Room
+		if err := ms.db.Collection(roomCollection).FindOne(sessCtx, bson.M{"_id": booking.RoomID}).Decode(&current); err != nil {
+			return nil, err
+		}
+		if current.HotelID != target.HotelID {
+			return nil, ValidationError(CodeInvalidMove, "a booking moves within its hotel only", nil)
+		}
+
+		booked := overlapping(target.ID, date, booking.TillDate)
+		booked["canceled"] = bson.M{"$ne": true}
+		count, err := ms.coll.CountDocuments(sessCtx, booked)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room "+params.RoomID+" is not available")
+		}
+		held, err := heldByOthers(sessCtx, ms.db.Collection(holdCollection), target.ID, date, booking.TillDate, booking.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room "+params.RoomID+" is held by another guest")
+		}
+		if err := checkBlocks(sessCtx, ms.db, target.ID, date, booking.TillDate); err != nil {
+			return nil, err
+		}
+
+		move := types.RoomMove{
+			FromRoomID: booking.RoomID,
+			ToRoomID:   target.ID,
+			Date:       date,
+			Reason:     params.Reason,
+			MovedBy:    params.MovedBy,
+			MovedAt:    time.Now().UTC(),
+		}
+		set := bson.M{"roomID": target.ID}
+
+		if date.After(booking.FromDate) {
+			// the remainder gets a code of its own, the code of the original
+			// keeps finding the original and its moves
+			code, err := types.NewConfirmationCode()
+			if err != nil {
+				return nil, err
+			}
+			// a remainder split again still points to the original booking
+			original := booking.OriginalID()
+			remainder = &types.Booking{
+				ID:          primitive.NewObjectID(),
+				Code:        code,
+				RoomID:      target.ID,
+				UserID:      booking.UserID,
+				CountPerson: booking.CountPerson,
+				FromDate:    date,
+				TillDate:    booking.TillDate,
+				PaymentMode: booking.PaymentMode,
+				// the stay keeps the rate it was booked at
+				NightlyRate: booking.NightlyRate,
+				Version:     types.InitialVersion,
+				GroupID:     booking.GroupID,
+				SplitFromID: &original,
+				Moves:       []types.RoomMove{move},
+				CheckedInAt: booking.CheckedInAt,
+			}
+			if _, err := ms.coll.InsertOne(sessCtx, remainder); err != nil {
+				return nil, err
+			}
+			move.SplitBookingID = &remainder.ID
+			set = bson.M{"tillDate": date}
+			// a guest in house carries the check-in over to the remainder and
+			// leaves the original stay at the move date
+			if booking.CheckedInAt != nil {
+				set["checkedOutAt"] = date
+			}
+		}
+		if booking.CheckedInAt != nil {
+			_, err := ms.db.Collection(roomCollection).UpdateOne(sessCtx, bson.M{"_id": booking.RoomID}, bson.M{
+				"$set": bson.M{"housekeeping": types.HousekeepingDirty, "housekeepingAt": time.Now().UTC()},
+				"$inc": bson.M{"version": 1},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		moved = &types.Booking{}
+		err = ms.coll.FindOneAndUpdate(sessCtx, bson.M{"_id": booking.ID}, bson.M{
+			"$set":  set,
+			"$push": bson.M{"moves": move},
+			"$inc":  bson.M{"version": 1},
+		}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(moved)
+		return nil, err
+	}
+	// a split draws a confirmation code, a taken one runs the move again
+	err = retryCodeCollision(func() error {
+		_, err := session.WithTransaction(ctx, callback, options.Transaction().SetWriteConcern(writeconcern.Majority()))
 		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "booking move transaction failed", "bookingID", params.BookingID, logger.Err(err))
+		return nil, nil, err
 	}
-	if resp.MatchedCount == 0 {
-		return fmt.Errorf("booking not found")
+
+	return moved, remainder, nil
+}
+
+func (ms *MongoBookingStore) CheckIn(ctx context.Context, bookingID string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.CheckIn")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(bookingID)
+	if err != nil {
+		return nil, err
 	}
-	if resp.ModifiedCount == 0 {
-		return fmt.Errorf("booking already canceled")
+
+	session, err := ms.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction %w", err)
 	}
+	defer session.EndSession(ctx)
 
-	return nil
+	var booking types.Booking
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if err := ms.coll.FindOne(sessCtx, bson.M{"_id": oid}).Decode(&booking); err != nil {
+			return nil, notFound(err, CodeBookingNotFound, "no booking found with id "+bookingID)
+		}
+		from := booking.FromDate
+		arrival := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+		switch {
+		case booking.Canceled:
+			return nil, ConflictError(CodeBookingAlreadyCanceled, "a canceled booking can not check in")
+		case booking.CheckedInAt != nil:
+			return nil, ConflictError(CodeInvalidStayState, "the guest already checked in")
+		case time.Now().Before(arrival):
+			return nil, ConflictError(CodeInvalidStayState, "the stay starts on "+from.Format(time.DateOnly))
+		case !time.Now().Before(booking.TillDate):
+			return nil, ConflictError(CodeInvalidStayState, "the stay is over")
+		}
+
+		var room types.Room
+		if err := ms.db.Collection(roomCollection).FindOne(sessCtx, bson.M{"_id": booking.RoomID}).Decode(&room); err != nil {
+			return nil, notFound(err, CodeRoomNotFound, "no room found with id "+booking.RoomID.Hex())
+		}
+		if !room.Housekeeping.Ready() {
+			return nil, ConflictError(CodeRoomNotReady, "the room is "+string(room.Housekeeping)+", it must be clean to check in")
+		}
+
+		return nil, ms.coll.FindOneAndUpdate(sessCtx, bson.M{"_id": oid}, bson.M{
+			"$set": bson.M{"checkedInAt": time.Now().UTC()},
+			"$inc": bson.M{"version": 1},
+		}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&booking)
+	}, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
 }
 
-func (ms *MongoBookingStore) CancelBookingByAdmin(ctx context.Context, bookingId string) error {
-	bookingOID, err := primitive.ObjectIDFromHex(bookingId)
+func (ms *MongoBookingStore) CheckOut(ctx context.Context, bookingID string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.CheckOut")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(bookingID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	param := types.CancelBookingParam{Canceled: true}
-	resp, err := ms.coll.UpdateOne(ctx, bson.M{"_id": bookingOID}, bson.M{"$set": param.ToBsonMap()})
+
+	session, err := ms.db.Client().StartSession()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error starting transaction %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var booking types.Booking
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now().UTC()
+		err := ms.coll.FindOneAndUpdate(sessCtx, bson.M{
+			"_id":          oid,
+			"checkedInAt":  bson.M{"$exists": true},
+			"checkedOutAt": bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{"checkedOutAt": now},
+			"$inc": bson.M{"version": 1},
+		}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&booking)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			count, err := ms.coll.CountDocuments(sessCtx, bson.M{"_id": oid})
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				return nil, NotFoundError(CodeBookingNotFound, "no booking found with id "+bookingID)
+			}
+			return nil, ConflictError(CodeInvalidStayState, "the guest is not checked in")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = ms.db.Collection(roomCollection).UpdateOne(sessCtx, bson.M{"_id": booking.RoomID}, bson.M{
+			"$set": bson.M{"housekeeping": types.HousekeepingDirty, "housekeepingAt": now},
+			"$inc": bson.M{"version": 1},
+		})
+		return nil, err
+	}, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+	if err != nil {
+		return nil, err
 	}
-	if resp.MatchedCount == 0 {
-		return fmt.Errorf("booking not found")
+
+	return &booking, nil
+}
+
+func (ms *MongoBookingStore) GetStayParts(ctx context.Context, originalIDs []primitive.ObjectID) (_ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetStayParts")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, bson.M{
+		"canceled": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"_id": bson.M{"$in": originalIDs}},
+			bson.M{"splitFromID": bson.M{"$in": originalIDs}},
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
-	if resp.ModifiedCount == 0 {
-		return fmt.Errorf("booking already canceled")
+
+	bookings := []*types.Booking{}
+	if err := cur.All(ctx, &bookings); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return bookings, nil
+}
+
+func (ms *MongoBookingStore) GetHotelBookingsOn(
+	ctx context.Context,
+	hotelID string,
+	kind types.ReportKind,
+	day time.Time,
+) (_ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetHotelBookingsOn")
+	defer tracing.End(span, &err)
+
+	hotelOID, err := objectID(hotelID)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs, err := ms.db.Collection(roomCollection).Distinct(ctx, "_id", bson.M{"hotelID": hotelOID})
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	filter := bson.M{
+		"roomID":   bson.M{"$in": roomIDs},
+		"canceled": bson.M{"$ne": true},
+	}
+	switch kind {
+	// a split stay arrives with the original booking and leaves with the
+	// last remainder, the guest does not come and go at the move
+	case types.ReportArrivals:
+		filter["fromDate"] = bson.M{"$gte": start, "$lt": end}
+		filter["splitFromID"] = bson.M{"$exists": false}
+	case types.ReportDepartures:
+		filter["tillDate"] = bson.M{"$gte": start, "$lt": end}
+		filter["moves.splitBookingID"] = bson.M{"$exists": false}
+	case types.ReportInHouse:
+		// a guest who checked out early, a move included, left the room
+		filter["fromDate"] = bson.M{"$lt": end}
+		filter["tillDate"] = bson.M{"$gte": end}
+		filter["checkedOutAt"] = nil
+	default:
+		return nil, ValidationError(CodeInvalidReport, "unknown report "+string(kind), nil)
+	}
+
+	cur, err := ms.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "fromDate", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	bookings := []*types.Booking{}
+	if err := cur.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
 }
 
 func (ms *MongoBookingStore) Drop(ctx context.Context) error {
-	log.Printf("dropping %s collection", bookingCollection)
+	slog.InfoContext(ctx, "dropping collection", "collection", bookingCollection)
 	return ms.coll.Drop(ctx)
 }
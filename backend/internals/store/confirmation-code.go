@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// codeAttempts bounds the inserts retried on a taken confirmation code, with
+// 32^8 codes a second collision in a row is already unlikely
+const codeAttempts = 5
+
+// retryCodeCollision runs insert again while the unique index on the
+// confirmation codes refuses its code. insert draws new codes on each run.
+func retryCodeCollision(insert func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := insert()
+		if attempt == codeAttempts || !isCodeCollision(err) {
+			return err
+		}
+	}
+}
+
+const (
+	// codeIndex is the unique index on bookings.code, created by db.CreateIndexes
+	codeIndex        = "code_1"
+	duplicateKeyCode = 11000
+)
+
+// isCodeCollision tells a code drawn twice from the other duplicate keys, by
+// the index the server names in the write error.
+func isCodeCollision(err error) bool {
+	var writeErrors []mongo.WriteError
+	var we mongo.WriteException
+	var bwe mongo.BulkWriteException
+	switch {
+	case errors.As(err, &we):
+		writeErrors = we.WriteErrors
+	case errors.As(err, &bwe):
+		for _, e := range bwe.WriteErrors {
+			writeErrors = append(writeErrors, e.WriteError)
+		}
+	}
+
+	for _, e := range writeErrors {
+		if e.Code == duplicateKeyCode && strings.Contains(e.Message, "index: "+codeIndex+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBookingByCode finds the booking of the code when lastName is the guest's,
+// in any case. A wrong name is answered like an unknown code.
+func (ms *MongoBookingStore) GetBookingByCode(ctx context.Context, code, lastName string) (_ *types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "BookingStore.GetBookingByCode")
+	defer tracing.End(span, &err)
+
+	missing := NotFoundError(CodeBookingNotFound, "no booking found for this code and name")
+
+	code, ok := types.NormalizeConfirmationCode(code)
+	if !ok {
+		return nil, missing
+	}
+
+	var booking types.Booking
+	if err := ms.coll.FindOne(ctx, bson.M{"code": code}).Decode(&booking); err != nil {
+		return nil, notFound(err, CodeBookingNotFound, "no booking found for this code and name")
+	}
+
+	// erased guests keep no name to match
+	var user types.User
+	err = ms.db.Collection(userCollection).FindOne(ctx, bson.M{"_id": booking.UserID, "erasedAt": bson.M{"$exists": false}}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, missing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(lastName), user.LastName) {
+		return nil, missing
+	}
+
+	return &booking, nil
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Kinds of domain errors, match them with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+	// ErrPreconditionFailed reports a write based on a stale version
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Stable codes sent to the api clients, never rename them.
+const (
+	CodeInvalidID              = "invalid_id"
+	CodeUserNotFound           = "user_not_found"
+	CodeUserErased             = "user_erased"
+	CodeEmailTaken             = "email_taken"
+	CodeHotelNotFound          = "hotel_not_found"
+	CodeRoomNotFound           = "room_not_found"
+	CodeRoomNotAvailable       = "room_not_available"
+	CodeBookingNotFound        = "booking_not_found"
+	CodeBookingAlreadyCanceled = "booking_already_canceled"
+	CodeVersionMismatch        = "version_mismatch"
+	CodePaymentNotFound        = "payment_not_found"
+	CodeHoldNotFound           = "hold_not_found"
+	CodeGroupNotFound          = "group_not_found"
+	CodeInvalidMove            = "invalid_move"
+	CodeBlockNotFound          = "block_not_found"
+	CodeRoomNotReady           = "room_not_ready"
+	CodeInvalidStayState       = "invalid_stay_state"
+	CodeInvalidReport          = "invalid_report"
+	CodeInvalidStayDates       = "invalid_stay_dates"
+)
+
+// Error is a failure the caller can act on. Msg is safe to show to the client,
+// Err keeps the driver error for the logs only.
+type Error struct {
+	Kind error
+	Code string
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+	return e.Msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFoundError(code, msg string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Msg: msg}
+}
+
+func ConflictError(code, msg string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Msg: msg}
+}
+
+func ForbiddenError(code, msg string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Msg: msg}
+}
+
+func PreconditionFailedError(code, msg string) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: code, Msg: msg}
+}
+
+func ValidationError(code, msg string, err error) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Msg: msg, Err: err}
+}
+
+func objectID(id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, ValidationError(CodeInvalidID, "invalid id "+id, err)
+	}
+	return oid, nil
+}
+
+// notFound turns mongo.ErrNoDocuments into a typed not found error.
+func notFound(err error, code, msg string) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &Error{Kind: ErrNotFound, Code: code, Msg: msg, Err: err}
+	}
+	return err
+}
+
+// versionFilter matches the expected version, documents written before the
+// version field existed count as version 0.
+func versionFilter(id primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": id, "version": version}
+}
+
+// staleOrMissing tells apart a missing document from a version mismatch once
+// a conditional update matched nothing.
+func staleOrMissing(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, notFoundCode, msg string) error {
+	count, err := coll.CountDocuments(ctx, notDeleted(bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return NotFoundError(notFoundCode, msg)
+	}
+	return PreconditionFailedError(CodeVersionMismatch, "the resource was modified, fetch it again to get the current ETag")
+}
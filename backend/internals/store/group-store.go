@@ -0,0 +1,328 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/logger"
+	"github.com/tnguven/hotel-reservation-app/internals/metrics"
+	"github.com/tnguven/hotel-reservation-app/internals/payment"
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const groupCollection = "groups"
+
+type GroupBookingStore interface {
+	Dropper
+
+	// InsertGroupBooking books every room of the group or none of them
+	InsertGroupBooking(context.Context, *types.GroupBookingParam) (*types.GroupBooking, []*types.Booking, error)
+	GetGroupBookingByID(context.Context, string) (*types.GroupBooking, error)
+	GetGroupBookingsByUserID(context.Context, primitive.ObjectID) ([]*types.GroupBooking, error)
+	GetGroupBookings(context.Context, primitive.ObjectID) ([]*types.Booking, error)
+	// EraseUserGroupBookings anonymizes the lead guest of the groups booked by
+	// an erased user
+	EraseUserGroupBookings(context.Context, primitive.ObjectID) (int64, error)
+}
+
+type MongoGroupBookingStore struct {
+	db       *mongo.Database
+	coll     *mongo.Collection
+	bookings *mongo.Collection
+	rooms    *mongo.Collection
+	holds    *mongo.Collection
+}
+
+func NewMongoGroupBookingStore(mongodb *repo.MongoDatabase) *MongoGroupBookingStore {
+	return &MongoGroupBookingStore{
+		db:       mongodb.GetDb(),
+		coll:     mongodb.Coll(groupCollection),
+		bookings: mongodb.Coll(bookingCollection),
+		rooms:    mongodb.Coll(roomCollection),
+		holds:    mongodb.Coll(holdCollection),
+	}
+}
+
+// groupRoom is a room picked for the group with the party staying in it.
+type groupRoom struct {
+	roomID      primitive.ObjectID
+	countPerson int
+	nightlyRate int64
+}
+
+func (ms *MongoGroupBookingStore) InsertGroupBooking(
+	ctx context.Context,
+	params *types.GroupBookingParam,
+) (_ *types.GroupBooking, _ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingStore.InsertGroupBooking")
+	defer tracing.End(span, &err)
+
+	hotelOID, err := objectID(params.HotelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	hotels, err := ms.db.Collection(hotelCollection).CountDocuments(ctx, notDeleted(bson.M{"_id": hotelOID}))
+	if err != nil {
+		return nil, nil, err
+	}
+	if hotels == 0 {
+		return nil, nil, NotFoundError(CodeHotelNotFound, "no hotel found with id "+params.HotelID)
+	}
+
+	reference, err := types.NewGroupReference()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := ms.db.Client().StartSession()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error starting transaction %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var (
+		group    *types.GroupBooking
+		bookings []*types.Booking
+	)
+	// the callback can run again on transient errors, it rebuilds everything
+	callback := func(sessCtx mongo.SessionContext) (interface{}, error) {
+		rooms, err := ms.pickRooms(sessCtx, hotelOID, params)
+		if err != nil {
+			return nil, err
+		}
+
+		groupID := primitive.NewObjectID()
+		bookings = make([]*types.Booking, len(rooms))
+		docs := make([]interface{}, len(rooms))
+		for i, room := range rooms {
+			code, err := types.NewConfirmationCode()
+			if err != nil {
+				return nil, err
+			}
+			bookings[i] = &types.Booking{
+				ID:          primitive.NewObjectID(),
+				Code:        code,
+				RoomID:      room.roomID,
+				UserID:      params.UserID,
+				CountPerson: room.countPerson,
+				FromDate:    params.FromDate,
+				TillDate:    params.TillDate,
+				PaymentMode: types.PayAtHotel,
+				NightlyRate: room.nightlyRate,
+				Version:     types.InitialVersion,
+				GroupID:     &groupID,
+			}
+			docs[i] = bookings[i]
+		}
+		if _, err := ms.bookings.InsertMany(sessCtx, docs); err != nil {
+			return nil, err
+		}
+
+		group = &types.GroupBooking{
+			ID:         groupID,
+			Reference:  reference,
+			HotelID:    hotelOID,
+			UserID:     params.UserID,
+			LeadGuest:  params.LeadGuest,
+			FromDate:   params.FromDate,
+			TillDate:   params.TillDate,
+			BookingIDs: make([]primitive.ObjectID, len(bookings)),
+			CreatedAt:  time.Now().UTC(),
+		}
+		for i, booking := range bookings {
+			group.BookingIDs[i] = booking.ID
+		}
+		return ms.coll.InsertOne(sessCtx, group)
+	}
+	// every booking draws a confirmation code, a taken one runs it all again
+	err = retryCodeCollision(func() error {
+		_, err := session.WithTransaction(ctx, callback, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+		return err
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "group booking transaction failed", "hotelID", params.HotelID, logger.Err(err))
+		return nil, nil, err
+	}
+
+	metrics.BookingsCreated.Add(float64(len(bookings)))
+	return group, bookings, nil
+}
+
+// pickRooms resolves the rooms asked by id or by type, every room must be free
+// for the whole stay.
+func (ms *MongoGroupBookingStore) pickRooms(
+	ctx context.Context,
+	hotelID primitive.ObjectID,
+	params *types.GroupBookingParam,
+) ([]groupRoom, error) {
+	picked := map[primitive.ObjectID]bool{}
+	rooms := []groupRoom{}
+
+	for _, req := range params.Rooms {
+		if req.RoomID != "" {
+			roomOID, err := objectID(req.RoomID)
+			if err != nil {
+				return nil, err
+			}
+			var room types.Room
+			err = ms.rooms.FindOne(ctx, notDeleted(bson.M{"_id": roomOID, "hotelID": hotelID})).Decode(&room)
+			if err != nil {
+				return nil, notFound(err, CodeRoomNotFound, "no room found with id "+req.RoomID+" in the hotel")
+			}
+			if picked[roomOID] {
+				return nil, ConflictError(CodeRoomNotAvailable, "room "+req.RoomID+" is asked twice")
+			}
+			free, err := ms.free(ctx, roomOID, params)
+			if err != nil {
+				return nil, err
+			}
+			if !free {
+				return nil, ConflictError(CodeRoomNotAvailable, "room "+req.RoomID+" is not available")
+			}
+			picked[roomOID] = true
+			rooms = append(rooms, groupRoom{roomID: roomOID, countPerson: req.CountPerson, nightlyRate: payment.NightlyAmount(&room)})
+			continue
+		}
+
+		cur, err := ms.rooms.Find(ctx, notDeleted(bson.M{"hotelID": hotelID, "type": req.Type}),
+			options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetProjection(bson.M{"_id": 1, "price": 1, "basePrice": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var candidates []*types.Room
+		if err := cur.All(ctx, &candidates); err != nil {
+			return nil, err
+		}
+
+		found := 0
+		for _, candidate := range candidates {
+			if found == req.Count {
+				break
+			}
+			if picked[candidate.ID] {
+				continue
+			}
+			free, err := ms.free(ctx, candidate.ID, params)
+			if err != nil {
+				return nil, err
+			}
+			if !free {
+				continue
+			}
+			picked[candidate.ID] = true
+			rooms = append(rooms, groupRoom{roomID: candidate.ID, countPerson: req.CountPerson, nightlyRate: payment.NightlyAmount(candidate)})
+			found++
+		}
+		if found < req.Count {
+			return nil, ConflictError(CodeRoomNotAvailable, fmt.Sprintf("only %d %s rooms are available, %d asked", found, req.Type, req.Count))
+		}
+	}
+
+	return rooms, nil
+}
+
+func (ms *MongoGroupBookingStore) free(ctx context.Context, roomID primitive.ObjectID, params *types.GroupBookingParam) (bool, error) {
+	booked := overlapping(roomID, params.FromDate, params.TillDate)
+	booked["canceled"] = bson.M{"$ne": true}
+	bookings, err := ms.bookings.CountDocuments(ctx, booked)
+	if err != nil || bookings > 0 {
+		return false, err
+	}
+
+	held, err := heldByOthers(ctx, ms.holds, roomID, params.FromDate, params.TillDate, params.UserID)
+	if err != nil || held > 0 {
+		return false, err
+	}
+
+	blocks, err := ms.db.Collection(blockCollection).CountDocuments(ctx, overlapping(roomID, params.FromDate, params.TillDate))
+	return blocks == 0, err
+}
+
+func (ms *MongoGroupBookingStore) GetGroupBookingByID(ctx context.Context, id string) (_ *types.GroupBooking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingStore.GetGroupBookingByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var group types.GroupBooking
+	if err := ms.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&group); err != nil {
+		return nil, notFound(err, CodeGroupNotFound, "no group booking found with id "+id)
+	}
+
+	return &group, nil
+}
+
+func (ms *MongoGroupBookingStore) GetGroupBookingsByUserID(ctx context.Context, userID primitive.ObjectID) (_ []*types.GroupBooking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingStore.GetGroupBookingsByUserID")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, bson.M{"userID": userID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*types.GroupBooking{}
+	if err := cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (ms *MongoGroupBookingStore) GetGroupBookings(ctx context.Context, groupID primitive.ObjectID) (_ []*types.Booking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingStore.GetGroupBookings")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.bookings.Find(ctx, bson.M{"groupID": groupID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	bookings := []*types.Booking{}
+	if err := cur.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+func (ms *MongoGroupBookingStore) EraseUserGroupBookings(ctx context.Context, userID primitive.ObjectID) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingStore.EraseUserGroupBookings")
+	defer tracing.End(span, &err)
+
+	res, err := ms.coll.UpdateMany(ctx, bson.M{
+		"userID":   userID,
+		"erasedAt": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{
+			"leadGuest": types.LeadGuest{
+				FirstName: types.ErasedFirstName,
+				LastName:  types.ErasedLastName,
+				Email:     types.ErasedEmail(userID),
+			},
+			"erasedAt": time.Now().UTC(),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+func (ms *MongoGroupBookingStore) Drop(ctx context.Context) error {
+	slog.InfoContext(ctx, "dropping collection", "collection", groupCollection)
+	return ms.coll.Drop(ctx)
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const holdCollection = "holds"
+
+// HoldStore reserves rooms during checkout. Holds are never updated, a hold is
+// either confirmed into a booking by BookingStore.InsertBooking, released, or
+// left to expire.
+type HoldStore interface {
+	Dropper
+
+	InsertHold(context.Context, *types.HoldParam) (*types.Hold, error)
+	// GetHoldByID only returns live holds
+	GetHoldByID(context.Context, string) (*types.Hold, error)
+	ReleaseHold(ctx context.Context, id string, userID primitive.ObjectID) error
+}
+
+type MongoHoldStore struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+
+	RoomStore
+}
+
+func NewMongoHoldStore(mongodb *repo.MongoDatabase, roomStore RoomStore) *MongoHoldStore {
+	return &MongoHoldStore{
+		db:   mongodb.GetDb(),
+		coll: mongodb.Coll(holdCollection),
+
+		RoomStore: roomStore,
+	}
+}
+
+func (ms *MongoHoldStore) InsertHold(ctx context.Context, params *types.HoldParam) (_ *types.Hold, err error) {
+	ctx, span := tracing.Start(ctx, "HoldStore.InsertHold")
+	defer tracing.End(span, &err)
+
+	room, err := bookableRoom(ctx, ms.RoomStore, ms.db, params.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := params.Minutes
+	if minutes == 0 {
+		minutes = types.DefaultHoldMinutes
+	}
+	now := time.Now().UTC()
+	hold := &types.Hold{
+		RoomID:      room.ID,
+		UserID:      params.UserID,
+		CountPerson: params.CountPerson,
+		FromDate:    params.FromDate,
+		TillDate:    params.TillDate,
+		ExpiresAt:   now.Add(time.Duration(minutes) * time.Minute),
+		CreatedAt:   now,
+	}
+
+	session, err := ms.db.Client().StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	// the same checks as a booking, so a hold never covers a booked night
+	result, err := session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		booked := overlapping(hold.RoomID, hold.FromDate, hold.TillDate)
+		booked["canceled"] = bson.M{"$ne": true}
+		bookings, err := ms.db.Collection(bookingCollection).CountDocuments(sessCtx, booked)
+		if err != nil {
+			return nil, err
+		}
+		if bookings > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room is not available")
+		}
+
+		held, err := heldByOthers(sessCtx, ms.coll, hold.RoomID, hold.FromDate, hold.TillDate, hold.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if held > 0 {
+			return nil, ConflictError(CodeRoomNotAvailable, "room is held by another guest")
+		}
+		if err := checkBlocks(sessCtx, ms.db, hold.RoomID, hold.FromDate, hold.TillDate); err != nil {
+			return nil, err
+		}
+
+		return ms.coll.InsertOne(sessCtx, hold)
+	}, options.Transaction().SetWriteConcern(writeconcern.Majority()))
+	if err != nil {
+		return nil, err
+	}
+
+	hold.ID = result.(*mongo.InsertOneResult).InsertedID.(primitive.ObjectID)
+	return hold, nil
+}
+
+func (ms *MongoHoldStore) GetHoldByID(ctx context.Context, id string) (_ *types.Hold, err error) {
+	ctx, span := tracing.Start(ctx, "HoldStore.GetHoldByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var hold types.Hold
+	err = ms.coll.FindOne(ctx, bson.M{"_id": oid, "expiresAt": bson.M{"$gt": time.Now().UTC()}}).Decode(&hold)
+	if err != nil {
+		return nil, notFound(err, CodeHoldNotFound, "the hold "+id+" expired or does not exist")
+	}
+
+	return &hold, nil
+}
+
+func (ms *MongoHoldStore) ReleaseHold(ctx context.Context, id string, userID primitive.ObjectID) (err error) {
+	ctx, span := tracing.Start(ctx, "HoldStore.ReleaseHold")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := ms.coll.DeleteOne(ctx, bson.M{"_id": oid, "userID": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundError(CodeHoldNotFound, "the hold "+id+" expired or does not exist")
+	}
+
+	return nil
+}
+
+func (ms *MongoHoldStore) Drop(ctx context.Context) error {
+	slog.InfoContext(ctx, "dropping collection", "collection", holdCollection)
+	return ms.coll.Drop(ctx)
+}
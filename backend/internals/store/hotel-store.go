@@ -2,14 +2,17 @@ package store
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const hotelCollection = "hotels"
@@ -19,8 +22,15 @@ type HotelStore interface {
 
 	InsertHotel(context.Context, *types.Hotel) (*types.Hotel, error)
 	PutHotel(context.Context, *types.UpdateHotelParams, *primitive.ObjectID) error
+	// UpdateHotel applies the update only when the hotel is still at version
+	UpdateHotel(ctx context.Context, hotelID string, params *types.UpdateHotelParams, version int64) (*types.Hotel, error)
 	GetHotels(context.Context, *types.GetHotelsRequest) ([]*types.Hotel, int64, error)
 	GetHotelByID(context.Context, string) (*types.Hotel, error)
+	// DeleteHotel is a soft delete, the rooms of the hotel are left as they are
+	DeleteHotel(context.Context, string) error
+	GetDeletedHotels(context.Context, *types.QueryNumericPaginate) ([]*types.Hotel, int64, error)
+	RestoreHotel(context.Context, string) (*types.Hotel, error)
+	PurgeDeletedHotels(ctx context.Context, before time.Time) (int64, error)
 }
 
 type MongoHotelStore struct {
@@ -35,11 +45,14 @@ func NewMongoHotelStore(mongodb *repo.MongoDatabase) *MongoHotelStore {
 	}
 }
 
-func (ms *MongoHotelStore) GetHotels(ctx context.Context, qParams *types.GetHotelsRequest) ([]*types.Hotel, int64, error) {
+func (ms *MongoHotelStore) GetHotels(ctx context.Context, qParams *types.GetHotelsRequest) (_ []*types.Hotel, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.GetHotels")
+	defer tracing.End(span, &err)
+
 	pipeline := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: bson.D{
-			{Key: "rating", Value: bson.M{"$gte": qParams.Rating}},
-		}}},
+		bson.D{{Key: "$match", Value: notDeleted(bson.M{
+			"rating": bson.M{"$gte": qParams.Rating},
+		})}},
 		bson.D{{Key: "$facet", Value: bson.D{
 			{Key: "data", Value: bson.A{
 				bson.D{{Key: "$skip", Value: &qParams.Page}},
@@ -77,22 +90,29 @@ func (ms *MongoHotelStore) GetHotels(ctx context.Context, qParams *types.GetHote
 	return aggResult[0].Data, aggResult[0].TotalCount, nil
 }
 
-func (ms *MongoHotelStore) GetHotelByID(ctx context.Context, hotelID string) (*types.Hotel, error) {
-	oid, err := primitive.ObjectIDFromHex(hotelID)
+func (ms *MongoHotelStore) GetHotelByID(ctx context.Context, hotelID string) (_ *types.Hotel, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.GetHotelByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(hotelID)
 	if err != nil {
 		return nil, err
 	}
 
 	var hotel types.Hotel
 
-	if err := ms.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&hotel); err != nil {
-		return nil, err
+	if err := ms.coll.FindOne(ctx, notDeleted(bson.M{"_id": oid})).Decode(&hotel); err != nil {
+		return nil, notFound(err, CodeHotelNotFound, "no hotel found with id "+hotelID)
 	}
 
 	return &hotel, nil
 }
 
-func (ms *MongoHotelStore) InsertHotel(ctx context.Context, hotel *types.Hotel) (*types.Hotel, error) {
+func (ms *MongoHotelStore) InsertHotel(ctx context.Context, hotel *types.Hotel) (_ *types.Hotel, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.InsertHotel")
+	defer tracing.End(span, &err)
+
+	hotel.Version = types.InitialVersion
 	resp, err := ms.coll.InsertOne(ctx, hotel)
 	if err != nil {
 		return nil, err
@@ -106,20 +126,87 @@ func (ms *MongoHotelStore) PutHotel(
 	ctx context.Context,
 	params *types.UpdateHotelParams,
 	hotelId *primitive.ObjectID,
-) error {
+) (err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.PutHotel")
+	defer tracing.End(span, &err)
+
 	result, err := ms.coll.UpdateOne(ctx, bson.M{"_id": hotelId}, params.ToBsonMap())
 	if err != nil {
 		return err
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("no hotel found with id %s", hotelId)
+		return NotFoundError(CodeHotelNotFound, "no hotel found with id "+hotelId.Hex())
 	}
 
 	return nil
 }
 
+func (ms *MongoHotelStore) UpdateHotel(
+	ctx context.Context,
+	hotelID string,
+	params *types.UpdateHotelParams,
+	version int64,
+) (_ *types.Hotel, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.UpdateHotel")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(hotelID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hotel types.Hotel
+	err = ms.coll.FindOneAndUpdate(
+		ctx,
+		notDeleted(versionFilter(oid, version)),
+		params.ToBsonMap(),
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&hotel)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, staleOrMissing(ctx, ms.coll, oid, CodeHotelNotFound, "no hotel found with id "+hotelID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &hotel, nil
+}
+
+func (ms *MongoHotelStore) DeleteHotel(ctx context.Context, hotelID string) (err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.DeleteHotel")
+	defer tracing.End(span, &err)
+
+	return softDelete(ctx, ms.coll, hotelID, CodeHotelNotFound, "no hotel found with id "+hotelID)
+}
+
+func (ms *MongoHotelStore) GetDeletedHotels(
+	ctx context.Context,
+	pagination *types.QueryNumericPaginate,
+) (_ []*types.Hotel, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.GetDeletedHotels")
+	defer tracing.End(span, &err)
+
+	return findDeleted[types.Hotel](ctx, ms.coll, pagination)
+}
+
+func (ms *MongoHotelStore) RestoreHotel(ctx context.Context, hotelID string) (_ *types.Hotel, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.RestoreHotel")
+	defer tracing.End(span, &err)
+
+	return restore[types.Hotel](ctx, ms.coll, hotelID, CodeHotelNotFound, "no deleted hotel found with id "+hotelID)
+}
+
+// PurgeDeletedHotels keeps the hotels that still have rooms, purge the rooms
+// first.
+func (ms *MongoHotelStore) PurgeDeletedHotels(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "HotelStore.PurgeDeletedHotels")
+	defer tracing.End(span, &err)
+
+	return purgeDeleted(ctx, ms.coll, before, ms.db.Collection(roomCollection), "hotelID")
+}
+
 func (ms *MongoHotelStore) Drop(ctx context.Context) error {
-	log.Printf("dropping %s collection", hotelCollection)
+	slog.InfoContext(ctx, "dropping collection", "collection", hotelCollection)
 	return ms.coll.Drop(ctx)
 }
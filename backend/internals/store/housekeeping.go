@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetHousekeeping records the cleaning state of a room. It is not version
+// checked, the last housekeeper to report wins.
+func (ms *MongoRoomStore) SetHousekeeping(
+	ctx context.Context,
+	roomID string,
+	status types.HousekeepingStatus,
+) (_ *types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.SetHousekeeping")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var room types.Room
+	err = ms.coll.FindOneAndUpdate(ctx, notDeleted(bson.M{"_id": oid}), bson.M{
+		"$set": bson.M{"housekeeping": status, "housekeepingAt": time.Now().UTC()},
+		"$inc": bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&room)
+	if err != nil {
+		return nil, notFound(err, CodeRoomNotFound, "no room found with id "+roomID)
+	}
+
+	return &room, nil
+}
+
+// GetHousekeepingTasks lists the rooms of the hotel to prepare on the day: the
+// rooms a guest arrives in, by arrival time, then the other rooms that are not
+// ready.
+func (ms *MongoRoomStore) GetHousekeepingTasks(
+	ctx context.Context,
+	hotelID string,
+	day time.Time,
+) (_ []*types.HousekeepingTask, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetHousekeepingTasks")
+	defer tracing.End(span, &err)
+
+	rooms, err := ms.hotelRooms(ctx, &types.FreeRoomsParam{HotelID: hotelID})
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return []*types.HousekeepingTask{}, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(rooms))
+	tasks := make(map[primitive.ObjectID]*types.HousekeepingTask, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+		tasks[room.ID] = &types.HousekeepingTask{
+			RoomID:       room.ID,
+			RoomType:     room.Type,
+			Housekeeping: room.Housekeeping,
+		}
+		if tasks[room.ID].Housekeeping == "" {
+			tasks[room.ID].Housekeeping = types.HousekeepingClean
+		}
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	cur, err := ms.db.Collection(bookingCollection).Find(ctx, bson.M{
+		"roomID":   bson.M{"$in": ids},
+		"canceled": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"fromDate": bson.M{"$gte": start, "$lt": end}, "checkedInAt": bson.M{"$exists": false}},
+			bson.M{"tillDate": bson.M{"$gte": start, "$lt": end}, "checkedOutAt": bson.M{"$exists": false}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var bookings []*types.Booking
+	if err := cur.All(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	for _, booking := range bookings {
+		task := tasks[booking.RoomID]
+		if booking.CheckedInAt == nil && !booking.FromDate.Before(start) && booking.FromDate.Before(end) {
+			if task.Arrival == nil || booking.FromDate.Before(*task.Arrival) {
+				arrival, bookingID := booking.FromDate, booking.ID
+				task.Arrival, task.BookingID = &arrival, &bookingID
+			}
+		}
+		if booking.CheckedOutAt == nil && !booking.TillDate.Before(start) && booking.TillDate.Before(end) {
+			task.Departure = true
+		}
+	}
+
+	list := []*types.HousekeepingTask{}
+	for _, id := range ids {
+		task := tasks[id]
+		if task.Arrival != nil || task.Departure || !task.Housekeeping.Ready() {
+			list = append(list, task)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].Arrival, list[j].Arrival
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+
+	return list, nil
+}
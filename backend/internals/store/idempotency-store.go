@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyCollection = "idempotency_keys"
+
+type IdempotencyStore interface {
+	// Reserve claims the key for a request, when the key is already known the
+	// existing record is returned with reserved set to false.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (record *types.IdempotencyRecord, reserved bool, err error)
+	Complete(ctx context.Context, key string, status int, body []byte, contentType string) error
+	Release(ctx context.Context, key string) error
+}
+
+// MongoIdempotencyStore keeps the records until the TTL index on expireAt
+// created in db.CreateIndexes removes them.
+type MongoIdempotencyStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoIdempotencyStore(mongodb *repo.MongoDatabase) *MongoIdempotencyStore {
+	return &MongoIdempotencyStore{
+		coll: mongodb.Coll(idempotencyCollection),
+	}
+}
+
+func (ms *MongoIdempotencyStore) Reserve(
+	ctx context.Context,
+	key, requestHash string,
+	ttl time.Duration,
+) (_ *types.IdempotencyRecord, _ bool, err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyStore.Reserve")
+	defer tracing.End(span, &err)
+
+	now := time.Now()
+	record := types.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		State:       types.IdempotencyProcessing,
+		CreatedAt:   now,
+		ExpireAt:    now.Add(ttl),
+	}
+
+	// two concurrent upserts of the same _id can both miss, the loser gets a
+	// duplicate key error and reads the winner's record on the second try
+	for attempt := 0; ; attempt++ {
+		var existing types.IdempotencyRecord
+		err = ms.coll.FindOneAndUpdate(
+			ctx,
+			bson.M{"_id": key},
+			bson.M{"$setOnInsert": record},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).Decode(&existing)
+
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return &record, true, nil
+		case err == nil:
+			return &existing, false, nil
+		case mongo.IsDuplicateKeyError(err) && attempt == 0:
+			continue
+		default:
+			return nil, false, err
+		}
+	}
+}
+
+func (ms *MongoIdempotencyStore) Complete(ctx context.Context, key string, status int, body []byte, contentType string) (err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyStore.Complete")
+	defer tracing.End(span, &err)
+
+	_, err = ms.coll.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"state":          types.IdempotencyCompleted,
+		"responseStatus": status,
+		"responseBody":   body,
+		"contentType":    contentType,
+	}})
+	return err
+}
+
+func (ms *MongoIdempotencyStore) Release(ctx context.Context, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyStore.Release")
+	defer tracing.End(span, &err)
+
+	_, err = ms.coll.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const paymentCollection = "payments"
+
+type PaymentStore interface {
+	Dropper
+
+	InsertPayment(context.Context, *types.Payment) (*types.Payment, error)
+	GetPaymentByID(context.Context, string) (*types.Payment, error)
+	GetPaymentsByBookingID(context.Context, primitive.ObjectID) ([]*types.Payment, error)
+	GetPaymentsByBookingIDs(context.Context, []primitive.ObjectID) ([]*types.Payment, error)
+	// UpdatePayment writes the status and the amounts of the payment when its
+	// version still matches, the version is bumped on the given payment
+	UpdatePayment(context.Context, *types.Payment) error
+}
+
+type MongoPaymentStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoPaymentStore(mongodb *repo.MongoDatabase) *MongoPaymentStore {
+	return &MongoPaymentStore{
+		coll: mongodb.Coll(paymentCollection),
+	}
+}
+
+func (ms *MongoPaymentStore) InsertPayment(ctx context.Context, payment *types.Payment) (_ *types.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.InsertPayment")
+	defer tracing.End(span, &err)
+
+	now := time.Now().UTC()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	payment.Version = types.InitialVersion
+
+	res, err := ms.coll.InsertOne(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.ID = res.InsertedID.(primitive.ObjectID)
+	return payment, nil
+}
+
+func (ms *MongoPaymentStore) GetPaymentByID(ctx context.Context, id string) (_ *types.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment types.Payment
+	if err := ms.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&payment); err != nil {
+		return nil, notFound(err, CodePaymentNotFound, "no payment found with id "+id)
+	}
+
+	return &payment, nil
+}
+
+func (ms *MongoPaymentStore) GetPaymentsByBookingID(ctx context.Context, bookingID primitive.ObjectID) (_ []*types.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentsByBookingID")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, bson.M{"bookingID": bookingID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []*types.Payment{}
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (ms *MongoPaymentStore) GetPaymentsByBookingIDs(ctx context.Context, bookingIDs []primitive.ObjectID) (_ []*types.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.GetPaymentsByBookingIDs")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, bson.M{"bookingID": bson.M{"$in": bookingIDs}}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []*types.Payment{}
+	if err := cur.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (ms *MongoPaymentStore) UpdatePayment(ctx context.Context, payment *types.Payment) (err error) {
+	ctx, span := tracing.Start(ctx, "PaymentStore.UpdatePayment")
+	defer tracing.End(span, &err)
+
+	now := time.Now().UTC()
+	res, err := ms.coll.UpdateOne(ctx, versionFilter(payment.ID, payment.Version), bson.M{
+		"$set": bson.M{
+			"status":      payment.Status,
+			"captured":    payment.Captured,
+			"refunded":    payment.Refunded,
+			"providerRef": payment.ProviderRef,
+			"updatedAt":   now,
+		},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return staleOrMissing(ctx, ms.coll, payment.ID, CodePaymentNotFound, "no payment found with id "+payment.ID.Hex())
+	}
+
+	payment.UpdatedAt = now
+	payment.Version++
+	return nil
+}
+
+func (ms *MongoPaymentStore) Drop(ctx context.Context) error {
+	slog.InfoContext(ctx, "dropping collection", "collection", paymentCollection)
+	return ms.coll.Drop(ctx)
+}
@@ -2,14 +2,16 @@ package store
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const roomCollection = "rooms"
@@ -20,6 +22,22 @@ type RoomStore interface {
 	InsertRoom(context.Context, *types.Room) (*types.Room, error)
 	GetRoomsByHotelID(context.Context, string) ([]*types.Room, error)
 	GetRooms(context.Context, *types.GetRoomsRequest) ([]*types.Room, int64, string, error)
+	GetRoomByID(context.Context, string) (*types.Room, error)
+	// GetRoomsByIDs includes the deleted rooms, their bookings still show up
+	GetRoomsByIDs(context.Context, []primitive.ObjectID) ([]*types.Room, error)
+	// GetRoomIDsByHotelID includes the deleted rooms too
+	GetRoomIDsByHotelID(context.Context, string) ([]primitive.ObjectID, error)
+	// DeleteRoom is a soft delete, the bookings of the room are kept
+	DeleteRoom(context.Context, string) error
+	GetDeletedRooms(context.Context, *types.QueryNumericPaginate) ([]*types.Room, int64, error)
+	RestoreRoom(context.Context, string) (*types.Room, error)
+	PurgeDeletedRooms(ctx context.Context, before time.Time) (int64, error)
+	// GetFreeRooms lists the rooms free for the stay, cheapest first
+	GetFreeRooms(context.Context, *types.FreeRoomsParam) ([]*types.Room, error)
+	// GetTypeAvailability counts the free rooms of each type of the hotel
+	GetTypeAvailability(context.Context, *types.FreeRoomsParam) ([]*types.TypeAvailability, error)
+	SetHousekeeping(context.Context, string, types.HousekeepingStatus) (*types.Room, error)
+	GetHousekeepingTasks(ctx context.Context, hotelID string, day time.Time) ([]*types.HousekeepingTask, error)
 }
 
 type MongoRoomStore struct {
@@ -38,7 +56,11 @@ func NewMongoRoomStore(db *repo.MongoDatabase, hotelStore HotelStore) *MongoRoom
 	}
 }
 
-func (ms *MongoRoomStore) InsertRoom(ctx context.Context, room *types.Room) (*types.Room, error) {
+func (ms *MongoRoomStore) InsertRoom(ctx context.Context, room *types.Room) (_ *types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.InsertRoom")
+	defer tracing.End(span, &err)
+
+	room.Version = types.InitialVersion
 	resp, err := ms.coll.InsertOne(ctx, room)
 	if err != nil {
 		return nil, err
@@ -53,13 +75,16 @@ func (ms *MongoRoomStore) InsertRoom(ctx context.Context, room *types.Room) (*ty
 	return room, nil
 }
 
-func (ms *MongoRoomStore) GetRoomsByHotelID(ctx context.Context, hotelID string) ([]*types.Room, error) {
-	oid, err := primitive.ObjectIDFromHex(hotelID)
+func (ms *MongoRoomStore) GetRoomsByHotelID(ctx context.Context, hotelID string) (_ []*types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetRoomsByHotelID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(hotelID)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := ms.coll.Find(ctx, bson.M{"hotelID": oid})
+	resp, err := ms.coll.Find(ctx, notDeleted(bson.M{"hotelID": oid}))
 	if err != nil {
 		return nil, err
 	}
@@ -71,19 +96,21 @@ func (ms *MongoRoomStore) GetRoomsByHotelID(ctx context.Context, hotelID string)
 	return rooms, nil
 }
 
-func (ms *MongoRoomStore) GetRooms(ctx context.Context, qParams *types.GetRoomsRequest) ([]*types.Room, int64, string, error) {
+func (ms *MongoRoomStore) GetRooms(ctx context.Context, qParams *types.GetRoomsRequest) (_ []*types.Room, _ int64, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetRooms")
+	defer tracing.End(span, &err)
+
 	now := time.Now()
-	pipeline := mongo.Pipeline{}
+	match := notDeleted(bson.M{})
 	if qParams.LastID != "" {
 		lastObjID, err := qParams.GetLastID()
 		if err != nil {
-			return nil, 0, "", err
+			return nil, 0, "", ValidationError(CodeInvalidID, "invalid lastID "+qParams.LastID, err)
 		}
-		pipeline = append(pipeline, bson.D{
-			{Key: "$match", Value: bson.D{
-				{Key: "_id", Value: bson.D{{Key: "$gt", Value: lastObjID}}},
-			}},
-		})
+		match["_id"] = bson.M{"$gt": lastObjID}
+	}
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
 	}
 
 	// Join bookings with rooms.
@@ -140,6 +167,43 @@ func (ms *MongoRoomStore) GetRooms(ctx context.Context, qParams *types.GetRoomsR
 		}},
 	})
 
+	// A block in force (fromDate <= now < tillDate) wins over the bookings.
+	pipeline = append(pipeline,
+		bson.D{
+			{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: blockCollection},
+				{Key: "localField", Value: "_id"},
+				{Key: "foreignField", Value: "roomID"},
+				{Key: "as", Value: "blocks"},
+			}},
+		},
+		bson.D{
+			{Key: "$addFields", Value: bson.D{
+				{Key: "status", Value: bson.D{
+					{Key: "$cond", Value: bson.A{
+						bson.D{{Key: "$gt", Value: bson.A{
+							bson.D{{Key: "$size", Value: bson.D{
+								{Key: "$filter", Value: bson.D{
+									{Key: "input", Value: "$blocks"},
+									{Key: "as", Value: "b"},
+									{Key: "cond", Value: bson.D{
+										{Key: "$and", Value: bson.A{
+											bson.D{{Key: "$lte", Value: bson.A{"$$b.fromDate", now}}},
+											bson.D{{Key: "$gt", Value: bson.A{"$$b.tillDate", now}}},
+										}},
+									}},
+								}},
+							}}},
+							0,
+						}}},
+						string(types.BlockedRoom),
+						"$status",
+					}},
+				}},
+			}},
+		},
+	)
+
 	// Optionally add a status filter.
 	if len(qParams.Status) > 0 {
 		pipeline = append(pipeline, bson.D{
@@ -199,7 +263,176 @@ func (ms *MongoRoomStore) GetRooms(ctx context.Context, qParams *types.GetRoomsR
 	return rooms, total, newLastID, nil
 }
 
+func (ms *MongoRoomStore) GetRoomByID(ctx context.Context, roomID string) (_ *types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetRoomByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var room types.Room
+	if err := ms.coll.FindOne(ctx, notDeleted(bson.M{"_id": oid})).Decode(&room); err != nil {
+		return nil, notFound(err, CodeRoomNotFound, "no room found with id "+roomID)
+	}
+
+	return &room, nil
+}
+
+func (ms *MongoRoomStore) GetRoomsByIDs(ctx context.Context, ids []primitive.ObjectID) (_ []*types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetRoomsByIDs")
+	defer tracing.End(span, &err)
+
+	cur, err := ms.coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := []*types.Room{}
+	if err := cur.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+func (ms *MongoRoomStore) GetRoomIDsByHotelID(ctx context.Context, hotelID string) (_ []primitive.ObjectID, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetRoomIDsByHotelID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(hotelID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := ms.coll.Distinct(ctx, "_id", bson.M{"hotelID": oid})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (ms *MongoRoomStore) DeleteRoom(ctx context.Context, roomID string) (err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.DeleteRoom")
+	defer tracing.End(span, &err)
+
+	return softDelete(ctx, ms.coll, roomID, CodeRoomNotFound, "no room found with id "+roomID)
+}
+
+func (ms *MongoRoomStore) GetDeletedRooms(
+	ctx context.Context,
+	pagination *types.QueryNumericPaginate,
+) (_ []*types.Room, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetDeletedRooms")
+	defer tracing.End(span, &err)
+
+	return findDeleted[types.Room](ctx, ms.coll, pagination)
+}
+
+func (ms *MongoRoomStore) RestoreRoom(ctx context.Context, roomID string) (_ *types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.RestoreRoom")
+	defer tracing.End(span, &err)
+
+	return restore[types.Room](ctx, ms.coll, roomID, CodeRoomNotFound, "no deleted room found with id "+roomID)
+}
+
+// PurgeDeletedRooms keeps the rooms that still have bookings.
+func (ms *MongoRoomStore) PurgeDeletedRooms(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.PurgeDeletedRooms")
+	defer tracing.End(span, &err)
+
+	return purgeDeleted(ctx, ms.coll, before, ms.db.Collection(bookingCollection), "roomID")
+}
+
+func (ms *MongoRoomStore) GetFreeRooms(ctx context.Context, params *types.FreeRoomsParam) (_ []*types.Room, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetFreeRooms")
+	defer tracing.End(span, &err)
+
+	rooms, err := ms.hotelRooms(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return freeRooms(ctx, ms.db, rooms, params)
+}
+
+func (ms *MongoRoomStore) GetTypeAvailability(
+	ctx context.Context,
+	params *types.FreeRoomsParam,
+) (_ []*types.TypeAvailability, err error) {
+	ctx, span := tracing.Start(ctx, "RoomStore.GetTypeAvailability")
+	defer tracing.End(span, &err)
+
+	rooms, err := ms.hotelRooms(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	free, err := freeRooms(ctx, ms.db, rooms, params)
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[types.RoomType]*types.TypeAvailability{}
+	availability := []*types.TypeAvailability{}
+	for _, room := range rooms {
+		if _, ok := byType[room.Type]; !ok {
+			byType[room.Type] = &types.TypeAvailability{Type: room.Type}
+			availability = append(availability, byType[room.Type])
+		}
+		byType[room.Type].Total++
+	}
+	// free rooms come cheapest first, the first of a type has its lowest rate
+	for _, room := range free {
+		typed := byType[room.Type]
+		if typed.Available == 0 {
+			typed.FromRate = room.Rate()
+		}
+		typed.Available++
+	}
+
+	return availability, nil
+}
+
+// hotelRooms lists the rooms of a live hotel, of the asked type if any.
+func (ms *MongoRoomStore) hotelRooms(ctx context.Context, params *types.FreeRoomsParam) ([]*types.Room, error) {
+	hotelOID, err := objectID(params.HotelID)
+	if err != nil {
+		return nil, err
+	}
+	hotels, err := ms.db.Collection(hotelCollection).CountDocuments(ctx, notDeleted(bson.M{"_id": hotelOID}))
+	if err != nil {
+		return nil, err
+	}
+	if hotels == 0 {
+		return nil, NotFoundError(CodeHotelNotFound, "no hotel found with id "+params.HotelID)
+	}
+
+	filter := bson.M{"hotelID": hotelOID}
+	if params.Type != "" {
+		filter["type"] = params.Type
+	}
+	cur, err := ms.coll.Find(ctx, notDeleted(filter), options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := []*types.Room{}
+	if err := cur.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
 func (ms *MongoRoomStore) Drop(ctx context.Context) error {
-	log.Printf("dropping %s collection", roomCollection)
+	slog.InfoContext(ctx, "dropping collection", "collection", roomCollection)
 	return ms.coll.Drop(ctx)
 }
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/tnguven/hotel-reservation-app/internals/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Users, hotels and rooms are soft deleted: deletedAt is set and the default
+// queries leave them out until they are restored or purged.
+
+// notDeleted restricts a filter to the documents that are not soft deleted.
+func notDeleted(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+func onlyDeleted(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": true}
+	return filter
+}
+
+func softDelete(ctx context.Context, coll *mongo.Collection, id string, notFoundCode, msg string) error {
+	oid, err := objectID(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := coll.UpdateOne(ctx, notDeleted(bson.M{"_id": oid}), bson.M{
+		"$set": bson.M{"deletedAt": time.Now().UTC()},
+		"$inc": bson.M{"version": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundError(notFoundCode, msg)
+	}
+
+	return nil
+}
+
+func restore[T any](ctx context.Context, coll *mongo.Collection, id string, notFoundCode, msg string) (*T, error) {
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// an erased document lost its personal data, it stays deleted
+	filter := onlyDeleted(bson.M{"_id": oid, "erasedAt": bson.M{"$exists": false}})
+
+	var doc T
+	err = coll.FindOneAndUpdate(ctx, filter, bson.M{
+		"$unset": bson.M{"deletedAt": ""},
+		"$inc":   bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&doc)
+	if err != nil {
+		return nil, notFound(err, notFoundCode, msg)
+	}
+
+	return &doc, nil
+}
+
+func findDeleted[T any](ctx context.Context, coll *mongo.Collection, pagination *types.QueryNumericPaginate) ([]*T, int64, error) {
+	filter := onlyDeleted(bson.M{})
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cur, err := coll.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "deletedAt", Value: -1}}).
+		SetSkip(pagination.Skip).
+		SetLimit(pagination.Limit))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	docs := []*T{}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+
+	return docs, total, nil
+}
+
+// purgeDeleted removes for good the documents soft deleted before the cutoff.
+// Documents still referenced by refField in refColl are kept, so a purge never
+// leaves bookings or rooms pointing at nothing.
+func purgeDeleted(ctx context.Context, coll *mongo.Collection, before time.Time, refColl *mongo.Collection, refField string) (int64, error) {
+	cur, err := coll.Find(ctx, bson.M{"deletedAt": bson.M{"$lt": before}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	referenced, err := refColl.Distinct(ctx, refField, bson.M{refField: bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	if referenced == nil {
+		referenced = bson.A{}
+	}
+
+	res, err := coll.DeleteMany(ctx, bson.M{
+		"_id":       bson.M{"$in": ids, "$nin": referenced},
+		"deletedAt": bson.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
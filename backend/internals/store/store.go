@@ -5,4 +5,11 @@ type Stores struct {
 	Room    RoomStore
 	User    UserStore
 	Booking BookingStore
+	Payment PaymentStore
+	Hold    HoldStore
+	Group   GroupBookingStore
+	Block   BlockStore
+
+	Idempotency IdempotencyStore
+	Audit       AuditStore
 }
@@ -2,13 +2,17 @@ package store
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/tnguven/hotel-reservation-app/internals/repo"
+	"github.com/tnguven/hotel-reservation-app/internals/tracing"
 	"github.com/tnguven/hotel-reservation-app/internals/types"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const userCollection = "users"
@@ -17,11 +21,25 @@ type UserStore interface {
 	Dropper
 
 	GetByID(context.Context, string) (*types.User, error)
+	// GetUsersByIDs includes the deleted users, their bookings still show up
+	GetUsersByIDs(context.Context, []primitive.ObjectID) ([]*types.User, error)
 	GetUserByEmail(context.Context, string) (*types.User, error)
 	GetUsers(context.Context, *types.QueryNumericPaginate) ([]*types.User, int64, error)
 	InsertUser(context.Context, *types.User) (*types.User, error)
+	// DeleteUser is a soft delete, the user keeps its bookings and its email
+	// until PurgeDeletedUsers removes it
 	DeleteUser(context.Context, string) error
-	PutUser(context.Context, *types.UpdateUserParams, string) (int64, error)
+	// PutUser applies the update only when the user is still at version
+	PutUser(ctx context.Context, params *types.UpdateUserParams, id string, version int64) (*types.User, error)
+	// SetRoles replaces the roles and hotels of the user when it is still at
+	// version
+	SetRoles(ctx context.Context, id string, params *types.SetRolesParams, version int64) (*types.User, error)
+	GetDeletedUsers(context.Context, *types.QueryNumericPaginate) ([]*types.User, int64, error)
+	RestoreUser(context.Context, string) (*types.User, error)
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+	// EraseUser anonymizes the personal fields and soft deletes the user, the
+	// id stays so the bookings still add up
+	EraseUser(context.Context, string) (*types.User, error)
 }
 
 type MongoUserStore struct {
@@ -36,36 +54,62 @@ func NewMongoUserStore(mongodb *repo.MongoDatabase) *MongoUserStore {
 	}
 }
 
-func (ms *MongoUserStore) GetByID(ctx context.Context, id string) (*types.User, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+func (ms *MongoUserStore) GetByID(ctx context.Context, id string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetByID")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
 	if err != nil {
 		return nil, err
 	}
 
 	var user *types.User
-	if err := ms.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&user); err != nil {
-		return nil, err
+	if err := ms.coll.FindOne(ctx, notDeleted(bson.M{"_id": oid})).Decode(&user); err != nil {
+		return nil, notFound(err, CodeUserNotFound, "no user found with id "+id)
 	}
 
 	return user, nil
 }
 
-func (ms *MongoUserStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
-	var user types.User
+func (ms *MongoUserStore) GetUsersByIDs(ctx context.Context, ids []primitive.ObjectID) (_ []*types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUsersByIDs")
+	defer tracing.End(span, &err)
 
-	if err := ms.coll.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+	cur, err := ms.coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
 		return nil, err
 	}
 
+	users := []*types.User{}
+	if err := cur.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (ms *MongoUserStore) GetUserByEmail(ctx context.Context, email string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUserByEmail")
+	defer tracing.End(span, &err)
+
+	var user types.User
+
+	if err := ms.coll.FindOne(ctx, notDeleted(bson.M{"email": email})).Decode(&user); err != nil {
+		return nil, notFound(err, CodeUserNotFound, "no user found with this email")
+	}
+
 	return &user, nil
 }
 
 func (ms *MongoUserStore) GetUsers(
 	ctx context.Context,
 	pagination *types.QueryNumericPaginate,
-) ([]*types.User, int64, error) {
+) (_ []*types.User, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetUsers")
+	defer tracing.End(span, &err)
+
 	pipeline := mongo.Pipeline{
-		bson.D{{Key: "$match", Value: bson.D{}}},
+		bson.D{{Key: "$match", Value: notDeleted(bson.M{})}},
 		bson.D{{Key: "$facet", Value: bson.D{
 			{Key: "data", Value: bson.A{
 				bson.D{{Key: "$skip", Value: pagination.Page}},
@@ -102,9 +146,16 @@ func (ms *MongoUserStore) GetUsers(
 	return aggResult[0].Data, aggResult[0].TotalCount, nil
 }
 
-func (ms *MongoUserStore) InsertUser(ctx context.Context, user *types.User) (*types.User, error) {
+func (ms *MongoUserStore) InsertUser(ctx context.Context, user *types.User) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.InsertUser")
+	defer tracing.End(span, &err)
+
+	user.Version = types.InitialVersion
 	res, err := ms.coll.InsertOne(ctx, user)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, &Error{Kind: ErrConflict, Code: CodeEmailTaken, Msg: "email already exist", Err: err}
+		}
 		return nil, err
 	}
 
@@ -112,18 +163,15 @@ func (ms *MongoUserStore) InsertUser(ctx context.Context, user *types.User) (*ty
 	return user, nil
 }
 
-func (ms *MongoUserStore) DeleteUser(ctx context.Context, id string) error {
-	oid, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return err
-	}
+func (ms *MongoUserStore) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.DeleteUser")
+	defer tracing.End(span, &err)
 
-	_, err = ms.coll.DeleteOne(ctx, bson.M{"_id": oid})
-	if err != nil {
+	if err := softDelete(ctx, ms.coll, id, CodeUserNotFound, "no user found with id "+id); err != nil {
 		return err
 	}
 
-	log.Printf("user deleted success id: %s deleted", id)
+	slog.InfoContext(ctx, "user deleted", "userID", id)
 
 	return nil
 }
@@ -132,23 +180,134 @@ func (ms *MongoUserStore) PutUser(
 	ctx context.Context,
 	params *types.UpdateUserParams,
 	id string,
-) (int64, error) {
-	oid, err := primitive.ObjectIDFromHex(id)
+	version int64,
+) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.PutUser")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var user types.User
+	err = ms.coll.FindOneAndUpdate(ctx, notDeleted(versionFilter(oid, version)), bson.D{
+		{Key: "$set", Value: params.ToBsonMap()},
+		{Key: "$inc", Value: bson.M{"version": 1}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, staleOrMissing(ctx, ms.coll, oid, CodeUserNotFound, "no user found with id "+id)
+	}
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	result, err := ms.coll.UpdateOne(ctx, bson.M{"_id": oid}, bson.D{{
-		Key: "$set", Value: params.ToBsonMap(),
-	}})
+	return &user, nil
+}
+
+func (ms *MongoUserStore) SetRoles(
+	ctx context.Context,
+	id string,
+	params *types.SetRolesParams,
+	version int64,
+) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.SetRoles")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return result.MatchedCount, nil
+	var user types.User
+	err = ms.coll.FindOneAndUpdate(ctx, notDeleted(versionFilter(oid, version)), bson.M{
+		"$set": bson.M{"roles": params.Roles, "hotelIDs": params.HotelIDs},
+		"$inc": bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, staleOrMissing(ctx, ms.coll, oid, CodeUserNotFound, "no user found with id "+id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (ms *MongoUserStore) GetDeletedUsers(
+	ctx context.Context,
+	pagination *types.QueryNumericPaginate,
+) (_ []*types.User, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.GetDeletedUsers")
+	defer tracing.End(span, &err)
+
+	return findDeleted[types.User](ctx, ms.coll, pagination)
+}
+
+func (ms *MongoUserStore) RestoreUser(ctx context.Context, id string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.RestoreUser")
+	defer tracing.End(span, &err)
+
+	user, err := restore[types.User](ctx, ms.coll, id, CodeUserNotFound, "no deleted user found with id "+id)
+	if !errors.Is(err, ErrNotFound) {
+		return user, err
+	}
+
+	// restore leaves erased users alone, tell them apart from a missing one
+	oid, _ := objectID(id)
+	erased, countErr := ms.coll.CountDocuments(ctx, bson.M{"_id": oid, "erasedAt": bson.M{"$exists": true}})
+	if countErr != nil {
+		return nil, countErr
+	}
+	if erased > 0 {
+		return nil, ConflictError(CodeUserErased, "user "+id+" was erased and cannot be restored")
+	}
+
+	return nil, err
+}
+
+// PurgeDeletedUsers keeps the users that still have bookings.
+func (ms *MongoUserStore) PurgeDeletedUsers(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.PurgeDeletedUsers")
+	defer tracing.End(span, &err)
+
+	return purgeDeleted(ctx, ms.coll, before, ms.db.Collection(bookingCollection), "userID")
+}
+
+func (ms *MongoUserStore) EraseUser(ctx context.Context, id string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserStore.EraseUser")
+	defer tracing.End(span, &err)
+
+	oid, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var user types.User
+	err = ms.coll.FindOneAndUpdate(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{
+			"firstName":         types.ErasedFirstName,
+			"lastName":          types.ErasedLastName,
+			"email":             types.ErasedEmail(oid),
+			"EncryptedPassword": "",
+			"isAdmin":           false,
+			"erasedAt":          now,
+			"deletedAt":         now,
+		},
+		"$unset": bson.M{"roles": "", "hotelIDs": ""},
+		"$inc":   bson.M{"version": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+	if err != nil {
+		return nil, notFound(err, CodeUserNotFound, "no user found with id "+id)
+	}
+
+	slog.InfoContext(ctx, "user erased", "userID", id)
+
+	return &user, nil
 }
 
 func (ms *MongoUserStore) Drop(ctx context.Context) error {
-	log.Printf("dropping %s collection", userCollection)
+	slog.InfoContext(ctx, "dropping collection", "collection", userCollection)
 	return ms.coll.Drop(ctx)
 }
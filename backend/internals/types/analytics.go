@@ -0,0 +1,94 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnalyticsPeriod is the bucket the figures are grouped by. Weeks start on
+// Monday, every bucket is in UTC.
+type AnalyticsPeriod string
+
+const (
+	AnalyticsDay   AnalyticsPeriod = "day"
+	AnalyticsWeek  AnalyticsPeriod = "week"
+	AnalyticsMonth AnalyticsPeriod = "month"
+)
+
+// AnalyticsParam covers the nights from From up to Till, excluded.
+type AnalyticsParam struct {
+	From   time.Time
+	Till   time.Time
+	Period AnalyticsPeriod
+}
+
+// BookingFact is what the bookings of one room add to a period:
+//   - NightsSold, the nights of live stays falling in the period
+//   - Revenue, those nights at the rate they were booked at, UnratedNights
+//     the ones of bookings made before rates were kept
+//   - Arrivals, Cancellations and StayNights, the stays starting in the
+//     period, the canceled ones and the nights of the live ones
+//   - Booked and BookedNights, the stays booked during the period, canceled
+//     since or not
+//
+// A stay split by a room move starts and is booked once, with the nights of
+// all its parts.
+type BookingFact struct {
+	Start         time.Time          `bson:"start"`
+	RoomID        primitive.ObjectID `bson:"roomID"`
+	NightsSold    int64              `bson:"nightsSold"`
+	Revenue       int64              `bson:"revenue"`
+	UnratedNights int64              `bson:"unratedNights"`
+	Arrivals      int64              `bson:"arrivals"`
+	Cancellations int64              `bson:"cancellations"`
+	StayNights    int64              `bson:"stayNights"`
+	Booked        int64              `bson:"booked"`
+	BookedNights  int64              `bson:"bookedNights"`
+}
+
+// AnalyticsFigures are the revenue figures of a period. Amounts are in minor
+// units of the currency, priced at the rate the nights were booked at.
+type AnalyticsFigures struct {
+	// RoomNights is the capacity, the rooms times the nights of the period
+	RoomNights int64 `json:"roomNights"`
+	NightsSold int64 `json:"nightsSold"`
+	Revenue    int64 `json:"revenue"`
+	// Occupancy is NightsSold over RoomNights
+	Occupancy float64 `json:"occupancy"`
+	// ADR is the average daily rate, Revenue over NightsSold
+	ADR int64 `json:"adr"`
+	// RevPAR is the revenue per available room, Revenue over RoomNights
+	RevPAR           int64   `json:"revpar"`
+	Arrivals         int64   `json:"arrivals"`
+	Cancellations    int64   `json:"cancellations"`
+	CancellationRate float64 `json:"cancellationRate"`
+	// AverageStay is the average length in nights of the live stays starting
+	// in the period
+	AverageStay float64 `json:"averageStay"`
+	// Booked and BookedNights are the booking pace, what was booked during
+	// the period whatever the dates of the stay
+	Booked       int64 `json:"booked"`
+	BookedNights int64 `json:"bookedNights"`
+}
+
+type RoomTypeFigures struct {
+	Type RoomType `json:"type"`
+	AnalyticsFigures
+}
+
+type AnalyticsBucket struct {
+	Start time.Time `json:"start"`
+	AnalyticsFigures
+	RoomTypes []*RoomTypeFigures `json:"roomTypes"`
+}
+
+type HotelAnalytics struct {
+	HotelID  primitive.ObjectID `json:"hotelID"`
+	From     time.Time          `json:"from"`
+	Till     time.Time          `json:"till"`
+	Period   AnalyticsPeriod    `json:"period"`
+	Currency string             `json:"currency"`
+	Total    AnalyticsFigures   `json:"total"`
+	Periods  []*AnalyticsBucket `json:"periods"`
+}
@@ -0,0 +1,125 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditAction string
+
+const (
+	AuditUserCreated       AuditAction = "user.created"
+	AuditUserUpdated       AuditAction = "user.updated"
+	AuditUserDeleted       AuditAction = "user.deleted"
+	AuditUserRestored      AuditAction = "user.restored"
+	AuditUserErased        AuditAction = "user.erased"
+	AuditUserRolesChanged  AuditAction = "user.roles_changed"
+	AuditHotelUpdated      AuditAction = "hotel.updated"
+	AuditHotelDeleted      AuditAction = "hotel.deleted"
+	AuditHotelRestored     AuditAction = "hotel.restored"
+	AuditRoomDeleted       AuditAction = "room.deleted"
+	AuditRoomRestored      AuditAction = "room.restored"
+	AuditRoomBlocked       AuditAction = "room.blocked"
+	AuditRoomUnblocked     AuditAction = "room.unblocked"
+	AuditRoomHousekeeping  AuditAction = "room.housekeeping"
+	AuditBookingCreated    AuditAction = "booking.created"
+	AuditBookingCanceled   AuditAction = "booking.canceled"
+	AuditBookingMoved      AuditAction = "booking.moved"
+	AuditBookingCheckedIn  AuditAction = "booking.checked_in"
+	AuditBookingCheckedOut AuditAction = "booking.checked_out"
+	AuditPaymentRefunded   AuditAction = "payment.refunded"
+)
+
+type AuditTarget string
+
+const (
+	AuditTargetUser    AuditTarget = "user"
+	AuditTargetHotel   AuditTarget = "hotel"
+	AuditTargetRoom    AuditTarget = "room"
+	AuditTargetBooking AuditTarget = "booking"
+	AuditTargetPayment AuditTarget = "payment"
+)
+
+// AuditEntry records one mutation. Entries are only ever appended, the store
+// exposes no way to change or remove them but the redaction of an erasure
+// request.
+type AuditEntry struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	ActorID    primitive.ObjectID     `bson:"actorID,omitempty" json:"actorID,omitempty"`
+	ActorAdmin bool                   `bson:"actorAdmin" json:"actorAdmin"`
+	Action     AuditAction            `bson:"action" json:"action"`
+	TargetType AuditTarget            `bson:"targetType" json:"targetType"`
+	TargetID   primitive.ObjectID     `bson:"targetID" json:"targetID"`
+	Changes    map[string]AuditChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	Reason     string                 `bson:"reason,omitempty" json:"reason,omitempty"`
+	IP         string                 `bson:"ip" json:"ip"`
+	RequestID  string                 `bson:"requestID" json:"requestID"`
+	CreatedAt  time.Time              `bson:"createdAt" json:"createdAt"`
+	// Redacted entries lost their personal values to an erasure request
+	Redacted bool `bson:"redacted,omitempty" json:"redacted,omitempty"`
+}
+
+type AuditChange struct {
+	Before any `bson:"before" json:"before"`
+	After  any `bson:"after" json:"after"`
+}
+
+// AuditDiff lists the fields that differ between two states of a document,
+// before is nil on create and after is nil on delete. The json form is
+// compared so the fields hidden from the api, like the password hash, never
+// reach the log.
+func AuditDiff(before, after any) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]AuditChange{}
+	for field, value := range beforeFields {
+		if next, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, next) {
+			changes[field] = AuditChange{Before: value, After: next}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = AuditChange{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+func auditFields(doc any) (map[string]any, error) {
+	if doc == nil || reflect.ValueOf(doc).Kind() == reflect.Pointer && reflect.ValueOf(doc).IsNil() {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+type GetAuditRequest struct {
+	ActorID    string      `validate:"omitempty,id" query:"actorID"`
+	Action     AuditAction `validate:"omitempty,max=64" query:"action"`
+	TargetType AuditTarget `validate:"omitempty,oneof=user hotel room booking payment" query:"targetType"`
+	TargetID   string      `validate:"omitempty,id" query:"targetID"`
+	From       time.Time   `query:"from"`
+	Till       time.Time   `query:"till"`
+
+	*QueryNumericPaginate
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := &User{FirstName: "Foo", LastName: "Bar", EncryptedPassword: "hash", Version: 1}
+	after := &User{FirstName: "Baz", LastName: "Bar", EncryptedPassword: "other", Version: 2}
+
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]AuditChange{
+		"firstName": {Before: "Foo", After: "Baz"},
+		"version":   {Before: float64(1), After: float64(2)},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %v, got %v", expected, changes)
+	}
+
+	var missing *User
+	created, err := AuditDiff(missing, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created["firstName"].After != "Baz" || created["firstName"].Before != nil {
+		t.Errorf("expected every field of a created document, got %v", created)
+	}
+	if _, ok := created["EncryptedPassword"]; ok {
+		t.Errorf("fields hidden from the api must not be audited")
+	}
+}
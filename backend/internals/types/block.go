@@ -0,0 +1,38 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BlockType string
+
+const (
+	BlockMaintenance BlockType = "maintenance"
+	BlockOwnerUse    BlockType = "owner_use"
+	BlockOutOfOrder  BlockType = "out_of_order"
+)
+
+// RoomBlock takes a room out of service for the nights from FromDate to
+// TillDate, nobody can book or hold it meanwhile.
+type RoomBlock struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RoomID    primitive.ObjectID `bson:"roomID" json:"roomID"`
+	HotelID   primitive.ObjectID `bson:"hotelID" json:"hotelID"`
+	Type      BlockType          `bson:"type" json:"type"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	FromDate  time.Time          `bson:"fromDate" json:"fromDate"`
+	TillDate  time.Time          `bson:"tillDate" json:"tillDate"`
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type RoomBlockParam struct {
+	RoomID    string             `json:"-"`
+	Type      BlockType          `json:"type"`
+	Reason    string             `json:"reason"`
+	FromDate  time.Time          `json:"fromDate"`
+	TillDate  time.Time          `json:"tillDate"`
+	CreatedBy primitive.ObjectID `json:"-"`
+}
@@ -1,7 +1,9 @@
 package types
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,14 +17,51 @@ type Booking struct {
 	FromDate    time.Time          `bson:"fromDate,omitempty" json:"fromDate,omitempty"`
 	TillDate    time.Time          `bson:"tillDate,omitempty" json:"tillDate,omitempty"`
 	Canceled    bool               `bson:"canceled,omitempty" json:"canceled,omitempty"`
+	PaymentMode PaymentMode        `bson:"paymentMode,omitempty" json:"paymentMode,omitempty"`
+	Version     int64              `bson:"version" json:"version"`
+	// NightlyRate is the rate of the room in minor units when booked, the
+	// bookings made before rates were kept have none
+	NightlyRate int64 `bson:"nightlyRate,omitempty" json:"nightlyRate,omitempty"`
+	// Code is the confirmation code given to the guest, the bookings made
+	// before codes existed have none
+	Code string `bson:"code,omitempty" json:"code,omitempty"`
+	// GroupID is set on the bookings made by a group booking
+	GroupID *primitive.ObjectID `bson:"groupID,omitempty" json:"groupID,omitempty"`
+	// SplitFromID is the original booking of a stay split by a room move,
+	// this booking holds the nights spent in the new room
+	SplitFromID *primitive.ObjectID `bson:"splitFromID,omitempty" json:"splitFromID,omitempty"`
+	// Moves is the history of the room moves, oldest first
+	Moves        []RoomMove `bson:"moves,omitempty" json:"moves,omitempty"`
+	CheckedInAt  *time.Time `bson:"checkedInAt,omitempty" json:"checkedInAt,omitempty"`
+	CheckedOutAt *time.Time `bson:"checkedOutAt,omitempty" json:"checkedOutAt,omitempty"`
+	// ErasedAt is set when the guest asked for erasure, the booking is kept
+	// for accounting without its personal fields
+	ErasedAt *time.Time `bson:"erasedAt,omitempty" json:"erasedAt,omitempty"`
+}
+
+// OriginalID is the booking a split stay started with, it holds the payments
+// of the whole stay.
+func (b *Booking) OriginalID() primitive.ObjectID {
+	if b.SplitFromID != nil {
+		return *b.SplitFromID
+	}
+	return b.ID
 }
 
 type BookingParam struct {
-	RoomID      string             `json:"roomID,omitempty"`
+	RoomID string `json:"roomID,omitempty"`
+	// RoomType books any free room of the type when no RoomID is given
+	RoomType    RoomType           `json:"roomType,omitempty"`
 	UserID      primitive.ObjectID `json:"userID,omitempty"`
 	CountPerson int                `json:"countPerson,omitempty"`
 	FromDate    time.Time          `json:"fromDate,omitempty"`
 	TillDate    time.Time          `json:"tillDate,omitempty"`
+	PaymentMode PaymentMode        `json:"paymentMode,omitempty"`
+	// Card and ChallengeResponse only reach the payment provider
+	Card              *Card  `json:"card,omitempty"`
+	ChallengeResponse string `json:"challengeResponse,omitempty"`
+	// HoldID is the hold the booking confirms, it is released with the insert
+	HoldID string `json:"-"`
 }
 
 func NewBookingFromParams(params *BookingParam) (*Booking, error) {
@@ -37,9 +76,90 @@ func NewBookingFromParams(params *BookingParam) (*Booking, error) {
 		CountPerson: params.CountPerson,
 		FromDate:    params.FromDate,
 		TillDate:    params.TillDate,
+		PaymentMode: params.PaymentMode,
+		Version:     InitialVersion,
 	}, nil
 }
 
+// BookingDetail is a booking with the documents asked for by expand. HotelID
+// is always set, it decides who may read the booking.
+type BookingDetail struct {
+	Booking `bson:",inline"`
+	HotelID primitive.ObjectID `bson:"hotelID" json:"hotelID"`
+	Room    *Room              `bson:"room,omitempty" json:"room,omitempty"`
+	Hotel   *Hotel             `bson:"hotel,omitempty" json:"hotel,omitempty"`
+	User    *User              `bson:"user,omitempty" json:"user,omitempty"`
+}
+
+// BookingExpand names a document joined to a booking detail.
+type BookingExpand string
+
+const (
+	ExpandRoom  BookingExpand = "room"
+	ExpandHotel BookingExpand = "hotel"
+	ExpandUser  BookingExpand = "user"
+)
+
+// BookingStatus is where a booking stands, derived from its fields.
+type BookingStatus string
+
+const (
+	// BookingConfirmed is live and not checked in yet
+	BookingConfirmed  BookingStatus = "confirmed"
+	BookingCheckedIn  BookingStatus = "checked_in"
+	BookingCheckedOut BookingStatus = "checked_out"
+	BookingCanceled   BookingStatus = "canceled"
+)
+
+// GetBookingsRequest filters the bookings, every filter is optional. FromDate
+// and TillDate keep the stays overlapping that range, CreatedFrom and
+// CreatedTill the bookings made in that range. Sort is createdAt, fromDate or
+// tillDate, descending with a leading "-".
+type GetBookingsRequest struct {
+	HotelID     string        `validate:"omitempty,id" query:"hotelID"`
+	RoomID      string        `validate:"omitempty,id" query:"roomID"`
+	UserID      string        `validate:"omitempty,id" query:"userID"`
+	Email       string        `validate:"omitempty,email" query:"email"`
+	Status      BookingStatus `validate:"omitempty,oneof=confirmed checked_in checked_out canceled" query:"status"`
+	FromDate    time.Time     `query:"fromDate"`
+	TillDate    time.Time     `query:"tillDate"`
+	CreatedFrom time.Time     `query:"createdFrom"`
+	CreatedTill time.Time     `query:"createdTill"`
+	Sort        string        `validate:"omitempty,oneof=createdAt -createdAt fromDate -fromDate tillDate -tillDate" query:"sort"`
+
+	QueryCursorPaginate[primitive.ObjectID]
+}
+
+// NewConfirmationCode is 8 letters of the reference alphabet in two groups,
+// like 7KQ4-MX2P, easy to read over the phone.
+func NewConfirmationCode() (string, error) {
+	code, err := randomReference(8)
+	if err != nil {
+		return "", err
+	}
+	return code[:4] + "-" + code[4:], nil
+}
+
+// NormalizeConfirmationCode reads a code as typed by a guest, in any case and
+// with or without the dash. It reports false when it can not be a code.
+func NormalizeConfirmationCode(input string) (string, bool) {
+	var code []byte
+	for _, r := range strings.ToUpper(input) {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r < utf8.RuneSelf && strings.IndexByte(referenceAlphabet, byte(r)) >= 0:
+			code = append(code, byte(r))
+		default:
+			return "", false
+		}
+	}
+	if len(code) != 8 {
+		return "", false
+	}
+	return string(code[:4]) + "-" + string(code[4:]), true
+}
+
 type CancelBookingParam struct {
 	Canceled bool `json:"canceled"`
 }
@@ -47,3 +167,33 @@ type CancelBookingParam struct {
 func (p *CancelBookingParam) ToBsonMap() bson.M {
 	return bson.M{"canceled": p.Canceled}
 }
+
+// RoomMove records a stay moved to another room from Date on. A move after the
+// first night splits the stay, SplitBookingID is then the booking of the
+// remaining nights.
+type RoomMove struct {
+	FromRoomID     primitive.ObjectID  `bson:"fromRoomID" json:"fromRoomID"`
+	ToRoomID       primitive.ObjectID  `bson:"toRoomID" json:"toRoomID"`
+	Date           time.Time           `bson:"date" json:"date"`
+	SplitBookingID *primitive.ObjectID `bson:"splitBookingID,omitempty" json:"splitBookingID,omitempty"`
+	Reason         string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	MovedBy        primitive.ObjectID  `bson:"movedBy" json:"movedBy"`
+	MovedAt        time.Time           `bson:"movedAt" json:"movedAt"`
+}
+
+// MoveBookingParam moves a booking to RoomID, the whole stay when Date is zero
+// or the nights from Date on.
+type MoveBookingParam struct {
+	BookingID string
+	RoomID    string
+	Date      time.Time
+	Reason    string
+	MovedBy   primitive.ObjectID
+	Version   int64
+}
+
+// MovedBooking answers a room move, Remainder is set when the stay was split.
+type MovedBooking struct {
+	Booking   *Booking `json:"booking"`
+	Remainder *Booking `json:"remainder,omitempty"`
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmationCode(t *testing.T) {
+	code, err := NewConfirmationCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 9 || code[4] != '-' {
+		t.Fatalf("expected a code like ABCD-EFGH, got %q", code)
+	}
+	if strings.ContainsAny(code, "01IO") {
+		t.Fatalf("expected no look-alike letters, got %q", code)
+	}
+
+	for input, want := range map[string]string{
+		code:                              code,
+		strings.ToLower(code):             code,
+		strings.ReplaceAll(code, "-", ""): code,
+		" " + strings.ReplaceAll(code, "-", " ") + " ": code,
+	} {
+		got, ok := NormalizeConfirmationCode(input)
+		if !ok || got != want {
+			t.Fatalf("expected %q to read as %q, got %q", input, want, got)
+		}
+	}
+
+	for _, input := range []string{"", "ABCD-EFG", "ABCD-EFGHJ", "ABCD-EF0H", "ABCD_EFGH", "ÀBCD-EFGH"} {
+		if got, ok := NormalizeConfirmationCode(input); ok {
+			t.Fatalf("expected %q to be refused, got %q", input, got)
+		}
+	}
+}
@@ -12,11 +12,11 @@ type (
 	QueryNumericPaginate struct {
 		Limit int64 `query:"limit" validate:"numeric,max=100,omitempty"`
 		Page  int   `query:"page" validate:"numeric,omitempty"`
-		Skip  int64 `query:"skip" validate:"numeric,omitempty"`
+		Skip  int64 `query:"-" validate:"numeric,omitempty"`
 	}
 
 	QueryCursorPaginate[T any] struct {
-		LastID string `query:"lastID" validate:"id"`
+		LastID string `query:"lastID" validate:"omitempty,id"`
 		Limit  int64  `query:"limit" validate:"numeric,max=100,omitempty"`
 		PaginateWithID[T]
 	}
@@ -40,3 +40,7 @@ func NewMongoQueryCursorPaginate(id string, limit int) QueryCursorPaginate[primi
 func (p QueryCursorPaginate[T]) GetLastID() (primitive.ObjectID, error) {
 	return primitive.ObjectIDFromHex(p.LastID)
 }
+
+// InitialVersion is given to every document on insert, each update increments
+// it and the handlers expose it as the ETag.
+const InitialVersion int64 = 1
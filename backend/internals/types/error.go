@@ -6,20 +6,22 @@ import (
 
 type Error struct {
 	*ResGeneric
+	// Code is the stable problem code, derived from the status when empty
+	Code string
+
+	cause error
 }
 
 func (err Error) Error() string {
 	return err.Msg
 }
 
-func NewError(err error, code int, msg string) *Error {
-	errors := make(map[string]interface{})
-	// TODO: add switch other variant
-	switch v := err.(type) {
-	default:
-		errors["body"] = v.Error()
-	}
+// Unwrap exposes the cause for the logs, it is never sent to the client.
+func (err Error) Unwrap() error {
+	return err.cause
+}
 
+func NewError(err error, code int, msg string) *Error {
 	message := http.StatusText(code)
 	if msg != "" {
 		message = msg
@@ -29,7 +31,30 @@ func NewError(err error, code int, msg string) *Error {
 		ResGeneric: &ResGeneric{
 			Status: code,
 			Msg:    message,
-			Errors: errors,
 		},
+		cause: err,
+	}
+}
+
+// Problem is the RFC 7807 application/problem+json body of every error
+// response. Code is the stable identifier clients should branch on.
+type Problem struct {
+	Type   string                 `json:"type"`
+	Title  string                 `json:"title"`
+	Status int                    `json:"status"`
+	Detail string                 `json:"detail,omitempty"`
+	Code   string                 `json:"code"`
+	Errors map[string]interface{} `json:"errors,omitempty"`
+}
+
+const ProblemContentType = "application/problem+json"
+
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
 	}
 }
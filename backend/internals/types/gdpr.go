@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DataExport is the archive answering a subject access request, everything
+// the api keeps about a user.
+type DataExport struct {
+	GeneratedAt  time.Time       `json:"generatedAt"`
+	User         *User           `json:"user"`
+	Bookings     []*Booking      `json:"bookings"`
+	Payments     []*Payment      `json:"payments"`
+	Groups       []*GroupBooking `json:"groups"`
+	AuditEntries []*AuditEntry   `json:"auditEntries"`
+}
+
+const (
+	ErasedFirstName = "Erased"
+	ErasedLastName  = "User"
+)
+
+// ErasedEmail keeps the unique email index satisfied once the real address
+// is gone, the .invalid TLD can never be delivered.
+func ErasedEmail(id primitive.ObjectID) string {
+	return "erased-" + id.Hex() + "@erased.invalid"
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"crypto/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const MaxGroupRooms = 50
+
+// GroupBooking ties the bookings of a party staying together. Each room keeps
+// its own booking, canceled one by one.
+type GroupBooking struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Reference is the shared code given to the hotel and the guests
+	Reference  string               `bson:"reference" json:"reference"`
+	HotelID    primitive.ObjectID   `bson:"hotelID" json:"hotelID"`
+	UserID     primitive.ObjectID   `bson:"userID" json:"userID"`
+	LeadGuest  LeadGuest            `bson:"leadGuest" json:"leadGuest"`
+	FromDate   time.Time            `bson:"fromDate" json:"fromDate"`
+	TillDate   time.Time            `bson:"tillDate" json:"tillDate"`
+	BookingIDs []primitive.ObjectID `bson:"bookingIDs" json:"bookingIDs"`
+	CreatedAt  time.Time            `bson:"createdAt" json:"createdAt"`
+	// ErasedAt is set when the booker asked for erasure, the lead guest is
+	// anonymized then
+	ErasedAt *time.Time `bson:"erasedAt,omitempty" json:"erasedAt,omitempty"`
+}
+
+// LeadGuest is the contact of the party at the hotel, not always the user who
+// booked.
+type LeadGuest struct {
+	FirstName string `bson:"firstName" json:"firstName"`
+	LastName  string `bson:"lastName" json:"lastName"`
+	Email     string `bson:"email" json:"email"`
+	Phone     string `bson:"phone,omitempty" json:"phone,omitempty"`
+}
+
+// GroupRoomParam asks for a room by id, or for Count rooms of a Type.
+type GroupRoomParam struct {
+	RoomID      string   `json:"roomID,omitempty"`
+	Type        RoomType `json:"type,omitempty"`
+	Count       int      `json:"count,omitempty"`
+	CountPerson int      `json:"countPerson"`
+}
+
+type GroupBookingParam struct {
+	HotelID   string             `json:"hotelID"`
+	UserID    primitive.ObjectID `json:"-"`
+	LeadGuest LeadGuest          `json:"leadGuest"`
+	FromDate  time.Time          `json:"fromDate"`
+	TillDate  time.Time          `json:"tillDate"`
+	Rooms     []GroupRoomParam   `json:"rooms"`
+}
+
+// GroupBookingWithBookings is the group as answered by the api.
+type GroupBookingWithBookings struct {
+	*GroupBooking
+	Bookings []*Booking `json:"bookings"`
+}
+
+// referenceAlphabet leaves out 0, 1, I and O, easily mistaken when read out.
+// Its 32 letters divide 256, every letter is as likely.
+const referenceAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+func randomReference(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = referenceAlphabet[int(b[i])%len(referenceAlphabet)]
+	}
+	return string(b), nil
+}
+
+func NewGroupReference() (string, error) {
+	reference, err := randomReference(8)
+	if err != nil {
+		return "", err
+	}
+	return "GRP-" + reference, nil
+}
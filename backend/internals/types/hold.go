@@ -0,0 +1,39 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultHoldMinutes = 15
+	MaxHoldMinutes     = 30
+)
+
+// Hold reserves a room for a stay while its guest checks out. An expired hold
+// blocks nothing, the TTL index removes it later.
+type Hold struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RoomID      primitive.ObjectID `bson:"roomID" json:"roomID"`
+	UserID      primitive.ObjectID `bson:"userID" json:"userID"`
+	CountPerson int                `bson:"countPerson" json:"countPerson"`
+	FromDate    time.Time          `bson:"fromDate" json:"fromDate"`
+	TillDate    time.Time          `bson:"tillDate" json:"tillDate"`
+	ExpiresAt   time.Time          `bson:"expiresAt" json:"expiresAt"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+func (h *Hold) Expired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+type HoldParam struct {
+	RoomID      string             `json:"-"`
+	UserID      primitive.ObjectID `json:"-"`
+	CountPerson int                `json:"countPerson"`
+	FromDate    time.Time          `json:"fromDate"`
+	TillDate    time.Time          `json:"tillDate"`
+	// Minutes the room is held, DefaultHoldMinutes when zero
+	Minutes int `json:"minutes"`
+}
@@ -1,21 +1,26 @@
 package types
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Hotel struct {
-	ID       primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
-	Name     string               `bson:"name" json:"name"`
-	Location string               `bson:"location" json:"location"`
-	Rooms    []primitive.ObjectID `bson:"rooms" json:"rooms"`
-	Rating   int                  `bson:"rating" json:"rating"`
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string               `bson:"name" json:"name"`
+	Location  string               `bson:"location" json:"location"`
+	Rooms     []primitive.ObjectID `bson:"rooms" json:"rooms"`
+	Rating    int                  `bson:"rating" json:"rating"`
+	Version   int64                `bson:"version" json:"version"`
+	DeletedAt *time.Time           `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
 }
 
 type UpdateHotelParams struct {
 	Name     string             `json:"name,omitempty"`
 	Location string             `json:"location,omitempty"`
+	Rating   int                `json:"rating,omitempty"`
 	RoomID   primitive.ObjectID `json:"roomId,omitempty"`
 }
 
@@ -29,12 +34,16 @@ func (p *UpdateHotelParams) ToBsonMap() bson.M {
 	if len(p.Location) > 0 {
 		setValues["location"] = p.Location
 	}
+	if p.Rating > 0 {
+		setValues["rating"] = p.Rating
+	}
 	if len(setValues) > 0 {
 		update["$set"] = setValues
 	}
-	if len(p.RoomID) > 0 {
+	if !p.RoomID.IsZero() {
 		update["$push"] = bson.M{"rooms": p.RoomID}
 	}
+	update["$inc"] = bson.M{"version": 1}
 
 	return update
 }
@@ -47,7 +56,7 @@ type HotelQueryParams struct {
 }
 
 type GetHotelsRequest struct {
-	Rooms  bool `validate:"boolean,omitempty" query:"room"`
+	Rooms  bool `validate:"boolean,omitempty" query:"rooms"`
 	Rating int  `validate:"numeric,omitempty" query:"rating"`
 
 	*QueryNumericPaginate
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HousekeepingStatus is the cleaning state of a room, apart from its occupancy.
+type HousekeepingStatus string
+
+const (
+	HousekeepingClean      HousekeepingStatus = "clean"
+	HousekeepingDirty      HousekeepingStatus = "dirty"
+	HousekeepingInspected  HousekeepingStatus = "inspected"
+	HousekeepingInProgress HousekeepingStatus = "in_progress"
+)
+
+// Ready tells if a guest can check in. Rooms stored before housekeeping was
+// tracked have no status and count as clean.
+func (s HousekeepingStatus) Ready() bool {
+	return s == "" || s == HousekeepingClean || s == HousekeepingInspected
+}
+
+// HousekeepingTask is a room to prepare on a day. Arrival is the check in
+// expected in the room that day, if any.
+type HousekeepingTask struct {
+	RoomID       primitive.ObjectID  `json:"roomID"`
+	RoomType     RoomType            `json:"roomType"`
+	Housekeeping HousekeepingStatus  `json:"housekeeping"`
+	Arrival      *time.Time          `json:"arrival,omitempty"`
+	BookingID    *primitive.ObjectID `json:"bookingID,omitempty"`
+	// Departure is set when a guest leaves the room that day
+	Departure bool `json:"departure,omitempty"`
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+type IdempotencyState string
+
+const (
+	IdempotencyProcessing IdempotencyState = "processing"
+	IdempotencyCompleted  IdempotencyState = "completed"
+)
+
+// IdempotencyRecord remembers the first response given to an Idempotency-Key.
+// The key is scoped to the caller, see middleware.WithIdempotency.
+type IdempotencyRecord struct {
+	Key            string           `bson:"_id"`
+	RequestHash    string           `bson:"requestHash"`
+	State          IdempotencyState `bson:"state"`
+	ResponseStatus int              `bson:"responseStatus,omitempty"`
+	ResponseBody   []byte           `bson:"responseBody,omitempty"`
+	ContentType    string           `bson:"contentType,omitempty"`
+	CreatedAt      time.Time        `bson:"createdAt"`
+	ExpireAt       time.Time        `bson:"expireAt"`
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PaymentMode string
+
+const (
+	// PayNow charges the whole stay when booking
+	PayNow PaymentMode = "pay_now"
+	// PayAtHotel charges nothing online, the guest settles at the front desk
+	PayAtHotel PaymentMode = "pay_at_hotel"
+	// PayDeposit charges DepositPercent of the stay when booking
+	PayDeposit PaymentMode = "deposit"
+)
+
+// Charged tells if the mode takes money when booking.
+func (m PaymentMode) Charged() bool {
+	return m == PayNow || m == PayDeposit
+}
+
+type PaymentStatus string
+
+const (
+	PaymentPending           PaymentStatus = "pending"
+	PaymentAuthorized        PaymentStatus = "authorized"
+	PaymentCaptured          PaymentStatus = "captured"
+	PaymentPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentRefunded          PaymentStatus = "refunded"
+	PaymentVoided            PaymentStatus = "voided"
+)
+
+// Payment is the money side of a booking. Amounts are in minor units (cents)
+// of Currency.
+type Payment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	BookingID   primitive.ObjectID `bson:"bookingID" json:"bookingID"`
+	UserID      primitive.ObjectID `bson:"userID" json:"userID"`
+	Mode        PaymentMode        `bson:"mode" json:"mode"`
+	Status      PaymentStatus      `bson:"status" json:"status"`
+	Currency    string             `bson:"currency" json:"currency"`
+	Total       int64              `bson:"total" json:"total"`
+	Captured    int64              `bson:"captured" json:"captured"`
+	Refunded    int64              `bson:"refunded" json:"refunded"`
+	Provider    string             `bson:"provider,omitempty" json:"provider,omitempty"`
+	ProviderRef string             `bson:"providerRef,omitempty" json:"-"`
+	CardLast4   string             `bson:"cardLast4,omitempty" json:"cardLast4,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	Version     int64              `bson:"version" json:"version"`
+}
+
+// Refundable is what was captured and not refunded yet.
+func (p *Payment) Refundable() int64 {
+	return p.Captured - p.Refunded
+}
+
+// Card is only handed to the payment provider, it is never stored.
+type Card struct {
+	Number   string `json:"number"`
+	ExpMonth int    `json:"expMonth"`
+	ExpYear  int    `json:"expYear"`
+	CVC      string `json:"cvc"`
+}
+
+func (c *Card) Last4() string {
+	if len(c.Number) < 4 {
+		return ""
+	}
+	return c.Number[len(c.Number)-4:]
+}
+
+type RefundPaymentParams struct {
+	// Amount in minor units, the whole refundable amount when zero
+	Amount int64 `json:"amount"`
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportKind is a front desk list of the bookings of a hotel on a day.
+type ReportKind string
+
+const (
+	// ReportArrivals lists the stays starting on the day
+	ReportArrivals ReportKind = "arrivals"
+	// ReportDepartures lists the stays ending on the day
+	ReportDepartures ReportKind = "departures"
+	// ReportInHouse lists the guests staying the night after the day
+	ReportInHouse ReportKind = "in-house"
+)
+
+// ReportLine is one booking of a report. Balance is what the guest still owes,
+// in minor units of Currency.
+type ReportLine struct {
+	BookingID    primitive.ObjectID `json:"bookingID"`
+	RoomID       primitive.ObjectID `json:"roomID"`
+	RoomType     RoomType           `json:"roomType"`
+	GuestName    string             `json:"guestName"`
+	CountPerson  int                `json:"countPerson"`
+	FromDate     time.Time          `json:"fromDate"`
+	TillDate     time.Time          `json:"tillDate"`
+	CheckedInAt  *time.Time         `json:"checkedInAt,omitempty"`
+	CheckedOutAt *time.Time         `json:"checkedOutAt,omitempty"`
+	PaymentMode  PaymentMode        `json:"paymentMode"`
+	Balance      int64              `json:"balance"`
+	Currency     string             `json:"currency"`
+}
+
+type DailyReport struct {
+	HotelID primitive.ObjectID `json:"hotelID"`
+	Kind    ReportKind         `json:"kind"`
+	Date    string             `json:"date"`
+	Lines   []*ReportLine      `json:"lines"`
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,6 +23,19 @@ type Room struct {
 	Price     float64            `bson:"price" json:"price"`
 	HotelID   primitive.ObjectID `bson:"hotelID" json:"hotelID"`
 	Status    RoomStatus         `bson:"status" json:"status"`
+	// Housekeeping is the cleaning state, see HousekeepingStatus.Ready
+	Housekeeping   HousekeepingStatus `bson:"housekeeping,omitempty" json:"housekeeping,omitempty"`
+	HousekeepingAt *time.Time         `bson:"housekeepingAt,omitempty" json:"housekeepingAt,omitempty"`
+	Version        int64              `bson:"version" json:"version"`
+	DeletedAt      *time.Time         `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+}
+
+// Rate is the nightly price of the room, BasePrice when no rate is set.
+func (r *Room) Rate() float64 {
+	if r.Price == 0 {
+		return r.BasePrice
+	}
+	return r.Price
 }
 
 type RoomStatus string
@@ -29,10 +44,31 @@ const (
 	OccupiedRoom  RoomStatus = "occupied"
 	BookedRoom    RoomStatus = "booked"
 	AvailableRoom RoomStatus = "available"
+	// BlockedRoom is out of service, see RoomBlock
+	BlockedRoom RoomStatus = "blocked"
 )
 
 type GetRoomsRequest struct {
-	Status []RoomStatus
+	Status []RoomStatus `query:"status"`
 
 	QueryCursorPaginate[primitive.ObjectID]
 }
+
+// FreeRoomsParam looks for the rooms of a hotel free for the whole stay, of
+// any type when Type is empty. The holds of UserID do not block its rooms.
+type FreeRoomsParam struct {
+	HotelID  string
+	Type     RoomType
+	FromDate time.Time
+	TillDate time.Time
+	UserID   primitive.ObjectID
+}
+
+// TypeAvailability is the inventory of a room type for a stay.
+type TypeAvailability struct {
+	Type      RoomType `json:"type"`
+	Total     int      `json:"total"`
+	Available int      `json:"available"`
+	// FromRate is the lowest nightly rate of the available rooms
+	FromRate float64 `json:"fromRate,omitempty"`
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"slices"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
@@ -17,6 +20,49 @@ type User struct {
 	Email             string             `bson:"email" json:"email"`
 	EncryptedPassword string             `bson:"EncryptedPassword" json:"-"`
 	IsAdmin           bool               `bson:"isAdmin" json:"isAdmin"`
+	Roles             []Role             `bson:"roles,omitempty" json:"roles,omitempty"`
+	// HotelIDs are the hotels a staff user works at
+	HotelIDs  []primitive.ObjectID `bson:"hotelIDs,omitempty" json:"hotelIDs,omitempty"`
+	Version   int64                `bson:"version" json:"version"`
+	DeletedAt *time.Time           `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	// ErasedAt is set once the personal fields were anonymized
+	ErasedAt *time.Time `bson:"erasedAt,omitempty" json:"erasedAt,omitempty"`
+}
+
+// Role opens a part of the admin api to a user that is not an admin.
+type Role string
+
+const (
+	RoleRevenueManager Role = "revenue_manager"
+	// RoleStaff works at the hotels of HotelIDs
+	RoleStaff Role = "staff"
+)
+
+// HasRole reports whether the user may act as role, admins hold every role.
+func (u *User) HasRole(role Role) bool {
+	if u.IsAdmin {
+		return true
+	}
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// WorksAt reports whether the user is staff of the hotel, admins work at
+// every hotel.
+func (u *User) WorksAt(hotelID primitive.ObjectID) bool {
+	if u.IsAdmin {
+		return true
+	}
+	return u.HasRole(RoleStaff) && slices.Contains(u.HotelIDs, hotelID)
+}
+
+type SetRolesParams struct {
+	Roles    []Role               `json:"roles"`
+	HotelIDs []primitive.ObjectID `json:"hotelIDs"`
 }
 
 type CreateUserParams struct {
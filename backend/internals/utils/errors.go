@@ -27,6 +27,7 @@ func ValidatorError(err error) *types.Error {
 			Msg:    http.StatusText(http.StatusBadRequest),
 			Errors: errors,
 		},
+		Code: "validation_failed",
 	}
 }
 
@@ -76,6 +77,7 @@ func InvalidCredError() *types.Error {
 			Status: http.StatusBadRequest,
 			Msg:    "invalid credentials",
 		},
+		Code: "invalid_credentials",
 	}
 }
 
@@ -104,3 +106,93 @@ func InternalServerError(errorMessage string) *types.Error {
 		},
 	}
 }
+
+func TooManyRequestsError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusTooManyRequests,
+			Msg:    http.StatusText(http.StatusTooManyRequests),
+		},
+	}
+}
+
+func IdempotencyKeyReusedError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusUnprocessableEntity,
+			Msg:    "Idempotency-Key was already used with a different request",
+		},
+		Code: "idempotency_key_reused",
+	}
+}
+
+func IdempotencyKeyInProgressError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusConflict,
+			Msg:    "a request with this Idempotency-Key is still in progress",
+		},
+		Code: "idempotency_key_in_progress",
+	}
+}
+
+func PreconditionRequiredError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusPreconditionRequired,
+			Msg:    "If-Match header with the current ETag is required",
+		},
+		Code: "if_match_required",
+	}
+}
+
+func PaymentDeclinedError(declineCode string) *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusPaymentRequired,
+			Msg:    "the payment was declined",
+			Errors: map[string]interface{}{"declineCode": declineCode},
+		},
+		Code: "payment_declined",
+	}
+}
+
+func PaymentActionRequiredError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusPaymentRequired,
+			Msg:    "the card issuer asks for authentication, send the booking again with the challengeResponse",
+		},
+		Code: "payment_action_required",
+	}
+}
+
+func PaymentModeUnavailableError(mode string) *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusUnprocessableEntity,
+			Msg:    fmt.Sprintf("payment mode %s is not available, pay at the hotel instead", mode),
+		},
+		Code: "payment_mode_unavailable",
+	}
+}
+
+func PaymentNotRefundableError(errorMessage string) *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusConflict,
+			Msg:    errorMessage,
+		},
+		Code: "payment_not_refundable",
+	}
+}
+
+func PaymentProviderError() *types.Error {
+	return &types.Error{
+		ResGeneric: &types.ResGeneric{
+			Status: http.StatusBadGateway,
+			Msg:    "the payment provider is unavailable, try again later",
+		},
+		Code: "payment_provider_unavailable",
+	}
+}
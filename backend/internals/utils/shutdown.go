@@ -2,7 +2,7 @@ package utils
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os/signal"
 	"syscall"
 	"time"
@@ -14,12 +14,12 @@ func GraceFullyShutDown(rootCtx context.Context, cleanup func(ctx context.Contex
 
 	<-shutdown.Done()
 
-	log.Println("gracefully shutting down in progress...")
+	slog.Info("gracefully shutting down in progress...")
 
 	ctx, cancel := context.WithTimeout(rootCtx, 10*time.Second)
 	defer cancel()
 
 	cleanup(ctx)
 
-	log.Println("shutdown complete")
+	slog.Info("shutdown complete")
 }